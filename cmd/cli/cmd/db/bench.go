@@ -0,0 +1,173 @@
+package db
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Measure session page throughput against a SQLite database",
+	Long: `Runs parallel session page loads, the same repository calls made by the
+session page handler, against an existing database with a configurable share
+of vote writes mixed in. Seed the database with "db seed" first.
+
+Music lookups are stubbed so only database work is measured. Single query
+benchmarks run with "go test -bench . ./internal/storage".`,
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := storage.NewSqliteDb(flagDbPath,
+			storage.WithJournalMode(flagBenchJournalMode),
+			storage.WithBusyTimeout(flagBenchBusyTimeout),
+			storage.WithMaxReaderConns(flagBenchParallel),
+		)
+		if err != nil {
+			log.Fatalln("Failed to connect to the database:", err)
+		}
+		defer db.Close()
+
+		ctx := cmd.Context()
+
+		sessions, err := db.GetAllSessions(ctx)
+		if err != nil {
+			log.Fatalln("Failed to get sessions:", err)
+		}
+
+		type benchTarget struct {
+			session    core.SessionEntity
+			players    []core.PlayerEntity
+			candidates []core.CandidateEntity
+		}
+
+		targets := make([]benchTarget, 0)
+		for _, session := range *sessions {
+			players, err := db.GetPlayers(ctx, session.Id)
+			if err != nil {
+				log.Fatalln("Failed to get players:", err)
+			}
+			candidates, err := db.GetAllCandidates(ctx, session.Id)
+			if err != nil {
+				log.Fatalln("Failed to get candidates:", err)
+			}
+			if len(*players) > 0 {
+				targets = append(targets, benchTarget{session, *players, *candidates})
+			}
+		}
+		if len(targets) == 0 {
+			log.Fatalln("No sessions with players found, run `db seed` first")
+		}
+
+		sessionService := core.NewSessionService(db, core.NewUserService(db), nil, core.NewMusicService(&benchMusicRepository{}))
+
+		var reads, writes, failures, locked atomic.Int64
+		deadline, cancel := context.WithTimeout(ctx, flagBenchDuration)
+		defer cancel()
+
+		log.Printf("Running %d workers for %s against %d sessions @ %s\n", flagBenchParallel, flagBenchDuration, len(targets), flagDbPath)
+
+		wg := sync.WaitGroup{}
+		for worker := 0; worker < flagBenchParallel; worker++ {
+			wg.Add(1)
+			go func(worker int) {
+				defer wg.Done()
+				r := rand.New(rand.NewSource(int64(worker)))
+
+				for deadline.Err() == nil {
+					target := targets[r.Intn(len(targets))]
+					player := target.players[r.Intn(len(target.players))]
+
+					var err error
+					if r.Float64() < flagBenchWriteRatio && len(target.candidates) > 0 {
+						candidate := target.candidates[r.Intn(len(target.candidates))]
+						err = toggleVote(ctx, db, target.session.Id, player.PlayerId, candidate.Id)
+						writes.Add(1)
+					} else {
+						_, err = sessionService.GetSessionView(ctx, target.session.Id, player.PlayerId)
+						reads.Add(1)
+					}
+
+					if err != nil {
+						failures.Add(1)
+						if strings.Contains(err.Error(), "database is locked") {
+							locked.Add(1)
+						}
+					}
+				}
+			}(worker)
+		}
+		wg.Wait()
+
+		seconds := flagBenchDuration.Seconds()
+		log.Printf("Session page loads: %d (%.1f/s)\n", reads.Load(), float64(reads.Load())/seconds)
+		log.Printf("Vote writes:        %d (%.1f/s)\n", writes.Load(), float64(writes.Load())/seconds)
+		log.Printf("Failures:           %d (%d database is locked)\n", failures.Load(), locked.Load())
+	},
+}
+
+var (
+	flagBenchParallel    int
+	flagBenchDuration    time.Duration
+	flagBenchWriteRatio  float64
+	flagBenchJournalMode string
+	flagBenchBusyTimeout time.Duration
+)
+
+func init() {
+	benchCmd.Flags().IntVarP(&flagBenchParallel, "parallel", "n", 16, "The number of concurrent workers")
+	benchCmd.Flags().DurationVarP(&flagBenchDuration, "duration", "d", 10*time.Second, "How long to run for")
+	benchCmd.Flags().Float64Var(&flagBenchWriteRatio, "write-ratio", 0.1, "The fraction of operations that toggle a vote instead of loading a page")
+	benchCmd.Flags().StringVar(&flagBenchJournalMode, "journal-mode", "WAL", "The SQLite journal mode to benchmark, e.g. WAL or DELETE")
+	benchCmd.Flags().DurationVar(&flagBenchBusyTimeout, "busy-timeout", 5*time.Second, "The SQLite busy timeout")
+}
+
+func toggleVote(ctx context.Context, db *storage.SqliteStore, sessionId, voterId, candidateId int64) error {
+	vote, err := db.GetVote(ctx, sessionId, voterId, candidateId)
+	if err != nil {
+		return err
+	}
+
+	if vote != nil {
+		return db.DeleteVote(ctx, sessionId, voterId, candidateId)
+	}
+
+	_, err = db.AddVote(ctx, sessionId, &core.VoteEntity{
+		SessionId:   sessionId,
+		VoterId:     voterId,
+		CandidateId: candidateId,
+	})
+	if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		// Another worker cast the same vote first.
+		return nil
+	}
+	return err
+}
+
+type benchMusicRepository struct{}
+
+func (r *benchMusicRepository) AuthenticateUser(ctx context.Context, user *core.UserEntity) error {
+	return nil
+}
+
+func (r *benchMusicRepository) GetTrackById(ctx context.Context, trackId string) (*core.TrackEntity, error) {
+	return &core.TrackEntity{Id: trackId, Name: trackId}, nil
+}
+
+func (r *benchMusicRepository) SearchTracks(ctx context.Context, query string) ([]core.TrackEntity, error) {
+	return []core.TrackEntity{}, nil
+}
+
+func (r *benchMusicRepository) CreatePlaylist(ctx context.Context, name string, trackIds []string) (*core.PlaylistEntity, error) {
+	return &core.PlaylistEntity{Name: name}, nil
+}
+
+func (r *benchMusicRepository) GetPlaylistById(ctx context.Context, playlistId string) (*core.PlaylistEntity, error) {
+	return &core.PlaylistEntity{Id: playlistId}, nil
+}
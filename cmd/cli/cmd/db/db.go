@@ -17,8 +17,20 @@ var (
 )
 
 func init() {
-	DbCmd.PersistentFlags().StringVarP(&flagDbPath, "db-path", "p", config.GetConfigValue(config.ConfDbPath), "The path to the database")
+	AddDbPathFlag(DbCmd)
 
 	DbCmd.AddCommand(setupCmd)
-	DbCmd.AddCommand(loadTestDataCmd)
+	DbCmd.AddCommand(seedCmd)
+	DbCmd.AddCommand(benchCmd)
+}
+
+// AddDbPathFlag adds the --db-path flag to a command outside of DbCmd that works on the same
+// database, so every command resolves the path the same way.
+func AddDbPathFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&flagDbPath, "db-path", "p", config.GetConfigValue(config.ConfDbPath), "The path to the database")
+}
+
+// DbPath returns the database path given by --db-path or the config.
+func DbPath() string {
+	return flagDbPath
 }
@@ -0,0 +1,343 @@
+package db
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+//go:embed fixtures/tracks.txt
+var defaultTrackFixture []byte
+
+var seedCmd = &cobra.Command{
+	Use:   "seed",
+	Short: "Seed a SQLite database with generated test data",
+	Long: `Generates users, sessions in every phase, submissions and votes.
+
+All users are created with the password "pwd" and the first user is an admin.
+Track IDs are read from a fixture file, one per line, so the generated data
+can be served by any music provider that knows those IDs.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dbPath := flagDbPath
+
+		if flagSeedUsers < 2 {
+			log.Fatalln("At least 2 users are required")
+		}
+		if flagSeedSessionsPerPhase < 1 {
+			log.Fatalln("At least 1 session per phase is required")
+		}
+		if flagSeedSubmissions < 1 {
+			log.Fatalln("At least 1 submission per player is required")
+		}
+		if flagSeedVoteDensity < 0 || flagSeedVoteDensity > 1 {
+			log.Fatalln("Vote density must be between 0 and 1")
+		}
+
+		trackIds, err := loadTrackFixture(flagSeedTracksFile)
+		if err != nil {
+			log.Fatalln("Failed to load track fixture:", err)
+		}
+		if len(trackIds) < flagSeedSubmissions {
+			log.Fatalf("Track fixture has %d tracks, need at least %d for %d submissions per player", len(trackIds), flagSeedSubmissions, flagSeedSubmissions)
+		}
+
+		db, err := storage.NewSqliteDb(dbPath)
+		if err != nil {
+			log.Fatalln("Failed to connect to the database:", err)
+			return
+		}
+		defer db.Close()
+
+		ctx := cmd.Context()
+		isSeeded, err := isSeeded(ctx, db)
+		if err != nil {
+			log.Fatalln("Failed to check for existing seed data:", err)
+		} else if isSeeded {
+			log.Fatalln("The database @", dbPath, "is already seeded, seed a fresh database instead")
+		}
+
+		log.Println("Seeding the database @", dbPath, "with random seed", flagSeedRandomSeed)
+
+		generator := &seedGenerator{
+			db:       db,
+			rand:     rand.New(rand.NewSource(flagSeedRandomSeed)),
+			trackIds: trackIds,
+		}
+
+		// Everything is written in one transaction, so a failure leaves the database as it was.
+		err = db.InTransaction(ctx, func(ctx context.Context) error {
+			log.Default().Println("Creating users...")
+			if err := generator.CreateUsers(ctx, flagSeedUsers); err != nil {
+				return err
+			}
+
+			log.Default().Println("Creating crew...")
+			if err := generator.CreateCrew(ctx); err != nil {
+				return err
+			}
+
+			for _, phase := range []core.SessionPhase{core.SubmissionPhase, core.VotePhase, core.ResultPhase} {
+				log.Default().Printf("Creating %d %s phase sessions...\n", flagSeedSessionsPerPhase, phase)
+				for i := 0; i < flagSeedSessionsPerPhase; i++ {
+					if err := generator.CreateSession(ctx, phase, i+1); err != nil {
+						return err
+					}
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			log.Fatalln("Failed to seed the database, nothing was added:", err)
+		}
+
+		log.Println("Test data added successfully")
+	},
+}
+
+var (
+	flagSeedUsers            int
+	flagSeedSessionsPerPhase int
+	flagSeedSubmissions      int
+	flagSeedVoteDensity      float64
+	flagSeedRandomSeed       int64
+	flagSeedTracksFile       string
+)
+
+func init() {
+	seedCmd.Flags().IntVarP(&flagSeedUsers, "users", "u", 5, "The number of users to create")
+	seedCmd.Flags().IntVarP(&flagSeedSessionsPerPhase, "sessions", "s", 1, "The number of sessions to create per phase")
+	seedCmd.Flags().IntVar(&flagSeedSubmissions, "submissions", 5, "The number of submissions per player")
+	seedCmd.Flags().Float64Var(&flagSeedVoteDensity, "vote-density", 0.5, "The fraction of available votes each player casts, between 0 and 1")
+	seedCmd.Flags().Int64Var(&flagSeedRandomSeed, "seed", time.Now().UnixNano(), "The random seed, reuse a seed to reproduce a data set")
+	seedCmd.Flags().StringVarP(&flagSeedTracksFile, "tracks", "t", "", "A fixture file of track IDs, defaults to the built in fixture")
+}
+
+func loadTrackFixture(path string) ([]string, error) {
+	var reader io.Reader = bytes.NewReader(defaultTrackFixture)
+	if path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	trackIds := make([]string, 0)
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		trackIds = append(trackIds, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(trackIds) == 0 {
+		return nil, errors.New("no track IDs found")
+	}
+
+	return trackIds, nil
+}
+
+const (
+	seedAdminUsername = "admin"
+	seedCrewName      = "Seeded Crew"
+)
+
+// isSeeded reports whether the database already has the seeded admin or crew, whose names would
+// clash with a new seed.
+func isSeeded(ctx context.Context, db *storage.SqliteStore) (bool, error) {
+	admin, err := db.GetUserByUsername(ctx, seedAdminUsername)
+	if err != nil || admin != nil {
+		return admin != nil, err
+	}
+
+	crew, err := db.GetCrewByName(ctx, seedCrewName)
+	return crew != nil, err
+}
+
+type seedGenerator struct {
+	db       *storage.SqliteStore
+	rand     *rand.Rand
+	trackIds []string
+	users    []*core.UserEntity
+	crew     *core.CrewEntity
+}
+
+func (g *seedGenerator) CreateUsers(ctx context.Context, count int) error {
+	hashedPassword, err := core.HashPassword("pwd")
+	if err != nil {
+		return fmt.Errorf("error hashing default password: %w", err)
+	}
+
+	for i := 0; i < count; i++ {
+		username := fmt.Sprintf("user%d", i)
+		if i == 0 {
+			username = seedAdminUsername
+		}
+
+		user, err := g.db.CreateUser(ctx, &core.UserEntity{
+			Username:       username,
+			DisplayName:    username,
+			HashedPassword: hashedPassword,
+		})
+		if err != nil {
+			return fmt.Errorf("error creating user %s: %w", username, err)
+		}
+
+		g.users = append(g.users, user)
+	}
+
+	if err := g.db.UpdateUserIsAdmin(ctx, g.users[0].Id, true); err != nil {
+		return fmt.Errorf("error promoting admin user: %w", err)
+	}
+	return nil
+}
+
+// CreateCrew puts every seeded user in one crew, run by the admin.
+func (g *seedGenerator) CreateCrew(ctx context.Context) error {
+	crew, err := g.db.CreateCrew(ctx, &core.CrewEntity{
+		Name:      seedCrewName,
+		CreatedBy: g.users[0].Id,
+		CreatedAt: core.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("error creating crew: %w", err)
+	}
+	g.crew = crew
+
+	for i, user := range g.users {
+		role := core.CrewRoleMember
+		if i == 0 {
+			role = core.CrewRoleAdmin
+		}
+
+		err := g.db.AddCrewMember(ctx, &core.CrewMemberEntity{
+			CrewId:   crew.Id,
+			UserId:   user.Id,
+			Role:     role,
+			JoinedAt: crew.CreatedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("error adding crew member %s: %w", user.Username, err)
+		}
+	}
+	return nil
+}
+
+func (g *seedGenerator) CreateSession(ctx context.Context, phase core.SessionPhase, number int) error {
+	phaseDuration := 24 * time.Hour
+	startAt := core.Now()
+	switch phase {
+	case core.VotePhase:
+		startAt = startAt.Add(-phaseDuration - phaseDuration/2)
+	case core.ResultPhase:
+		startAt = startAt.Add(-3 * phaseDuration)
+	}
+
+	players := g.pickPlayers()
+	session := core.NewSessionEntity(
+		fmt.Sprintf("Seeded %s Session %d", phase, number),
+		players[0].Id,
+		core.WithSessionCrew(g.crew.Id),
+		core.WithSessionStartAt(startAt),
+		core.WithSubmissionDuration(phaseDuration),
+		core.WithVoteDuration(phaseDuration),
+	)
+	session.MaxSubmissions = flagSeedSubmissions
+
+	session, err := g.db.CreateSession(ctx, session)
+	if err != nil {
+		return fmt.Errorf("error creating session: %w", err)
+	}
+
+	candidates := make([]core.CandidateEntity, 0)
+	for _, player := range players {
+		_, err := g.db.AddPlayer(ctx, session.Id, &core.PlayerEntity{
+			SessionId: session.Id,
+			PlayerId:  player.Id,
+		})
+		if err != nil {
+			return fmt.Errorf("error creating player %s: %w", player.Username, err)
+		}
+
+		submissionCount := session.MaxSubmissions
+		if phase == core.SubmissionPhase {
+			submissionCount = g.rand.Intn(session.MaxSubmissions + 1)
+		}
+
+		for _, i := range g.rand.Perm(len(g.trackIds))[:submissionCount] {
+			candidate, err := g.db.AddCandidate(ctx, session.Id, &core.CandidateEntity{
+				SessionId:   session.Id,
+				NominatorId: player.Id,
+				TrackId:     g.trackIds[i],
+			})
+			if err != nil {
+				return fmt.Errorf("error adding candidate for track %s: %w", g.trackIds[i], err)
+			}
+			candidates = append(candidates, *candidate)
+		}
+
+		if submissionCount == session.MaxSubmissions {
+			err = g.db.FinalizePlayerSubmissions(ctx, session.Id, player.Id)
+			if err != nil {
+				return fmt.Errorf("error finalizing submissions for %s: %w", player.Username, err)
+			}
+		}
+	}
+
+	if phase == core.SubmissionPhase {
+		return nil
+	}
+
+	voteCount := int(math.Round(flagSeedVoteDensity * float64(session.MaxVotes())))
+	for _, player := range players {
+		ballot := make([]core.CandidateEntity, 0)
+		for _, candidate := range candidates {
+			if candidate.NominatorId != player.Id {
+				ballot = append(ballot, candidate)
+			}
+		}
+
+		for _, i := range g.rand.Perm(len(ballot))[:min(voteCount, len(ballot))] {
+			_, err := g.db.AddVote(ctx, session.Id, &core.VoteEntity{
+				SessionId:   session.Id,
+				VoterId:     player.Id,
+				CandidateId: ballot[i].Id,
+			})
+			if err != nil {
+				return fmt.Errorf("error adding vote for %s: %w", player.Username, err)
+			}
+		}
+	}
+	return nil
+}
+
+// pickPlayers returns a random subset of at least two of the generated users.
+func (g *seedGenerator) pickPlayers() []*core.UserEntity {
+	count := 2 + g.rand.Intn(len(g.users)-1)
+	players := make([]*core.UserEntity, count)
+	for i, j := range g.rand.Perm(len(g.users))[:count] {
+		players[i] = g.users[j]
+	}
+	return players
+}
@@ -22,8 +22,9 @@ var setupCmd = &cobra.Command{
 		}
 		defer db.Close()
 
-		createTables(db)
-		createViews(db)
+		if err := db.Setup(); err != nil {
+			log.Fatalln("Failed to set up the database:", err)
+		}
 
 		log.Println("Database setup completed successfully.")
 	},
@@ -31,71 +32,3 @@ var setupCmd = &cobra.Command{
 
 func init() {
 }
-
-func createTables(db *storage.SqliteStore) {
-	tables := []string{
-		`CREATE TABLE IF NOT EXISTS ` + storage.TableNameUsers + ` (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			username TEXT,
-			display_name TEXT,
-			hashed_password TEXT,
-			spotify_token TEXT,
-			spotify_email TEXT,
-			is_admin INTEGER DEFAULT (0)
-		);`,
-		`CREATE TABLE IF NOT EXISTS ` + storage.TableNameSessions + ` (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT,
-			created_by INTEGER,
-			created_at INTEGER,
-			max_submissions INTEGER,
-			start_at INTEGER,
-			submission_phase_duration INTEGER,
-			submissions_closed_at INTEGER,
-			vote_phase_duration INTEGER,
-			FOREIGN KEY (created_by) REFERENCES ` + storage.TableNameUsers + ` (id)
-		);`,
-		`CREATE TABLE IF NOT EXISTS ` + storage.TableNamePlayers + ` (
-			session_id INTEGER,
-			player_id INTEGER,
-			playlist_id TEXT,
-			is_submissions_finalized INTEGER DEFAULT (0),
-			FOREIGN KEY (session_id) REFERENCES ` + storage.TableNameSessions + ` (id),
-			FOREIGN KEY (player_id) REFERENCES ` + storage.TableNameUsers + ` (id),
-			PRIMARY KEY (session_id, player_id)
-		);`,
-		`CREATE TABLE IF NOT EXISTS ` + storage.TableNameCandidates + ` (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			nominator_id INTEGER,
-			session_id INTEGER,
-			track_id TEXT,
-			FOREIGN KEY (nominator_id) REFERENCES ` + storage.TableNameUsers + ` (id),
-			FOREIGN KEY (session_id) REFERENCES ` + storage.TableNameSessions + ` (id)
-		);`,
-		`CREATE TABLE IF NOT EXISTS ` + storage.TableNameVotes + ` (
-			session_id INTEGER,
-			voter_id INTEGER,
-			candidate_id INTEGER,
-			FOREIGN KEY (session_id) REFERENCES ` + storage.TableNameSessions + ` (id),
-			FOREIGN KEY (voter_id) REFERENCES ` + storage.TableNameUsers + ` (id),
-			FOREIGN KEY (candidate_id) REFERENCES ` + storage.TableNameCandidates + ` (id),
-			PRIMARY KEY (session_id, voter_id, candidate_id)
-		);`,
-	}
-
-	for _, query := range tables {
-		if _, err := db.Exec(query); err != nil {
-			log.Fatalln("Failed to create table:", err)
-		}
-	}
-}
-
-func createViews(db *storage.SqliteStore) {
-	views := []string{}
-
-	for _, query := range views {
-		if _, err := db.Exec(query); err != nil {
-			log.Fatalln("Failed to create view:", err)
-		}
-	}
-}
@@ -0,0 +1,68 @@
+package mail
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/CaribouBlue/mixtape/internal/mail"
+	"github.com/CaribouBlue/mixtape/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List outbox messages, failed ones by default",
+	Run: func(cmd *cobra.Command, args []string) {
+		statuses := make([]mail.OutboxStatus, 0)
+		for _, status := range strings.Split(flagStatus, ",") {
+			switch status := mail.OutboxStatus(strings.TrimSpace(status)); status {
+			case mail.OutboxStatusPending, mail.OutboxStatusSent, mail.OutboxStatusFailed:
+				statuses = append(statuses, status)
+			default:
+				log.Fatalln("Invalid status:", status)
+			}
+		}
+
+		db, err := storage.NewSqliteDb(flagDbPath)
+		if err != nil {
+			log.Fatalln("Failed to connect to the database:", err)
+		}
+		defer db.Close()
+
+		mailService := mail.NewMailService(mail.NewNoopMailer(), db)
+
+		messages, err := mailService.GetOutboxMessages(cmd.Context(), statuses, flagLimit)
+		if err != nil {
+			log.Fatalln("Failed to get outbox messages:", err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tSTATUS\tATTEMPTS\tTO\tSUBJECT\tUPDATED\tLAST ERROR")
+		for _, message := range messages {
+			fmt.Fprintf(w, "%d\t%s\t%d/%d\t%s\t%s\t%s\t%s\n",
+				message.Id,
+				message.Status,
+				message.Attempts,
+				message.MaxAttempts,
+				message.Message.To,
+				message.Message.Subject,
+				message.UpdatedAt.Format("2006-01-02 15:04:05"),
+				message.LastError,
+			)
+		}
+		w.Flush()
+	},
+}
+
+var (
+	flagStatus string
+	flagLimit  int
+)
+
+func init() {
+	listCmd.Flags().StringVarP(&flagStatus, "status", "s", string(mail.OutboxStatusFailed), "Comma separated statuses to list: pending, sent or failed")
+	listCmd.Flags().IntVarP(&flagLimit, "limit", "l", 50, "The maximum number of messages to list")
+}
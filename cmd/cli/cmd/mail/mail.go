@@ -0,0 +1,24 @@
+package mail
+
+import (
+	"github.com/CaribouBlue/mixtape/cmd/cli/config"
+	"github.com/spf13/cobra"
+)
+
+var MailCmd = &cobra.Command{
+	Use:   "mail",
+	Short: "Inspect and requeue outgoing mail in an app database",
+	Run: func(cmd *cobra.Command, args []string) {
+	},
+}
+
+var (
+	flagDbPath string
+)
+
+func init() {
+	MailCmd.PersistentFlags().StringVarP(&flagDbPath, "db-path", "p", config.GetConfigValue(config.ConfDbPath), "The path to the database")
+
+	MailCmd.AddCommand(listCmd)
+	MailCmd.AddCommand(requeueCmd)
+}
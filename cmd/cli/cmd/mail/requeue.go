@@ -0,0 +1,61 @@
+package mail
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/CaribouBlue/mixtape/internal/mail"
+	"github.com/CaribouBlue/mixtape/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var requeueCmd = &cobra.Command{
+	Use:   "requeue [ID]",
+	Short: "Queue failed mail to be delivered again",
+	Long:  "Resets a failed outbox message, or every failed message with --all, so the server retries its delivery with a fresh set of attempts.",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if flagAll == (len(args) == 1) {
+			log.Fatalln("Provide either a message ID or --all")
+		}
+
+		db, err := storage.NewSqliteDb(flagDbPath)
+		if err != nil {
+			log.Fatalln("Failed to connect to the database:", err)
+		}
+		defer db.Close()
+
+		mailService := mail.NewMailService(mail.NewNoopMailer(), db)
+
+		if flagAll {
+			count, err := mailService.RequeueFailedOutboxMessages(cmd.Context())
+			if err != nil {
+				log.Fatalln("Failed to requeue messages:", err)
+			}
+			log.Printf("Requeued %d failed messages\n", count)
+			return
+		}
+
+		messageId, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			log.Fatalln("Invalid message ID:", args[0])
+		}
+
+		err = mailService.RequeueOutboxMessage(cmd.Context(), messageId)
+		if err == mail.ErrOutboxMessageNotFound {
+			log.Fatalf("No failed message with ID %d\n", messageId)
+		} else if err != nil {
+			log.Fatalln("Failed to requeue message:", err)
+		}
+
+		log.Printf("Requeued message %d\n", messageId)
+	},
+}
+
+var (
+	flagAll bool
+)
+
+func init() {
+	requeueCmd.Flags().BoolVarP(&flagAll, "all", "a", false, "Requeue every failed message")
+}
@@ -0,0 +1,113 @@
+package play
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/server/response"
+)
+
+// apiError is an error response from the server.
+type apiError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s (%s)", e.Message, e.Code)
+}
+
+type apiClient struct {
+	baseUrl     string
+	accessToken string
+	http        *http.Client
+}
+
+func newApiClient(serverUrl string, accessToken string) *apiClient {
+	return &apiClient{
+		baseUrl:     strings.TrimSuffix(serverUrl, "/") + "/api/v1",
+		accessToken: accessToken,
+		http:        &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// mustApiClient returns a client for the configured server, exiting when there's no token to
+// authenticate with.
+func mustApiClient() *apiClient {
+	if flagAccessToken == "" {
+		log.Fatalln("Not logged in, run `play login` first")
+	}
+	return newApiClient(flagServerUrl, flagAccessToken)
+}
+
+// do sends a request with body encoded as JSON, when not nil, and decodes the response into out,
+// when not nil.
+func (c *apiClient) do(ctx context.Context, method string, path string, body any, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseUrl+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		var jsonErr response.JsonError
+		if err := json.NewDecoder(res.Body).Decode(&jsonErr); err != nil || jsonErr.Error.Code == "" {
+			return &apiError{Status: res.StatusCode, Code: strconv.Itoa(res.StatusCode), Message: res.Status}
+		}
+		return &apiError{Status: res.StatusCode, Code: jsonErr.Error.Code, Message: jsonErr.Error.Message}
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+func (c *apiClient) get(ctx context.Context, path string, out any) error {
+	return c.do(ctx, http.MethodGet, path, nil, out)
+}
+
+func sessionPath(sessionId int64, elems ...string) string {
+	path := fmt.Sprintf("/sessions/%d", sessionId)
+	for _, elem := range elems {
+		path += "/" + url.PathEscape(elem)
+	}
+	return path
+}
+
+func parseId(name string, value string) int64 {
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid %s: %s\n", name, value)
+	}
+	return id
+}
@@ -0,0 +1,27 @@
+package play
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/CaribouBlue/mixtape/internal/server/mux"
+	"github.com/spf13/cobra"
+)
+
+var joinCmd = &cobra.Command{
+	Use:   "join SESSION_ID",
+	Short: "Join a session during its submission phase",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		sessionId := parseId("session ID", args[0])
+
+		var player mux.ApiPlayer
+		err := mustApiClient().do(cmd.Context(), http.MethodPost, sessionPath(sessionId, "players", "me"), nil, &player)
+		if err != nil {
+			log.Fatalln("Failed to join session:", err)
+		}
+
+		fmt.Printf("Joined session %d as %s\n", sessionId, player.DisplayName)
+	},
+}
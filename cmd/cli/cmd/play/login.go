@@ -0,0 +1,64 @@
+package play
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/CaribouBlue/mixtape/cmd/cli/config"
+	"github.com/CaribouBlue/mixtape/internal/server/mux"
+	"github.com/spf13/cobra"
+)
+
+var loginCmd = &cobra.Command{
+	Use:   "login [TOKEN]",
+	Short: "Save a personal access token for later commands",
+	Long: "Checks the token against the server given by --server and saves both to " + config.CredentialsPath + ". " +
+		"The token is read from stdin when not given, which keeps it out of your shell history.",
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var token string
+		if len(args) == 1 {
+			token = args[0]
+		} else {
+			fmt.Fprint(os.Stderr, "Access token: ")
+			line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil && line == "" {
+				log.Fatalln("Failed to read access token:", err)
+			}
+			token = line
+		}
+		token = strings.TrimSpace(token)
+		if token == "" {
+			log.Fatalln("An access token is required")
+		}
+
+		var sessions []mux.ApiSession
+		err := newApiClient(flagServerUrl, token).get(cmd.Context(), "/sessions", &sessions)
+		if err != nil {
+			log.Fatalln("Failed to log in:", err)
+		}
+
+		if err := config.SaveCredentials(flagServerUrl, token); err != nil {
+			log.Fatalln("Failed to save credentials:", err)
+		}
+
+		fmt.Printf("Logged in to %s\n", flagServerUrl)
+	},
+}
+
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Forget the saved access token",
+	Long:  "Removes " + config.CredentialsPath + ". The token itself stays valid until you revoke it from your profile page.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := config.RemoveCredentials(); err != nil {
+			log.Fatalln("Failed to remove credentials:", err)
+		}
+
+		fmt.Println("Logged out")
+	},
+}
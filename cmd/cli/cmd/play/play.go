@@ -0,0 +1,36 @@
+package play
+
+import (
+	"github.com/CaribouBlue/mixtape/cmd/cli/config"
+	"github.com/spf13/cobra"
+)
+
+var PlayCmd = &cobra.Command{
+	Use:   "play",
+	Short: "Play sessions on a running server through its API",
+	Long: "Play sessions from the terminal. Log in once with a personal access token from your profile page, " +
+		"it's saved to " + config.CredentialsPath + " for later commands.",
+	Run: func(cmd *cobra.Command, args []string) {
+	},
+}
+
+var (
+	flagServerUrl   string
+	flagAccessToken string
+)
+
+func init() {
+	PlayCmd.PersistentFlags().StringVarP(&flagServerUrl, "server", "s", config.GetConfigValue(config.ConfPlayServerUrl), "The URL of the Mixtape server")
+	PlayCmd.PersistentFlags().StringVar(&flagAccessToken, "token", config.GetConfigValue(config.ConfPlayAccessToken), "The personal access token to use, instead of the saved one")
+
+	PlayCmd.AddCommand(loginCmd)
+	PlayCmd.AddCommand(logoutCmd)
+	PlayCmd.AddCommand(sessionsCmd)
+	PlayCmd.AddCommand(showCmd)
+	PlayCmd.AddCommand(joinCmd)
+	PlayCmd.AddCommand(searchCmd)
+	PlayCmd.AddCommand(submitCmd)
+	PlayCmd.AddCommand(finalizeCmd)
+	PlayCmd.AddCommand(voteCmd)
+	PlayCmd.AddCommand(resultsCmd)
+}
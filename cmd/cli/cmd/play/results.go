@@ -0,0 +1,40 @@
+package play
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/server/mux"
+	"github.com/spf13/cobra"
+)
+
+var resultsCmd = &cobra.Command{
+	Use:   "results SESSION_ID",
+	Short: "Print a finished session's results",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		sessionId := parseId("session ID", args[0])
+
+		var session mux.ApiSessionDetail
+		if err := mustApiClient().get(cmd.Context(), sessionPath(sessionId), &session); err != nil {
+			log.Fatalln("Failed to get session:", err)
+		}
+
+		if core.SessionPhase(session.Phase) != core.ResultPhase {
+			log.Fatalf("Results aren't in yet, %s\n", countdown(session.ApiSession, time.Now()))
+		}
+
+		fmt.Printf("Results of %s (#%d)\n\n", session.Name, session.Id)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PLACE\tVOTES\tTRACK\tSUBMITTED BY")
+		for _, candidate := range session.Results {
+			fmt.Fprintf(w, "%d\t%d\t%s\t%s\n", candidate.Place, candidate.Votes, trackLabel(candidate.Track), candidate.Nominator)
+		}
+		w.Flush()
+	},
+}
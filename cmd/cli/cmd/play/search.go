@@ -0,0 +1,45 @@
+package play
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/CaribouBlue/mixtape/internal/server/mux"
+	"github.com/spf13/cobra"
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search SESSION_ID QUERY...",
+	Short: "Search for tracks to submit to a session",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		sessionId := parseId("session ID", args[0])
+		query := strings.Join(args[1:], " ")
+
+		var tracks []mux.ApiTrack
+		path := sessionPath(sessionId, "tracks") + "?q=" + url.QueryEscape(query)
+		if err := mustApiClient().get(cmd.Context(), path, &tracks); err != nil {
+			log.Fatalln("Failed to search tracks:", err)
+		}
+
+		if len(tracks) == 0 {
+			fmt.Println("No tracks found")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TRACK ID\tNAME\tARTISTS\tALBUM")
+		for _, track := range tracks {
+			name := track.Name
+			if track.Explicit {
+				name += " [E]"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", track.Id, name, strings.Join(track.Artists, ", "), track.Album)
+		}
+		w.Flush()
+	},
+}
@@ -0,0 +1,84 @@
+package play
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/server/mux"
+	"github.com/spf13/cobra"
+)
+
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "List sessions along with their phase and what's next",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var sessions []mux.ApiSession
+		if err := mustApiClient().get(cmd.Context(), "/sessions", &sessions); err != nil {
+			log.Fatalln("Failed to list sessions:", err)
+		}
+
+		now := time.Now()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tPHASE\tNEXT\tJOINED")
+		for _, session := range sessions {
+			joined := ""
+			if session.IsJoined {
+				joined = "yes"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", session.Id, session.Name, session.Phase, countdown(session, now), joined)
+		}
+		w.Flush()
+	},
+}
+
+// countdown describes when the session moves on to its next phase.
+func countdown(session mux.ApiSession, now time.Time) string {
+	switch core.SessionPhase(session.Phase) {
+	case core.SubmissionPhase:
+		if now.Before(session.StartAt) {
+			return "starts in " + formatDuration(session.StartAt.Sub(now))
+		}
+		return "voting in " + formatDuration(session.VotingStartAt.Sub(now))
+	case core.VotePhase:
+		return "results in " + formatDuration(session.ResultsStartAt.Sub(now))
+	default:
+		return "finished"
+	}
+}
+
+// formatDuration rounds a duration to the minute and spells it out in days, hours and minutes.
+func formatDuration(d time.Duration) string {
+	if d < time.Minute {
+		return "less than a minute"
+	}
+
+	d = d.Round(time.Minute)
+	days := d / (24 * time.Hour)
+	hours := (d % (24 * time.Hour)) / time.Hour
+	minutes := (d % time.Hour) / time.Minute
+
+	parts := make([]string, 0, 3)
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%dd", days))
+	}
+	if hours > 0 {
+		parts = append(parts, fmt.Sprintf("%dh", hours))
+	}
+	if minutes > 0 {
+		parts = append(parts, fmt.Sprintf("%dm", minutes))
+	}
+	return strings.Join(parts, " ")
+}
+
+func trackLabel(track *mux.ApiTrack) string {
+	if track == nil {
+		return "(unknown track)"
+	}
+	return fmt.Sprintf("%s - %s", track.Name, strings.Join(track.Artists, ", "))
+}
@@ -0,0 +1,99 @@
+package play
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/server/mux"
+	"github.com/spf13/cobra"
+)
+
+var showCmd = &cobra.Command{
+	Use:   "show SESSION_ID",
+	Short: "Show a session's phase, players and what you can do next",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		sessionId := parseId("session ID", args[0])
+
+		var session mux.ApiSessionDetail
+		if err := mustApiClient().get(cmd.Context(), sessionPath(sessionId), &session); err != nil {
+			log.Fatalln("Failed to get session:", err)
+		}
+
+		fmt.Printf("%s (#%d)\n", session.Name, session.Id)
+		fmt.Printf("Phase: %s, %s\n\n", session.Phase, countdown(session.ApiSession, time.Now()))
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PLAYER\tSUBMISSIONS\tVOTES")
+		for _, player := range session.Players {
+			finalized := "in progress"
+			if player.IsSubmissionsFinalized {
+				finalized = "finalized"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\n", player.DisplayName, finalized, player.VoteCount)
+		}
+		w.Flush()
+		fmt.Println()
+
+		if session.Me == nil {
+			if core.SessionPhase(session.Phase) == core.SubmissionPhase {
+				fmt.Printf("You haven't joined, run `play join %d` to play\n", session.Id)
+			}
+			return
+		}
+
+		switch core.SessionPhase(session.Phase) {
+		case core.SubmissionPhase:
+			fmt.Printf("Your submissions (%d of %d):\n", len(session.Submissions), session.MaxSubmissions)
+			printCandidates(session.Submissions, false)
+			if session.Me.IsSubmissionsFinalized {
+				fmt.Println("Your submissions are finalized")
+			} else if len(session.Submissions) < session.MaxSubmissions {
+				fmt.Printf("Find tracks with `play search %d QUERY` and submit them with `play submit %d TRACK_ID`\n", session.Id, session.Id)
+			} else {
+				fmt.Printf("Lock them in with `play finalize %d`\n", session.Id)
+			}
+		case core.VotePhase:
+			votes := 0
+			for _, candidate := range session.Ballot {
+				if candidate.IsVoted {
+					votes++
+				}
+			}
+			fmt.Printf("Ballot (%d of %d votes cast):\n", votes, session.MaxVotes)
+			printCandidates(session.Ballot, true)
+			fmt.Printf("Vote with `play vote %d CANDIDATE_ID`\n", session.Id)
+		case core.ResultPhase:
+			fmt.Printf("See the results with `play results %d`\n", session.Id)
+		}
+
+		if session.Me.PlaylistUrl != "" {
+			fmt.Println("Playlist:", session.Me.PlaylistUrl)
+		}
+	},
+}
+
+func printCandidates(candidates []mux.ApiCandidate, showVotes bool) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if showVotes {
+		fmt.Fprintln(w, "ID\tTRACK\tVOTED")
+	} else {
+		fmt.Fprintln(w, "ID\tTRACK")
+	}
+	for _, candidate := range candidates {
+		if showVotes {
+			voted := ""
+			if candidate.IsVoted {
+				voted = "yes"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", candidate.Id, trackLabel(candidate.Track), voted)
+		} else {
+			fmt.Fprintf(w, "%d\t%s\n", candidate.Id, trackLabel(candidate.Track))
+		}
+	}
+	w.Flush()
+}
@@ -0,0 +1,64 @@
+package play
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/CaribouBlue/mixtape/internal/server/mux"
+	"github.com/spf13/cobra"
+)
+
+var submitCmd = &cobra.Command{
+	Use:   "submit SESSION_ID TRACK",
+	Short: "Submit a track to a session",
+	Long:  "Submits a track given by its ID from `play search`, a Spotify track link or a spotify:track: URI.",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		sessionId := parseId("session ID", args[0])
+
+		var candidate mux.ApiCandidate
+		err := mustApiClient().do(cmd.Context(), http.MethodPost, sessionPath(sessionId, "candidates"), mux.ApiSubmitCandidateRequest{
+			TrackId: parseTrackId(args[1]),
+		}, &candidate)
+		if err != nil {
+			log.Fatalln("Failed to submit track:", err)
+		}
+
+		fmt.Printf("Submitted %s as candidate %d\n", trackLabel(candidate.Track), candidate.Id)
+	},
+}
+
+// parseTrackId accepts what people tend to copy out of Spotify as well as bare track IDs.
+func parseTrackId(track string) string {
+	if id, ok := strings.CutPrefix(track, "spotify:track:"); ok {
+		return id
+	}
+
+	u, err := url.Parse(track)
+	if err == nil && u.Host == "open.spotify.com" {
+		if _, id, ok := strings.Cut(u.Path, "/track/"); ok {
+			return strings.Trim(id, "/")
+		}
+	}
+
+	return track
+}
+
+var finalizeCmd = &cobra.Command{
+	Use:   "finalize SESSION_ID",
+	Short: "Lock in your submissions to a session",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		sessionId := parseId("session ID", args[0])
+
+		err := mustApiClient().do(cmd.Context(), http.MethodPost, sessionPath(sessionId, "players", "me", "finalize"), nil, nil)
+		if err != nil {
+			log.Fatalln("Failed to finalize submissions:", err)
+		}
+
+		fmt.Printf("Your submissions to session %d are finalized\n", sessionId)
+	},
+}
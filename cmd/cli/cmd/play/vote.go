@@ -0,0 +1,46 @@
+package play
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/CaribouBlue/mixtape/internal/server/mux"
+	"github.com/spf13/cobra"
+)
+
+var voteCmd = &cobra.Command{
+	Use:   "vote SESSION_ID CANDIDATE_ID",
+	Short: "Vote for a candidate on a session's ballot",
+	Long:  "Votes for a candidate listed by `play show`, or takes the vote back with --remove.",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		sessionId := parseId("session ID", args[0])
+		candidateId := parseId("candidate ID", args[1])
+
+		method := http.MethodPost
+		if flagRemoveVote {
+			method = http.MethodDelete
+		}
+
+		var candidate mux.ApiCandidate
+		err := mustApiClient().do(cmd.Context(), method, sessionPath(sessionId, "candidates", fmt.Sprint(candidateId), "vote"), nil, &candidate)
+		if err != nil {
+			log.Fatalln("Failed to vote:", err)
+		}
+
+		if flagRemoveVote {
+			fmt.Printf("Took back your vote for %s\n", trackLabel(candidate.Track))
+		} else {
+			fmt.Printf("Voted for %s\n", trackLabel(candidate.Track))
+		}
+	},
+}
+
+var (
+	flagRemoveVote bool
+)
+
+func init() {
+	voteCmd.Flags().BoolVar(&flagRemoveVote, "remove", false, "Take back the vote instead")
+}
@@ -6,6 +6,11 @@ import (
 
 	"github.com/CaribouBlue/mixtape/cmd/cli/cmd/db"
 	"github.com/CaribouBlue/mixtape/cmd/cli/cmd/deploy"
+	"github.com/CaribouBlue/mixtape/cmd/cli/cmd/mail"
+	"github.com/CaribouBlue/mixtape/cmd/cli/cmd/play"
+	"github.com/CaribouBlue/mixtape/cmd/cli/cmd/session"
+	"github.com/CaribouBlue/mixtape/cmd/cli/cmd/user"
+	"github.com/CaribouBlue/mixtape/cmd/cli/cmd/webhook"
 	"github.com/spf13/cobra"
 )
 
@@ -17,6 +22,11 @@ var rootCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(deploy.DeployCmd)
 	rootCmd.AddCommand(db.DbCmd)
+	rootCmd.AddCommand(session.SessionCmd)
+	rootCmd.AddCommand(mail.MailCmd)
+	rootCmd.AddCommand(webhook.WebhookCmd)
+	rootCmd.AddCommand(play.PlayCmd)
+	rootCmd.AddCommand(user.UserCmd)
 }
 
 func Execute() {
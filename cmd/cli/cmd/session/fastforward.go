@@ -0,0 +1,52 @@
+package session
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/CaribouBlue/mixtape/cmd/cli/config"
+	appconfig "github.com/CaribouBlue/mixtape/internal/config"
+	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var fastForwardCmd = &cobra.Command{
+	Use:   "fast-forward ID",
+	Short: "(dev) Jump a session's timeline to the start of a later phase",
+	Long:  "Rewrites a session's start time so that the phase given by --to begins now. Intended for QA of each session phase, so it only runs when ENV is development.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if config.GetConfigValue(config.ConfEnv) != appconfig.EnvDevelopment {
+			log.Fatalln("Fast-forwarding is only available when ENV is development")
+		}
+
+		sessionId, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			log.Fatalln("Invalid session ID:", args[0])
+		}
+
+		db, err := storage.NewSqliteDb(flagDbPath)
+		if err != nil {
+			log.Fatalln("Failed to connect to the database:", err)
+		}
+		defer db.Close()
+
+		sessionService := core.NewSessionService(db, core.NewUserService(db), nil, nil)
+
+		session, err := sessionService.FastForwardSession(cmd.Context(), sessionId, core.SessionPhase(flagToPhase))
+		if err != nil {
+			log.Fatalln("Failed to fast-forward session:", err)
+		}
+
+		log.Printf("Session %d (%s) is now in the %s phase, new start time is %s\n", session.Id, session.Name, session.Phase(), session.StartAt.Format("2006-01-02 15:04:05"))
+	},
+}
+
+var (
+	flagToPhase string
+)
+
+func init() {
+	fastForwardCmd.Flags().StringVarP(&flagToPhase, "to", "t", string(core.VotePhase), "The phase to jump to, either voting or results")
+}
@@ -0,0 +1,23 @@
+package session
+
+import (
+	"github.com/CaribouBlue/mixtape/cmd/cli/config"
+	"github.com/spf13/cobra"
+)
+
+var SessionCmd = &cobra.Command{
+	Use:   "session",
+	Short: "Work with game sessions in an app database",
+	Run: func(cmd *cobra.Command, args []string) {
+	},
+}
+
+var (
+	flagDbPath string
+)
+
+func init() {
+	SessionCmd.PersistentFlags().StringVarP(&flagDbPath, "db-path", "p", config.GetConfigValue(config.ConfDbPath), "The path to the database")
+
+	SessionCmd.AddCommand(fastForwardCmd)
+}
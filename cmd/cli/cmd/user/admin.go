@@ -0,0 +1,50 @@
+package user
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+var promoteCmd = &cobra.Command{
+	Use:   "promote USER",
+	Short: "Make a user, given by ID or username, an admin",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		setAdmin(cmd, args[0], true)
+	},
+}
+
+var demoteCmd = &cobra.Command{
+	Use:   "demote USER",
+	Short: "Take admin rights away from a user, given by ID or username",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		setAdmin(cmd, args[0], false)
+	},
+}
+
+func setAdmin(cmd *cobra.Command, arg string, isAdmin bool) {
+	userService, closeDb := openUserService()
+	defer closeDb()
+
+	user := mustGetUser(cmd.Context(), userService, arg)
+	if user.IsAdmin == isAdmin {
+		fmt.Printf("Nothing to do, %s is already %s\n", user.Username, adminLabel(isAdmin))
+		return
+	}
+
+	if err := userService.SetAdmin(cmd.Context(), user.Id, isAdmin); err != nil {
+		log.Fatalln("Failed to update user:", err)
+	}
+
+	fmt.Printf("%s is now %s\n", user.Username, adminLabel(isAdmin))
+}
+
+func adminLabel(isAdmin bool) string {
+	if isAdmin {
+		return "an admin"
+	}
+	return "not an admin"
+}
@@ -0,0 +1,53 @@
+package user
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var createCmd = &cobra.Command{
+	Use:   "create USERNAME",
+	Short: "Create a user without an invitation",
+	Long:  "Creates a user with the password given by --password, or a generated one that is printed once.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		userService, closeDb := openUserService()
+		defer closeDb()
+
+		password := flagCreatePassword
+		if password == "" {
+			password = newPassword()
+		}
+
+		user, err := userService.CreateUser(cmd.Context(), args[0], password, password)
+		if err == core.ErrUsernameAlreadyExists {
+			log.Fatalln("Username already taken:", userService.NormalizeUsername(args[0]))
+		} else if err != nil {
+			log.Fatalln("Failed to create user:", err)
+		}
+
+		if flagCreateAdmin {
+			if err := userService.SetAdmin(cmd.Context(), user.Id, true); err != nil {
+				log.Fatalln("Failed to make user an admin:", err)
+			}
+		}
+
+		fmt.Printf("Created user %s with ID %d\n", user.Username, user.Id)
+		if flagCreatePassword == "" {
+			fmt.Println("Password:", password)
+		}
+	},
+}
+
+var (
+	flagCreatePassword string
+	flagCreateAdmin    bool
+)
+
+func init() {
+	createCmd.Flags().StringVar(&flagCreatePassword, "password", "", "The user's password, generated when not given")
+	createCmd.Flags().BoolVar(&flagCreateAdmin, "admin", false, "Make the user an admin")
+}
@@ -0,0 +1,52 @@
+package user
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/spf13/cobra"
+)
+
+var deleteCmd = &cobra.Command{
+	Use:   "delete USER",
+	Short: "Delete a user, given by ID or username, along with their submissions and votes",
+	Long: "Deletes a user and everything they did in sessions, which changes the ballots of sessions still being played. " +
+		"Users who created sessions can't be deleted. Published results are kept.",
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		userService, closeDb := openUserService()
+		defer closeDb()
+
+		user := mustGetUser(cmd.Context(), userService, args[0])
+
+		if !flagYes {
+			fmt.Printf("Delete %s (ID %d) along with their submissions and votes? [y/N] ", user.Username, user.Id)
+			answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+				fmt.Println("Aborted")
+				return
+			}
+		}
+
+		err := userService.DeleteUser(cmd.Context(), user.Id)
+		if err == core.ErrUserHasSessions {
+			log.Fatalf("%s created sessions and can't be deleted\n", user.Username)
+		} else if err != nil {
+			log.Fatalln("Failed to delete user:", err)
+		}
+
+		fmt.Printf("Deleted %s\n", user.Username)
+	},
+}
+
+var (
+	flagYes bool
+)
+
+func init() {
+	deleteCmd.Flags().BoolVarP(&flagYes, "yes", "y", false, "Don't ask for confirmation")
+}
@@ -0,0 +1,50 @@
+package user
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List users along with their role and linked accounts",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		userService, closeDb := openUserService()
+		defer closeDb()
+
+		users, err := userService.GetAllUsers(cmd.Context())
+		if err != nil {
+			log.Fatalln("Failed to get users:", err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tUSERNAME\tDISPLAY NAME\tADMIN\tSPOTIFY\tEMAIL")
+		for _, user := range *users {
+			admin := ""
+			if user.IsAdmin {
+				admin = "yes"
+			}
+
+			spotify := ""
+			if user.IsAuthenticatedWithSpotify() {
+				spotify = "linked"
+				if user.SpotifyEmail != "" {
+					spotify = user.SpotifyEmail
+				}
+			}
+
+			email := user.Email
+			if email != "" && !user.IsEmailVerified {
+				email += " (unverified)"
+			}
+
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", user.Id, user.Username, user.DisplayName, admin, spotify, email)
+		}
+		w.Flush()
+	},
+}
@@ -0,0 +1,43 @@
+package user
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+var resetPasswordCmd = &cobra.Command{
+	Use:   "reset-password USER",
+	Short: "Set a new password for a user, given by ID or username",
+	Long:  "Sets the password given by --password, or a generated one that is printed once.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		userService, closeDb := openUserService()
+		defer closeDb()
+
+		user := mustGetUser(cmd.Context(), userService, args[0])
+
+		password := flagResetPassword
+		if password == "" {
+			password = newPassword()
+		}
+
+		if err := userService.SetPassword(cmd.Context(), user.Id, password, password); err != nil {
+			log.Fatalln("Failed to reset password:", err)
+		}
+
+		fmt.Printf("Reset the password of %s\n", user.Username)
+		if flagResetPassword == "" {
+			fmt.Println("Password:", password)
+		}
+	},
+}
+
+var (
+	flagResetPassword string
+)
+
+func init() {
+	resetPasswordCmd.Flags().StringVar(&flagResetPassword, "password", "", "The new password, generated when not given")
+}
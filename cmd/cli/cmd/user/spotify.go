@@ -0,0 +1,31 @@
+package user
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+var unlinkSpotifyCmd = &cobra.Command{
+	Use:   "unlink-spotify USER",
+	Short: "Forget the Spotify account linked to a user, given by ID or username",
+	Long:  "Clears the user's Spotify token, so they're asked to link an account again the next time they use the app.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		userService, closeDb := openUserService()
+		defer closeDb()
+
+		user := mustGetUser(cmd.Context(), userService, args[0])
+		if !user.IsAuthenticatedWithSpotify() {
+			fmt.Printf("Nothing to do, %s has no Spotify account linked\n", user.Username)
+			return
+		}
+
+		if _, err := userService.UnlinkSpotify(cmd.Context(), user.Id); err != nil {
+			log.Fatalln("Failed to unlink Spotify:", err)
+		}
+
+		fmt.Printf("Unlinked the Spotify account of %s\n", user.Username)
+	},
+}
@@ -0,0 +1,73 @@
+package user
+
+import (
+	"context"
+	"log"
+	"strconv"
+
+	"github.com/CaribouBlue/mixtape/cmd/cli/cmd/db"
+	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/loginsession"
+	"github.com/CaribouBlue/mixtape/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var UserCmd = &cobra.Command{
+	Use:   "user",
+	Short: "Manage users in an app database",
+	Run: func(cmd *cobra.Command, args []string) {
+	},
+}
+
+func init() {
+	db.AddDbPathFlag(UserCmd)
+
+	UserCmd.AddCommand(listCmd)
+	UserCmd.AddCommand(createCmd)
+	UserCmd.AddCommand(promoteCmd)
+	UserCmd.AddCommand(demoteCmd)
+	UserCmd.AddCommand(resetPasswordCmd)
+	UserCmd.AddCommand(deleteCmd)
+	UserCmd.AddCommand(unlinkSpotifyCmd)
+}
+
+func openUserService() (*core.UserService, func()) {
+	store, err := storage.NewSqliteDb(db.DbPath())
+	if err != nil {
+		log.Fatalln("Failed to connect to the database:", err)
+	}
+
+	// Password resets log the user out everywhere, like they do in the app.
+	events := core.NewEventBus()
+	loginsession.NewLoginSessionService(store).Subscribe(events)
+
+	return core.NewUserService(store, core.WithEventBus(events)), func() { store.Close() }
+}
+
+// mustGetUser looks a user up by ID, or by username when the argument isn't a number.
+func mustGetUser(ctx context.Context, userService *core.UserService, arg string) *core.UserEntity {
+	var user *core.UserEntity
+	var err error
+	if userId, parseErr := strconv.ParseInt(arg, 10, 64); parseErr == nil {
+		user, err = userService.GetUserById(ctx, userId)
+	} else {
+		user, err = userService.GetUserByUsername(ctx, arg)
+	}
+
+	if err == core.ErrUserNotFound {
+		log.Fatalln("No such user:", arg)
+	} else if err != nil {
+		log.Fatalln("Failed to get user:", err)
+	}
+
+	return user
+}
+
+// newPassword returns a random password for when an admin doesn't pick one.
+func newPassword() string {
+	password, err := core.GeneratePassword()
+	if err != nil {
+		log.Fatalln("Failed to generate a password:", err)
+	}
+	return password
+}
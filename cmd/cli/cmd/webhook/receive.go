@@ -0,0 +1,87 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/webhook"
+	"github.com/spf13/cobra"
+)
+
+var receiveCmd = &cobra.Command{
+	Use:   "receive",
+	Short: "Run a local webhook receiver that prints the events it gets",
+	Long: "Starts an HTTP server that accepts webhook requests on any path, checks their signature when --secret is set, " +
+		"and prints each event. Use --status to answer with an error and exercise retries.",
+	Run: func(cmd *cobra.Command, args []string) {
+		address := fmt.Sprintf("%s:%d", flagHost, flagPort)
+		log.Printf("Listening for webhooks on http://%s\n", address)
+
+		err := http.ListenAndServe(address, http.HandlerFunc(handleWebhook))
+		if err != nil {
+			log.Fatalln("Receiver stopped:", err)
+		}
+	},
+}
+
+func handleWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	signature := "not checked"
+	if flagReceiveSecret != "" {
+		err := webhook.Verify(flagReceiveSecret, r.Header.Get(webhook.HeaderSignature), payload, flagTolerance)
+		if err != nil {
+			log.Printf("Rejected %s delivery %s: %s\n", r.Header.Get(webhook.HeaderEvent), r.Header.Get(webhook.HeaderDelivery), err)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		signature = "valid"
+	}
+
+	var body bytes.Buffer
+	if err := json.Indent(&body, payload, "", "  "); err != nil {
+		body.Reset()
+		body.Write(payload)
+	}
+
+	log.Printf("Received %s delivery %s on %s (signature %s)\n%s\n",
+		r.Header.Get(webhook.HeaderEvent),
+		r.Header.Get(webhook.HeaderDelivery),
+		r.URL.Path,
+		signature,
+		body.String(),
+	)
+
+	w.WriteHeader(flagStatus)
+	fmt.Fprintln(w, http.StatusText(flagStatus))
+}
+
+var (
+	flagHost          string
+	flagPort          int
+	flagReceiveSecret string
+	flagTolerance     time.Duration
+	flagStatus        int
+)
+
+func init() {
+	receiveCmd.Flags().StringVar(&flagHost, "host", "localhost", "The host to listen on")
+	receiveCmd.Flags().IntVar(&flagPort, "port", 9000, "The port to listen on")
+	receiveCmd.Flags().StringVarP(&flagReceiveSecret, "secret", "s", "", "Verify signatures with this secret")
+	receiveCmd.Flags().DurationVar(&flagTolerance, "tolerance", 5*time.Minute, "How old a signature can be before it is rejected")
+	receiveCmd.Flags().IntVar(&flagStatus, "status", http.StatusOK, "The status code to respond with")
+}
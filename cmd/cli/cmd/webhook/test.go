@@ -0,0 +1,106 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/storage"
+	"github.com/CaribouBlue/mixtape/internal/webhook"
+	"github.com/spf13/cobra"
+)
+
+var testCmd = &cobra.Command{
+	Use:   "test [URL]",
+	Short: "Send a signed sample event to a URL or a configured webhook",
+	Long: "Sends a signed sample event straight to URL, signed with --secret, and prints the response. " +
+		"With --id, a ping is sent to a webhook configured in the app database instead and recorded in its delivery log.",
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if (flagWebhookId != 0) == (len(args) == 1) {
+			log.Fatalln("Provide either a URL or --id")
+		}
+
+		if flagWebhookId != 0 {
+			testConfiguredWebhook(cmd)
+			return
+		}
+
+		if flagSecret == "" {
+			log.Fatalln("A --secret is required to sign the event")
+		}
+
+		event, err := webhook.SampleEvent(flagBaseUrl, webhook.EventType(flagEvent))
+		if err == webhook.ErrInvalidEventType {
+			log.Fatalln("Invalid event type:", flagEvent)
+		} else if err != nil {
+			log.Fatalln("Failed to build sample event:", err)
+		}
+
+		payload, err := json.Marshal(event)
+		if err != nil {
+			log.Fatalln("Failed to encode sample event:", err)
+		}
+
+		req, err := webhook.NewRequest(cmd.Context(), args[0], flagSecret, event.Type, "test", payload)
+		if err != nil {
+			log.Fatalln("Failed to build request:", err)
+		}
+
+		res, err := webhook.NewClient(flagTimeout).Do(req)
+		if err != nil {
+			log.Fatalln("Failed to send event:", err)
+		}
+		defer res.Body.Close()
+
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+		fmt.Printf("Sent %s event %s\n", event.Type, event.Id)
+		fmt.Printf("Response: %s\n", res.Status)
+		if len(body) > 0 {
+			fmt.Println(string(body))
+		}
+	},
+}
+
+func testConfiguredWebhook(cmd *cobra.Command) {
+	db, err := storage.NewSqliteDb(flagDbPath)
+	if err != nil {
+		log.Fatalln("Failed to connect to the database:", err)
+	}
+	defer db.Close()
+
+	webhookService := webhook.NewWebhookService(db, webhook.WithRequestTimeout(flagTimeout))
+
+	delivery, err := webhookService.TestWebhook(cmd.Context(), flagWebhookId)
+	if err == webhook.ErrWebhookNotFound {
+		log.Fatalf("No webhook with ID %d\n", flagWebhookId)
+	} else if err != nil {
+		log.Fatalln("Failed to send test event:", err)
+	}
+
+	fmt.Printf("Delivery %d: %s\n", delivery.Id, delivery.Status)
+	if delivery.ResponseStatus != 0 {
+		fmt.Printf("Response status: %d\n", delivery.ResponseStatus)
+	}
+	if delivery.LastError != "" {
+		fmt.Println("Error:", delivery.LastError)
+	}
+}
+
+var (
+	flagWebhookId int64
+	flagSecret    string
+	flagEvent     string
+	flagBaseUrl   string
+	flagTimeout   time.Duration
+)
+
+func init() {
+	testCmd.Flags().Int64Var(&flagWebhookId, "id", 0, "Send a ping to the configured webhook with this ID")
+	testCmd.Flags().StringVarP(&flagSecret, "secret", "s", "", "The secret to sign the event with")
+	testCmd.Flags().StringVarP(&flagEvent, "event", "e", string(webhook.EventPing), "The type of sample event to send")
+	testCmd.Flags().StringVar(&flagBaseUrl, "base-url", "http://localhost:8080", "The app URL used for links in the sample event")
+	testCmd.Flags().DurationVar(&flagTimeout, "timeout", 10*time.Second, "How long to wait for a response")
+}
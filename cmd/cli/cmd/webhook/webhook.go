@@ -0,0 +1,24 @@
+package webhook
+
+import (
+	"github.com/CaribouBlue/mixtape/cmd/cli/config"
+	"github.com/spf13/cobra"
+)
+
+var WebhookCmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "Send test webhook events and receive them locally",
+	Run: func(cmd *cobra.Command, args []string) {
+	},
+}
+
+var (
+	flagDbPath string
+)
+
+func init() {
+	WebhookCmd.PersistentFlags().StringVarP(&flagDbPath, "db-path", "p", config.GetConfigValue(config.ConfDbPath), "The path to the database")
+
+	WebhookCmd.AddCommand(testCmd)
+	WebhookCmd.AddCommand(receiveCmd)
+}
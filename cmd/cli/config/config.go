@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 
+	appconfig "github.com/CaribouBlue/mixtape/internal/config"
 	"github.com/joho/godotenv"
 )
 
@@ -14,12 +15,22 @@ type ConfigProperty struct {
 var (
 	ConfDockerContext ConfigProperty = ConfigProperty{"DOCKER_CONTEXT", "default"}
 	ConfDbPath        ConfigProperty = ConfigProperty{"DB_PATH", ""}
+	ConfEnv           ConfigProperty = ConfigProperty{"ENV", appconfig.EnvProduction}
+
+	ConfPlayServerUrl   ConfigProperty = ConfigProperty{"PLAY_SERVER_URL", "http://localhost:8080"}
+	ConfPlayAccessToken ConfigProperty = ConfigProperty{"PLAY_ACCESS_TOKEN", ""}
 )
 
+// CredentialsPath is where `play login` saves the server and access token. It's kept apart from
+// .env.cli so logging in doesn't rewrite hand-edited config.
+const CredentialsPath = ".credentials.cli"
+
 var isLoaded bool = false
 
 func Load() error {
 	isLoaded = true
+	// Credentials are optional, so a missing file isn't an error.
+	godotenv.Load(CredentialsPath)
 	return godotenv.Load(".env.cli")
 }
 
@@ -36,3 +47,25 @@ func GetConfigValue(prop ConfigProperty) string {
 	}
 	return val
 }
+
+// SaveCredentials stores the server and access token used by `play` commands, readable only by
+// the current user.
+func SaveCredentials(serverUrl string, accessToken string) error {
+	content, err := godotenv.Marshal(map[string]string{
+		ConfPlayServerUrl.Key:   serverUrl,
+		ConfPlayAccessToken.Key: accessToken,
+	})
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(CredentialsPath, []byte(content+"\n"), 0600)
+}
+
+func RemoveCredentials() error {
+	err := os.Remove(CredentialsPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"context"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/CaribouBlue/mixtape/internal/log"
 	"github.com/rs/zerolog"
@@ -15,7 +19,8 @@ func main() {
 	s := server.NewServer()
 
 	if config.GetConfigValue(config.ConfEnv) == config.EnvDevelopment {
-		log.SetDefaultLogger(log.Logger().Output(zerolog.ConsoleWriter{Out: os.Stderr}))
+		console := zerolog.MultiLevelWriter(zerolog.ConsoleWriter{Out: os.Stderr}, log.DefaultRecentErrors())
+		log.SetDefaultLogger(log.Logger().Output(console))
 		log.Logger().Warn().Msg("Using development mode")
 	} else {
 		logFile, _ := os.OpenFile(
@@ -25,12 +30,28 @@ func main() {
 		)
 		defer logFile.Close()
 
-		multi := zerolog.MultiLevelWriter(os.Stdout, logFile)
+		multi := zerolog.MultiLevelWriter(os.Stdout, logFile, log.DefaultRecentErrors())
 		log.SetDefaultLogger(zerolog.New(multi).Level(zerolog.InfoLevel).With().Timestamp().Logger())
 	}
 
-	log.Logger().Info().Str("address", s.Addr).Msg("Starting server")
-	if err := s.ListenAndServe(); err != nil {
-		log.Logger().Fatal().Err(err).Msg("Failed to start server")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		log.Logger().Info().Str("address", s.Addr).Msg("Starting server")
+		if err := s.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Logger().Fatal().Err(err).Msg("Failed to start server")
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+	log.Logger().Info().Msg("Shutting down server")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.GetConfigDuration(config.ConfShutdownTimeout))
+	defer cancel()
+
+	if err := s.Shutdown(shutdownCtx); err != nil {
+		log.Logger().Error().Err(err).Msg("Failed to shut down cleanly")
 	}
 }
@@ -0,0 +1,127 @@
+// Package accesstoken lets users create personal access tokens so scripts and bots can call the
+// API on their behalf without a browser login.
+package accesstoken
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+type Scope string
+
+const (
+	// ScopeRead allows reading sessions.
+	ScopeRead Scope = "read"
+	// ScopeSubmit allows joining sessions and submitting, removing and finalizing tracks.
+	ScopeSubmit Scope = "submit"
+	// ScopeVote allows casting and taking back votes.
+	ScopeVote Scope = "vote"
+	// ScopeSessions allows creating sessions for the crews the user is an admin of.
+	ScopeSessions Scope = "sessions"
+	// ScopeAdmin allows admin only actions, and can only be granted by admins.
+	ScopeAdmin Scope = "admin"
+)
+
+// Scopes lists every scope a token can be granted.
+var Scopes = []Scope{ScopeRead, ScopeSubmit, ScopeVote, ScopeSessions, ScopeAdmin}
+
+func IsScope(value string) bool {
+	return slices.Contains(Scopes, Scope(value))
+}
+
+// SecretPrefix starts every token secret so leaked tokens are easy to recognize, e.g. by secret
+// scanners.
+const SecretPrefix = "mxt_"
+
+// displayPrefixLength is how much of a secret is kept in the clear to tell tokens apart.
+const displayPrefixLength = len(SecretPrefix) + 8
+
+var (
+	ErrTokenNotFound        = errors.New("access token not found")
+	ErrInvalidToken         = errors.New("invalid, expired or revoked access token")
+	ErrInvalidName          = errors.New("access token name must be between 1 and 100 characters")
+	ErrInvalidScope         = errors.New("invalid access token scope")
+	ErrNoScopes             = errors.New("access token needs at least one scope")
+	ErrAdminScopeNotAllowed = errors.New("only admins can grant the admin scope")
+	ErrTooManyTokens        = errors.New("too many access tokens")
+	ErrInvalidExpiry        = errors.New("access token expiry must be in the future")
+)
+
+const (
+	MaxNameLength = 100
+	secretBytes   = 32
+)
+
+// AccessToken is a personal access token. Only a hash of the secret is stored, the secret itself
+// is shown once when the token is created.
+type AccessToken struct {
+	Id     int64
+	UserId int64
+	Name   string
+	// Prefix is the start of the secret, shown so users can tell their tokens apart.
+	Prefix     string
+	Hash       string
+	Scopes     []Scope
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+func (t *AccessToken) HasScope(scope Scope) bool {
+	return slices.Contains(t.Scopes, scope)
+}
+
+func (t *AccessToken) IsExpired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+func (t *AccessToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+func (t *AccessToken) IsActive(now time.Time) bool {
+	return !t.IsRevoked() && !t.IsExpired(now)
+}
+
+type Repository interface {
+	CreateAccessToken(ctx context.Context, token *AccessToken) (*AccessToken, error)
+	// GetAccessToken returns nil when no token has the given ID.
+	GetAccessToken(ctx context.Context, tokenId int64) (*AccessToken, error)
+	// GetAccessTokenByHash returns nil when no token has the given hash.
+	GetAccessTokenByHash(ctx context.Context, hash string) (*AccessToken, error)
+	// GetUserAccessTokens returns every token the user has created, newest first.
+	GetUserAccessTokens(ctx context.Context, userId int64) ([]AccessToken, error)
+	// CountActiveUserAccessTokens counts the user's tokens that are neither revoked nor expired.
+	CountActiveUserAccessTokens(ctx context.Context, userId int64, now time.Time) (int, error)
+	RevokeAccessToken(ctx context.Context, tokenId int64, now time.Time) error
+	SetAccessTokenLastUsed(ctx context.Context, tokenId int64, now time.Time) error
+}
+
+// newSecret returns a random token secret along with its hash.
+func newSecret() (string, string, error) {
+	b := make([]byte, secretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	secret := SecretPrefix + hex.EncodeToString(b)
+	return secret, HashSecret(secret), nil
+}
+
+// HashSecret hashes a token secret for storage and lookup. Secrets are long and random, so a fast
+// hash is enough, unlike for passwords.
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func isWellFormed(secret string) bool {
+	return strings.HasPrefix(secret, SecretPrefix) && len(secret) == len(SecretPrefix)+secretBytes*2
+}
@@ -0,0 +1,155 @@
+package accesstoken
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+)
+
+// memoryRepository keeps tokens in a slice, enough for the service's checks.
+type memoryRepository struct {
+	tokens []AccessToken
+}
+
+func (r *memoryRepository) CreateAccessToken(ctx context.Context, token *AccessToken) (*AccessToken, error) {
+	token.Id = int64(len(r.tokens) + 1)
+	r.tokens = append(r.tokens, *token)
+	return token, nil
+}
+
+func (r *memoryRepository) GetAccessToken(ctx context.Context, tokenId int64) (*AccessToken, error) {
+	for _, token := range r.tokens {
+		if token.Id == tokenId {
+			return &token, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryRepository) GetAccessTokenByHash(ctx context.Context, hash string) (*AccessToken, error) {
+	for _, token := range r.tokens {
+		if token.Hash == hash {
+			return &token, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryRepository) GetUserAccessTokens(ctx context.Context, userId int64) ([]AccessToken, error) {
+	tokens := make([]AccessToken, 0)
+	for _, token := range r.tokens {
+		if token.UserId == userId {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+func (r *memoryRepository) CountActiveUserAccessTokens(ctx context.Context, userId int64, now time.Time) (int, error) {
+	count := 0
+	for _, token := range r.tokens {
+		if token.UserId == userId && token.IsActive(now) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryRepository) RevokeAccessToken(ctx context.Context, tokenId int64, now time.Time) error {
+	for i := range r.tokens {
+		if r.tokens[i].Id == tokenId {
+			r.tokens[i].RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *memoryRepository) SetAccessTokenLastUsed(ctx context.Context, tokenId int64, now time.Time) error {
+	for i := range r.tokens {
+		if r.tokens[i].Id == tokenId {
+			r.tokens[i].LastUsedAt = &now
+		}
+	}
+	return nil
+}
+
+func TestHasScope(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []Scope
+		scope  Scope
+		want   bool
+	}{
+		{"granted", []Scope{ScopeRead}, ScopeRead, true},
+		{"one of several", []Scope{ScopeRead, ScopeSubmit, ScopeVote}, ScopeVote, true},
+		{"not granted", []Scope{ScopeRead}, ScopeSubmit, false},
+		{"read doesn't imply admin", []Scope{ScopeRead, ScopeSubmit, ScopeVote}, ScopeAdmin, false},
+		{"admin doesn't imply read", []Scope{ScopeAdmin}, ScopeRead, false},
+		{"no scopes", nil, ScopeRead, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := &AccessToken{Scopes: tt.scopes}
+			if got := token.HasScope(tt.scope); got != tt.want {
+				t.Errorf("HasScope(%q) = %v, want %v", tt.scope, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateTokenScopes(t *testing.T) {
+	user := &core.UserEntity{Id: 1}
+	admin := &core.UserEntity{Id: 2, IsAdmin: true}
+
+	tests := []struct {
+		name   string
+		user   *core.UserEntity
+		scopes []Scope
+		want   []Scope
+		err    error
+	}{
+		{"single scope", user, []Scope{ScopeRead}, []Scope{ScopeRead}, nil},
+		{"sorted and deduplicated", user, []Scope{ScopeVote, ScopeRead, ScopeVote}, []Scope{ScopeRead, ScopeVote}, nil},
+		{"no scopes", user, nil, nil, ErrNoScopes},
+		{"unknown scope", user, []Scope{ScopeRead, "write"}, nil, ErrInvalidScope},
+		{"admin scope for a user", user, []Scope{ScopeRead, ScopeAdmin}, nil, ErrAdminScopeNotAllowed},
+		{"admin scope for an admin", admin, []Scope{ScopeAdmin}, []Scope{ScopeAdmin}, nil},
+		{"sessions scope for a user", user, []Scope{ScopeSessions, ScopeRead}, []Scope{ScopeRead, ScopeSessions}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository := &memoryRepository{}
+			service := NewAccessTokenService(repository)
+
+			token, secret, err := service.CreateToken(context.Background(), tt.user, "bot", tt.scopes, 0)
+			if err != tt.err {
+				t.Fatalf("CreateToken() error = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				if len(repository.tokens) != 0 {
+					t.Errorf("CreateToken() stored a token despite failing")
+				}
+				return
+			}
+
+			if !slices.Equal(token.Scopes, tt.want) {
+				t.Errorf("CreateToken() scopes = %v, want %v", token.Scopes, tt.want)
+			}
+
+			authenticated, err := service.Authenticate(context.Background(), secret)
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			for _, scope := range Scopes {
+				if got, want := authenticated.HasScope(scope), slices.Contains(tt.want, scope); got != want {
+					t.Errorf("authenticated token HasScope(%q) = %v, want %v", scope, got, want)
+				}
+			}
+		})
+	}
+}
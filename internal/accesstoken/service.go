@@ -0,0 +1,176 @@
+package accesstoken
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+)
+
+type AccessTokenServiceOpts struct {
+	// MaxTokensPerUser caps how many active tokens a user can have at once.
+	MaxTokensPerUser int
+	// LastUsedInterval is how stale a token's last use can get before it's recorded again, so
+	// busy clients don't cause a write on every request.
+	LastUsedInterval time.Duration
+}
+
+type AccessTokenServiceOption func(*AccessTokenServiceOpts)
+
+func WithMaxTokensPerUser(max int) AccessTokenServiceOption {
+	return func(opts *AccessTokenServiceOpts) {
+		opts.MaxTokensPerUser = max
+	}
+}
+
+func WithLastUsedInterval(interval time.Duration) AccessTokenServiceOption {
+	return func(opts *AccessTokenServiceOpts) {
+		opts.LastUsedInterval = interval
+	}
+}
+
+type AccessTokenService struct {
+	opts       AccessTokenServiceOpts
+	repository Repository
+}
+
+func NewAccessTokenService(repository Repository, options ...AccessTokenServiceOption) *AccessTokenService {
+	opts := AccessTokenServiceOpts{
+		MaxTokensPerUser: 20,
+		LastUsedInterval: time.Minute,
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	return &AccessTokenService{
+		opts:       opts,
+		repository: repository,
+	}
+}
+
+// CreateToken creates a token for the user and returns it along with its secret, which can't be
+// recovered later. A zero ttl creates a token that never expires.
+func (s *AccessTokenService) CreateToken(ctx context.Context, user *core.UserEntity, name string, scopes []Scope, ttl time.Duration) (*AccessToken, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > MaxNameLength {
+		return nil, "", ErrInvalidName
+	}
+
+	if len(scopes) == 0 {
+		return nil, "", ErrNoScopes
+	}
+	for _, scope := range scopes {
+		if !IsScope(string(scope)) {
+			return nil, "", ErrInvalidScope
+		}
+		if scope == ScopeAdmin && !user.IsAdmin {
+			return nil, "", ErrAdminScopeNotAllowed
+		}
+	}
+
+	if ttl < 0 {
+		return nil, "", ErrInvalidExpiry
+	}
+
+	now := core.Now()
+	count, err := s.repository.CountActiveUserAccessTokens(ctx, user.Id, now)
+	if err != nil {
+		return nil, "", err
+	}
+	if count >= s.opts.MaxTokensPerUser {
+		return nil, "", ErrTooManyTokens
+	}
+
+	secret, hash, err := newSecret()
+	if err != nil {
+		return nil, "", err
+	}
+
+	token := &AccessToken{
+		UserId:    user.Id,
+		Name:      name,
+		Prefix:    secret[:displayPrefixLength],
+		Hash:      hash,
+		Scopes:    dedupeScopes(scopes),
+		CreatedAt: now,
+	}
+	if ttl > 0 {
+		expiresAt := now.Add(ttl)
+		token.ExpiresAt = &expiresAt
+	}
+
+	token, err = s.repository.CreateAccessToken(ctx, token)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return token, secret, nil
+}
+
+func (s *AccessTokenService) GetUserTokens(ctx context.Context, userId int64) ([]AccessToken, error) {
+	return s.repository.GetUserAccessTokens(ctx, userId)
+}
+
+// RevokeToken revokes one of the user's tokens. Tokens of other users are reported as not found.
+func (s *AccessTokenService) RevokeToken(ctx context.Context, userId int64, tokenId int64) (*AccessToken, error) {
+	token, err := s.repository.GetAccessToken(ctx, tokenId)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || token.UserId != userId {
+		return nil, ErrTokenNotFound
+	}
+
+	if token.IsRevoked() {
+		return token, nil
+	}
+
+	now := core.Now()
+	if err := s.repository.RevokeAccessToken(ctx, tokenId, now); err != nil {
+		return nil, err
+	}
+	token.RevokedAt = &now
+
+	return token, nil
+}
+
+// Authenticate returns the active token with the given secret and records that it was used.
+func (s *AccessTokenService) Authenticate(ctx context.Context, secret string) (*AccessToken, error) {
+	if !isWellFormed(secret) {
+		return nil, ErrInvalidToken
+	}
+
+	token, err := s.repository.GetAccessTokenByHash(ctx, HashSecret(secret))
+	if err != nil {
+		return nil, err
+	}
+
+	now := core.Now()
+	if token == nil || !token.IsActive(now) {
+		return nil, ErrInvalidToken
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= s.opts.LastUsedInterval {
+		if err := s.repository.SetAccessTokenLastUsed(ctx, token.Id, now); err != nil {
+			return nil, err
+		}
+		token.LastUsedAt = &now
+	}
+
+	return token, nil
+}
+
+func dedupeScopes(scopes []Scope) []Scope {
+	deduped := make([]Scope, 0, len(scopes))
+	for _, scope := range Scopes {
+		for _, s := range scopes {
+			if s == scope {
+				deduped = append(deduped, scope)
+				break
+			}
+		}
+	}
+	return deduped
+}
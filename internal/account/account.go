@@ -0,0 +1,312 @@
+package account
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/log"
+	"github.com/CaribouBlue/mixtape/internal/mail"
+)
+
+type AccountServiceOpts struct {
+	BaseUrl              string
+	EmailVerificationTTL time.Duration
+	PasswordResetTTL     time.Duration
+	MagicLinkTTL         time.Duration
+	// MagicLinkRateLimit is how many login links an account can be sent per MagicLinkRateWindow.
+	MagicLinkRateLimit  int
+	MagicLinkRateWindow time.Duration
+	// PasswordResetRateLimit is how many reset links an account can be sent per
+	// PasswordResetRateWindow.
+	PasswordResetRateLimit  int
+	PasswordResetRateWindow time.Duration
+	// IpRateLimit is how many login or reset links can be requested from one IP address per window,
+	// whichever accounts they're for.
+	IpRateLimit int
+}
+
+// AccountService runs the account flows that are confirmed through a link sent by email.
+type AccountService struct {
+	opts        AccountServiceOpts
+	tokens      TokenRepository
+	transactor  core.Transactor
+	userService *core.UserService
+	mailService *mail.MailService
+}
+
+func NewAccountService(opts AccountServiceOpts, tokens TokenRepository, transactor core.Transactor, userService *core.UserService, mailService *mail.MailService) *AccountService {
+	return &AccountService{
+		opts:        opts,
+		tokens:      tokens,
+		transactor:  transactor,
+		userService: userService,
+		mailService: mailService,
+	}
+}
+
+// RequestEmailVerification saves the email as the user's unverified address and emails it a
+// verification link.
+func (s *AccountService) RequestEmailVerification(ctx context.Context, user *core.UserEntity, email string) error {
+	email, err := core.NormalizeEmail(email)
+	if err != nil {
+		return err
+	}
+
+	existingUser, err := s.userService.GetUserByEmail(ctx, email)
+	if err != nil && err != core.ErrUserNotFound {
+		return err
+	} else if existingUser != nil && existingUser.Id != user.Id {
+		return core.ErrEmailAlreadyInUse
+	}
+
+	return s.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		if err := s.userService.SetEmail(ctx, user.Id, email, false); err != nil {
+			return err
+		}
+
+		link, err := s.issueToken(ctx, user.Id, TokenPurposeEmailVerification, email, "", s.opts.EmailVerificationTTL, "/auth/email/verify")
+		if err != nil {
+			return err
+		}
+
+		message, err := verificationMessage(ctx, user, email, link, s.opts.EmailVerificationTTL)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.mailService.Enqueue(ctx, message)
+		return err
+	})
+}
+
+// VerifyEmail marks the address a verification token was sent to as the user's verified email.
+func (s *AccountService) VerifyEmail(ctx context.Context, tokenString string) (*core.UserEntity, error) {
+	var userId int64
+	err := s.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		token, err := s.consumeToken(ctx, tokenString, TokenPurposeEmailVerification)
+		if err != nil {
+			return err
+		}
+		userId = token.UserId
+
+		if err := s.userService.SetEmail(ctx, token.UserId, token.Email, true); err != nil {
+			return err
+		}
+
+		return s.tokens.RevokeUserTokens(ctx, token.UserId, TokenPurposeEmailVerification)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.userService.GetUserById(ctx, userId)
+}
+
+// RequestPasswordReset emails a password reset link to the verified email of the account with the
+// given username or email. It doesn't report whether an account was found so it can't be used to
+// discover which usernames and emails are registered, including when too many links were sent.
+func (s *AccountService) RequestPasswordReset(ctx context.Context, usernameOrEmail string, ipAddress string) error {
+	user, err := s.findUser(ctx, usernameOrEmail)
+	if err != nil {
+		return err
+	} else if user == nil || user.VerifiedEmail() == "" {
+		log.Logger().Debug().Str("usernameOrEmail", usernameOrEmail).Msg("No verified email for password reset")
+		return nil
+	}
+
+	isLimited, err := s.isRateLimited(ctx, user.Id, ipAddress, TokenPurposePasswordReset, s.opts.PasswordResetRateLimit, s.opts.PasswordResetRateWindow)
+	if err != nil || isLimited {
+		return err
+	}
+
+	return s.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		link, err := s.issueToken(ctx, user.Id, TokenPurposePasswordReset, user.VerifiedEmail(), ipAddress, s.opts.PasswordResetTTL, "/auth/user/reset-password")
+		if err != nil {
+			return err
+		}
+
+		message, err := passwordResetMessage(ctx, user, link, s.opts.PasswordResetTTL)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.mailService.Enqueue(ctx, message)
+		return err
+	})
+}
+
+func (s *AccountService) findUser(ctx context.Context, usernameOrEmail string) (*core.UserEntity, error) {
+	if strings.Contains(usernameOrEmail, "@") {
+		user, err := s.userService.GetUserByEmail(ctx, usernameOrEmail)
+		if err == core.ErrUserNotFound || err == core.ErrInvalidEmail {
+			return nil, nil
+		}
+		return user, err
+	}
+
+	user, err := s.userService.GetUserByUsername(ctx, usernameOrEmail)
+	if err == core.ErrUserNotFound {
+		return nil, nil
+	}
+	return user, err
+}
+
+// CheckPasswordResetToken reports whether a password reset link is still usable without using it up.
+func (s *AccountService) CheckPasswordResetToken(tokenString string) error {
+	_, err := parseToken(tokenString, TokenPurposePasswordReset)
+	return err
+}
+
+// ResetPassword sets a new password for the user a password reset token was issued to. Every
+// other reset link the user was sent stops working.
+func (s *AccountService) ResetPassword(ctx context.Context, tokenString, password, confirmPassword string) error {
+	if password != confirmPassword {
+		return core.ErrPasswordsDoNotMatch
+	}
+
+	return s.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		token, err := s.consumeToken(ctx, tokenString, TokenPurposePasswordReset)
+		if err != nil {
+			return err
+		}
+
+		if err := s.userService.SetPassword(ctx, token.UserId, password, confirmPassword); err != nil {
+			return err
+		}
+
+		return s.tokens.RevokeUserTokens(ctx, token.UserId, TokenPurposePasswordReset)
+	})
+}
+
+// RequestMagicLink emails a login link to the account that has verified the given email. Like
+// RequestPasswordReset, it doesn't report whether an account was found, including when the
+// account has been sent too many links recently.
+func (s *AccountService) RequestMagicLink(ctx context.Context, email string, ipAddress string) error {
+	user, err := s.userService.GetUserByEmail(ctx, email)
+	if err == core.ErrUserNotFound || err == core.ErrInvalidEmail {
+		log.Logger().Debug().Str("email", email).Msg("No account for magic link")
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	isLimited, err := s.isRateLimited(ctx, user.Id, ipAddress, TokenPurposeMagicLink, s.opts.MagicLinkRateLimit, s.opts.MagicLinkRateWindow)
+	if err != nil || isLimited {
+		return err
+	}
+
+	return s.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		link, err := s.issueToken(ctx, user.Id, TokenPurposeMagicLink, user.Email, ipAddress, s.opts.MagicLinkTTL, "/auth/user/magic-link/confirm")
+		if err != nil {
+			return err
+		}
+
+		message, err := magicLinkMessage(ctx, user, link, s.opts.MagicLinkTTL)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.mailService.Enqueue(ctx, message)
+		return err
+	})
+}
+
+// CheckMagicLink returns the user a login link was sent to without using the link up.
+func (s *AccountService) CheckMagicLink(ctx context.Context, tokenString string) (*core.UserEntity, error) {
+	_, userId, err := parseTokenClaims(tokenString, TokenPurposeMagicLink)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userService.GetUserById(ctx, userId)
+	if err == core.ErrUserNotFound {
+		return nil, ErrInvalidToken
+	}
+	return user, err
+}
+
+// LoginWithMagicLink uses up a login link and returns the user it logs in. Every other login link
+// the user was sent stops working.
+func (s *AccountService) LoginWithMagicLink(ctx context.Context, tokenString string) (*core.UserEntity, error) {
+	var userId int64
+	err := s.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		token, err := s.consumeToken(ctx, tokenString, TokenPurposeMagicLink)
+		if err != nil {
+			return err
+		}
+		userId = token.UserId
+
+		return s.tokens.RevokeUserTokens(ctx, token.UserId, TokenPurposeMagicLink)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.userService.GetUserById(ctx, userId)
+}
+
+// issueToken stores a new token and returns the link that redeems it.
+// isRateLimited reports whether the account, or the IP address the request came from, has been
+// sent as many links for the purpose as it's allowed within the window.
+func (s *AccountService) isRateLimited(ctx context.Context, userId int64, ipAddress string, purpose TokenPurpose, limit int, window time.Duration) (bool, error) {
+	since := core.Now().Add(-window)
+
+	count, err := s.tokens.CountUserTokensSince(ctx, userId, purpose, since)
+	if err != nil {
+		return false, err
+	} else if count >= limit {
+		log.Logger().Warn().Int64("userId", userId).Str("purpose", string(purpose)).Msg("Account email rate limit reached")
+		return true, nil
+	}
+
+	if ipAddress == "" {
+		return false, nil
+	}
+
+	count, err = s.tokens.CountIpTokensSince(ctx, ipAddress, purpose, since)
+	if err != nil {
+		return false, err
+	} else if count >= s.opts.IpRateLimit {
+		log.Logger().Warn().Str("ip", ipAddress).Str("purpose", string(purpose)).Msg("IP address email rate limit reached")
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (s *AccountService) issueToken(ctx context.Context, userId int64, purpose TokenPurpose, email string, ipAddress string, ttl time.Duration, path string) (string, error) {
+	token, err := newUserToken(userId, purpose, email, ttl)
+	if err != nil {
+		return "", err
+	}
+	token.IpAddress = ipAddress
+
+	if err := s.tokens.CreateUserToken(ctx, token); err != nil {
+		return "", err
+	}
+
+	signed, err := token.sign()
+	if err != nil {
+		return "", err
+	}
+
+	return tokenUrl(s.opts.BaseUrl, path, signed), nil
+}
+
+func (s *AccountService) consumeToken(ctx context.Context, tokenString string, purpose TokenPurpose) (*UserToken, error) {
+	tokenId, err := parseToken(tokenString, purpose)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := s.tokens.ConsumeUserToken(ctx, tokenId, purpose, core.Now())
+	if err != nil {
+		return nil, err
+	} else if token == nil {
+		return nil, ErrInvalidToken
+	}
+
+	return token, nil
+}
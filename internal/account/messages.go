@@ -0,0 +1,69 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	netUrl "net/url"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/mail"
+	"github.com/CaribouBlue/mixtape/internal/templates"
+)
+
+func tokenUrl(baseUrl string, path string, token string) string {
+	return baseUrl + path + "?" + netUrl.Values{"token": {token}}.Encode()
+}
+
+func newMessage(ctx context.Context, to string, subject string, content templates.EmailContent) (*mail.Message, error) {
+	message := mail.NewMessage(to, subject)
+	message.Text = content.PlainText()
+	if err := message.SetHtml(ctx, templates.EmailMessage(subject, content)); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+func verificationMessage(ctx context.Context, user *core.UserEntity, email string, link string, ttl time.Duration) (*mail.Message, error) {
+	return newMessage(ctx, email, "Mixtape: verify your email", templates.EmailContent{
+		Greeting: fmt.Sprintf("Hi %s,", user.DisplayName),
+		Paragraphs: []string{
+			fmt.Sprintf("Confirm that %s is your email address to receive Mixtape emails there. The link expires in %s.", email, formatTTL(ttl)),
+		},
+		Links:  []templates.EmailLink{{Text: "Verify your email", Url: link}},
+		Footer: "If you didn't add this address to a Mixtape account, you can ignore this email.",
+	})
+}
+
+func passwordResetMessage(ctx context.Context, user *core.UserEntity, link string, ttl time.Duration) (*mail.Message, error) {
+	return newMessage(ctx, user.VerifiedEmail(), "Mixtape: reset your password", templates.EmailContent{
+		Greeting: fmt.Sprintf("Hi %s,", user.DisplayName),
+		Paragraphs: []string{
+			fmt.Sprintf("Someone asked to reset the password for your Mixtape account, %s. The link expires in %s and can only be used once.", user.Username, formatTTL(ttl)),
+		},
+		Links:  []templates.EmailLink{{Text: "Reset your password", Url: link}},
+		Footer: "If you didn't ask to reset your password, you can ignore this email.",
+	})
+}
+
+func magicLinkMessage(ctx context.Context, user *core.UserEntity, link string, ttl time.Duration) (*mail.Message, error) {
+	return newMessage(ctx, user.Email, "Mixtape: your login link", templates.EmailContent{
+		Greeting: fmt.Sprintf("Hi %s,", user.DisplayName),
+		Paragraphs: []string{
+			fmt.Sprintf("Use this link to log in to Mixtape as %s. It expires in %s and can only be used once.", user.Username, formatTTL(ttl)),
+		},
+		Links:  []templates.EmailLink{{Text: "Log in to Mixtape", Url: link}},
+		Footer: "If you didn't ask for a login link, you can ignore this email.",
+	})
+}
+
+func formatTTL(ttl time.Duration) string {
+	if ttl >= time.Hour && ttl%time.Hour == 0 {
+		hours := int(ttl / time.Hour)
+		if hours == 1 {
+			return "1 hour"
+		}
+		return fmt.Sprintf("%d hours", hours)
+	}
+	return fmt.Sprintf("%d minutes", int(ttl/time.Minute))
+}
@@ -0,0 +1,122 @@
+package account
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/config"
+	"github.com/CaribouBlue/mixtape/internal/core"
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+type TokenPurpose string
+
+const (
+	TokenPurposeEmailVerification TokenPurpose = "email-verification"
+	TokenPurposePasswordReset     TokenPurpose = "password-reset"
+	TokenPurposeMagicLink         TokenPurpose = "magic-link"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid or expired token")
+	ErrRateLimited  = errors.New("too many tokens requested")
+)
+
+// UserToken records a token sent to a user so it can only be used once.
+type UserToken struct {
+	Id      string
+	UserId  int64
+	Purpose TokenPurpose
+	// Email is the address the token was sent to.
+	Email string
+	// IpAddress is where the token was requested from, if it was requested without logging in.
+	IpAddress string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+type TokenRepository interface {
+	CreateUserToken(ctx context.Context, token *UserToken) error
+	// ConsumeUserToken marks an unused token that hasn't expired as used and returns it, or
+	// returns nil when there is no such token.
+	ConsumeUserToken(ctx context.Context, tokenId string, purpose TokenPurpose, now time.Time) (*UserToken, error)
+	// RevokeUserTokens marks every unused token the user has for the purpose as used.
+	RevokeUserTokens(ctx context.Context, userId int64, purpose TokenPurpose) error
+	CountUserTokensSince(ctx context.Context, userId int64, purpose TokenPurpose, since time.Time) (int, error)
+	CountIpTokensSince(ctx context.Context, ipAddress string, purpose TokenPurpose, since time.Time) (int, error)
+}
+
+func newUserToken(userId int64, purpose TokenPurpose, email string, ttl time.Duration) (*UserToken, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	now := core.Now()
+	return &UserToken{
+		Id:        hex.EncodeToString(id),
+		UserId:    userId,
+		Purpose:   purpose,
+		Email:     email,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}, nil
+}
+
+// sign encodes the token as a JWT so links can't be forged or altered. The stored record is
+// what makes it single use.
+func (t *UserToken) sign() (string, error) {
+	secretKey := config.GetConfigValue(config.ConfJwtSecret)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"jti":     t.Id,
+		"userId":  t.UserId,
+		"purpose": string(t.Purpose),
+		"exp":     t.ExpiresAt.Unix(),
+	})
+
+	return token.SignedString([]byte(secretKey))
+}
+
+// parseToken verifies a signed token's signature, expiry and purpose and returns its id.
+func parseToken(tokenString string, purpose TokenPurpose) (string, error) {
+	id, _, err := parseTokenClaims(tokenString, purpose)
+	return id, err
+}
+
+// parseTokenClaims is parseToken that also returns the id of the user the token was issued to.
+func parseTokenClaims(tokenString string, purpose TokenPurpose) (string, int64, error) {
+	secretKey := config.GetConfigValue(config.ConfJwtSecret)
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(secretKey), nil
+	}, jwt.WithExpirationRequired())
+	if err != nil {
+		return "", 0, ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != string(purpose) {
+		return "", 0, ErrInvalidToken
+	}
+
+	id, ok := claims["jti"].(string)
+	if !ok || id == "" {
+		return "", 0, ErrInvalidToken
+	}
+
+	userId, ok := claims["userId"].(float64)
+	if !ok {
+		return "", 0, ErrInvalidToken
+	}
+
+	return id, int64(userId), nil
+}
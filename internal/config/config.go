@@ -1,8 +1,11 @@
 package config
 
 import (
+	"net/netip"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/CaribouBlue/mixtape/internal/log"
 
@@ -59,7 +62,6 @@ func newConfigProperty(key string, isSecret bool, opts ...ConfigPropertyOpt) Con
 }
 
 var (
-	ConfAccessCode          ConfigProperty = newConfigProperty("ACCESS_CODE", true)
 	ConfDbPath              ConfigProperty = newConfigProperty("DB_PATH", false, withIsRequired(true))
 	ConfGmailUsername       ConfigProperty = newConfigProperty("GMAIL_USERNAME", true)
 	ConfGmailPassword       ConfigProperty = newConfigProperty("GMAIL_PASSWORD", true)
@@ -76,8 +78,135 @@ var (
 	}))
 	ConfJwtSecret   ConfigProperty = newConfigProperty("JWT_SECRET", true, withIsRequired(true))
 	ConfLogFilePath ConfigProperty = newConfigProperty("LOG_FILE_PATH", false, withDefaultValue("app.log"))
+
+	ConfSqliteJournalMode    ConfigProperty = newConfigProperty("SQLITE_JOURNAL_MODE", false, withDefaultValue("WAL"))
+	ConfSqliteBusyTimeout    ConfigProperty = newConfigProperty("SQLITE_BUSY_TIMEOUT", false, withDefaultValue("5s"), withValidation(isDuration))
+	ConfSqliteForeignKeys    ConfigProperty = newConfigProperty("SQLITE_FOREIGN_KEYS", false, withDefaultValue("true"), withValidation(isBool))
+	ConfSqliteSynchronous    ConfigProperty = newConfigProperty("SQLITE_SYNCHRONOUS", false, withDefaultValue("NORMAL"))
+	ConfSqliteMaxReaderConns ConfigProperty = newConfigProperty("SQLITE_MAX_READER_CONNS", false, withDefaultValue("4"), withValidation(isInt))
+	ConfSqliteQueryTimeout   ConfigProperty = newConfigProperty("SQLITE_QUERY_TIMEOUT", false, withDefaultValue("10s"), withValidation(isDuration))
+
+	ConfSpotifyRequestTimeout ConfigProperty = newConfigProperty("SPOTIFY_REQUEST_TIMEOUT", false, withDefaultValue("10s"), withValidation(isDuration))
+
+	ConfAppBaseUrl              ConfigProperty = newConfigProperty("APP_BASE_URL", false, withDefaultValue("http://localhost:8080"))
+	ConfNotificationInterval    ConfigProperty = newConfigProperty("NOTIFICATION_INTERVAL", false, withDefaultValue("1m"), withValidation(isDuration))
+	ConfNotificationMaxDelay    ConfigProperty = newConfigProperty("NOTIFICATION_MAX_DELAY", false, withDefaultValue("6h"), withValidation(isDuration))
+	ConfReminderLeadTime        ConfigProperty = newConfigProperty("REMINDER_LEAD_TIME", false, withDefaultValue("24h"), withValidation(isDuration))
+	ConfResultsFreezeRetryDelay ConfigProperty = newConfigProperty("RESULTS_FREEZE_RETRY_DELAY", false, withDefaultValue("15m"), withValidation(isDuration))
+
+	ConfMailer             ConfigProperty = newConfigProperty("MAILER", false, withValidation(isMailer))
+	ConfMailFrom           ConfigProperty = newConfigProperty("MAIL_FROM", false)
+	ConfMailFileDir        ConfigProperty = newConfigProperty("MAIL_FILE_DIR", false)
+	ConfMailOutboxInterval ConfigProperty = newConfigProperty("MAIL_OUTBOX_INTERVAL", false, withDefaultValue("10s"), withValidation(isDuration))
+	ConfMailMaxAttempts    ConfigProperty = newConfigProperty("MAIL_MAX_ATTEMPTS", false, withDefaultValue("8"), withValidation(isInt))
+	ConfSmtpHost           ConfigProperty = newConfigProperty("SMTP_HOST", false)
+	ConfSmtpPort           ConfigProperty = newConfigProperty("SMTP_PORT", false, withDefaultValue("587"), withValidation(isInt))
+	ConfSmtpUsername       ConfigProperty = newConfigProperty("SMTP_USERNAME", true)
+	ConfSmtpPassword       ConfigProperty = newConfigProperty("SMTP_PASSWORD", true)
+	ConfSmtpTLSPolicy      ConfigProperty = newConfigProperty("SMTP_TLS_POLICY", false, withDefaultValue("mandatory"), withValidation(isTLSPolicy))
+
+	ConfEmailVerificationTTL    ConfigProperty = newConfigProperty("EMAIL_VERIFICATION_TTL", false, withDefaultValue("24h"), withValidation(isDuration))
+	ConfPasswordResetTTL        ConfigProperty = newConfigProperty("PASSWORD_RESET_TTL", false, withDefaultValue("1h"), withValidation(isDuration))
+	ConfMagicLinkTTL            ConfigProperty = newConfigProperty("MAGIC_LINK_TTL", false, withDefaultValue("15m"), withValidation(isDuration))
+	ConfMagicLinkRateLimit      ConfigProperty = newConfigProperty("MAGIC_LINK_RATE_LIMIT", false, withDefaultValue("3"), withValidation(isInt))
+	ConfMagicLinkRateWindow     ConfigProperty = newConfigProperty("MAGIC_LINK_RATE_WINDOW", false, withDefaultValue("15m"), withValidation(isDuration))
+	ConfPasswordResetRateLimit  ConfigProperty = newConfigProperty("PASSWORD_RESET_RATE_LIMIT", false, withDefaultValue("3"), withValidation(isInt))
+	ConfPasswordResetRateWindow ConfigProperty = newConfigProperty("PASSWORD_RESET_RATE_WINDOW", false, withDefaultValue("15m"), withValidation(isDuration))
+	ConfAccountEmailIpRateLimit ConfigProperty = newConfigProperty("ACCOUNT_EMAIL_IP_RATE_LIMIT", false, withDefaultValue("10"), withValidation(isInt))
+
+	ConfWebhookDeliveryInterval ConfigProperty = newConfigProperty("WEBHOOK_DELIVERY_INTERVAL", false, withDefaultValue("10s"), withValidation(isDuration))
+	ConfWebhookMaxAttempts      ConfigProperty = newConfigProperty("WEBHOOK_MAX_ATTEMPTS", false, withDefaultValue("6"), withValidation(isInt))
+	ConfWebhookRequestTimeout   ConfigProperty = newConfigProperty("WEBHOOK_REQUEST_TIMEOUT", false, withDefaultValue("10s"), withValidation(isDuration))
+
+	ConfAccessTokenMaxPerUser ConfigProperty = newConfigProperty("ACCESS_TOKEN_MAX_PER_USER", false, withDefaultValue("20"), withValidation(isInt))
+
+	ConfTrustedProxies ConfigProperty = newConfigProperty("TRUSTED_PROXIES", false, withValidation(isTrustedProxies))
+
+	ConfLoginSessionIdleTimeout ConfigProperty = newConfigProperty("LOGIN_SESSION_IDLE_TIMEOUT", false, withDefaultValue("168h"), withValidation(isDuration))
+
+	ConfLoginLimiterStore     ConfigProperty = newConfigProperty("LOGIN_LIMITER_STORE", false, withDefaultValue("sqlite"), withValidation(isLoginLimiterStore))
+	ConfLoginFreeAttempts     ConfigProperty = newConfigProperty("LOGIN_FREE_ATTEMPTS", false, withDefaultValue("3"), withValidation(isInt))
+	ConfLoginLockoutThreshold ConfigProperty = newConfigProperty("LOGIN_LOCKOUT_THRESHOLD", false, withDefaultValue("10"), withValidation(isInt))
+	ConfLoginLockoutDuration  ConfigProperty = newConfigProperty("LOGIN_LOCKOUT_DURATION", false, withDefaultValue("15m"), withValidation(isDuration))
+
+	ConfInvitationUserQuota  ConfigProperty = newConfigProperty("INVITATION_USER_QUOTA", false, withDefaultValue("0"), withValidation(isInt))
+	ConfInvitationUserMaxTTL ConfigProperty = newConfigProperty("INVITATION_USER_MAX_TTL", false, withDefaultValue("720h"), withValidation(isDuration))
+
+	ConfLiveMaxConnections        ConfigProperty = newConfigProperty("LIVE_MAX_CONNECTIONS", false, withDefaultValue("1000"), withValidation(isInt))
+	ConfLiveMaxSessionConnections ConfigProperty = newConfigProperty("LIVE_MAX_SESSION_CONNECTIONS", false, withDefaultValue("100"), withValidation(isInt))
+	ConfLiveHeartbeatInterval     ConfigProperty = newConfigProperty("LIVE_HEARTBEAT_INTERVAL", false, withDefaultValue("30s"), withValidation(isDuration))
+	ConfLivePollInterval          ConfigProperty = newConfigProperty("LIVE_POLL_INTERVAL", false, withDefaultValue("1s"), withValidation(isDuration))
+
+	ConfSchedulerPollInterval  ConfigProperty = newConfigProperty("SCHEDULER_POLL_INTERVAL", false, withDefaultValue("1s"), withValidation(isDuration))
+	ConfSchedulerLeaseDuration ConfigProperty = newConfigProperty("SCHEDULER_LEASE_DURATION", false, withDefaultValue("5m"), withValidation(isDuration))
+	ConfSchedulerConcurrency   ConfigProperty = newConfigProperty("SCHEDULER_CONCURRENCY", false, withDefaultValue("4"), withValidation(isInt))
+	ConfShutdownTimeout        ConfigProperty = newConfigProperty("SHUTDOWN_TIMEOUT", false, withDefaultValue("30s"), withValidation(isDuration))
 )
 
+func isDuration(value string) bool {
+	_, err := time.ParseDuration(value)
+	return err == nil
+}
+
+func isBool(value string) bool {
+	_, err := strconv.ParseBool(value)
+	return err == nil
+}
+
+func isInt(value string) bool {
+	_, err := strconv.Atoi(value)
+	return err == nil
+}
+
+const (
+	MailerSmtp  = "smtp"
+	MailerGmail = "gmail"
+	MailerFile  = "file"
+	MailerNoop  = "noop"
+)
+
+// isMailer accepts an empty value, which picks Gmail when its credentials are set and otherwise
+// discards mail.
+func isMailer(value string) bool {
+	switch value {
+	case "", MailerSmtp, MailerGmail, MailerFile, MailerNoop:
+		return true
+	}
+	return false
+}
+
+func isTLSPolicy(value string) bool {
+	switch value {
+	case "mandatory", "opportunistic", "implicit", "none":
+		return true
+	}
+	return false
+}
+
+// isLoginLimiterStore checks where failed logins are counted: in memory, which only suits a single
+// server, or in the database.
+func isLoginLimiterStore(value string) bool {
+	return value == "memory" || value == "sqlite"
+}
+
+// isTrustedProxies checks a comma separated list of IP addresses and CIDR ranges, e.g.
+// "10.0.0.0/8,127.0.0.1".
+func isTrustedProxies(value string) bool {
+	for _, proxy := range strings.Split(value, ",") {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if _, err := netip.ParsePrefix(proxy); err == nil {
+			continue
+		}
+		if _, err := netip.ParseAddr(proxy); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
 var requiredConfigProperties = []*ConfigProperty{}
 var unvalidatedConfigProperties = []*ConfigProperty{}
 
@@ -123,3 +252,27 @@ func GetConfigValue(prop ConfigProperty) string {
 	}
 	return val
 }
+
+func GetConfigDuration(prop ConfigProperty) time.Duration {
+	val, err := time.ParseDuration(GetConfigValue(prop))
+	if err != nil {
+		val, _ = time.ParseDuration(prop.defaultValue)
+	}
+	return val
+}
+
+func GetConfigBool(prop ConfigProperty) bool {
+	val, err := strconv.ParseBool(GetConfigValue(prop))
+	if err != nil {
+		val, _ = strconv.ParseBool(prop.defaultValue)
+	}
+	return val
+}
+
+func GetConfigInt(prop ConfigProperty) int {
+	val, err := strconv.Atoi(GetConfigValue(prop))
+	if err != nil {
+		val, _ = strconv.Atoi(prop.defaultValue)
+	}
+	return val
+}
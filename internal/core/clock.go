@@ -0,0 +1,25 @@
+package core
+
+import "time"
+
+type Clock interface {
+	Now() time.Time
+}
+
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+var clock Clock = SystemClock{}
+
+// SetClock replaces the clock used for all phase and timestamp calculations, in core and in the
+// services and storage built on it. Tests use it to move time forward.
+func SetClock(c Clock) {
+	clock = c
+}
+
+func Now() time.Time {
+	return clock.Now()
+}
@@ -0,0 +1,401 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type CrewRole string
+
+const (
+	CrewRoleMember CrewRole = "member"
+	CrewRoleAdmin  CrewRole = "admin"
+)
+
+const MaxCrewNameLength = 50
+
+var (
+	ErrCrewNotFound          = errors.New("crew not found")
+	ErrInvalidCrewName       = errors.New("invalid crew name")
+	ErrInvalidCrewRole       = errors.New("invalid crew role")
+	ErrNotCrewMember         = errors.New("user is not a member of this crew")
+	ErrNotCrewAdmin          = errors.New("user is not an admin of this crew")
+	ErrCrewMemberNotFound    = errors.New("crew member not found")
+	ErrCrewMemberExists      = errors.New("user is already a member of this crew")
+	ErrLastCrewAdmin         = errors.New("a crew needs at least one admin")
+	ErrCrewsNotAllowed       = errors.New("only admins can create crews")
+	ErrCrewNameAlreadyExists = errors.New("crew name already exists")
+)
+
+// CrewEntity is a group of users that play sessions together. Sessions belong to a crew and only
+// its members can see and join them.
+type CrewEntity struct {
+	Id        int64
+	Name      string
+	CreatedBy int64
+	CreatedAt time.Time
+}
+
+type CrewMemberEntity struct {
+	CrewId   int64
+	UserId   int64
+	Role     CrewRole
+	JoinedAt time.Time
+}
+
+func (m *CrewMemberEntity) IsAdmin() bool {
+	return m.Role == CrewRoleAdmin
+}
+
+type CrewMemberDto struct {
+	CrewMemberEntity
+	Username    string
+	DisplayName string
+}
+
+type CrewDto struct {
+	CrewEntity
+	// CurrentMember is nil when the current user isn't a member, e.g. a global admin looking in.
+	CurrentMember *CrewMemberEntity
+	Members       *[]CrewMemberDto
+}
+
+// CanManage reports whether the user can change the crew's members and make sessions for it.
+func (c *CrewDto) CanManage(user *UserEntity) bool {
+	return user.IsAdmin || (c.CurrentMember != nil && c.CurrentMember.IsAdmin())
+}
+
+type CrewRepository interface {
+	CreateCrew(ctx context.Context, crew *CrewEntity) (*CrewEntity, error)
+	GetCrewById(ctx context.Context, id int64) (*CrewEntity, error)
+	GetCrewByName(ctx context.Context, name string) (*CrewEntity, error)
+	GetAllCrews(ctx context.Context) ([]CrewEntity, error)
+
+	AddCrewMember(ctx context.Context, member *CrewMemberEntity) error
+	GetCrewMember(ctx context.Context, crewId, userId int64) (*CrewMemberEntity, error)
+	GetCrewMembers(ctx context.Context, crewId int64) ([]CrewMemberEntity, error)
+	GetUserCrewMemberships(ctx context.Context, userId int64) ([]CrewMemberEntity, error)
+	UpdateCrewMemberRole(ctx context.Context, crewId, userId int64, role CrewRole) error
+	DeleteCrewMember(ctx context.Context, crewId, userId int64) error
+}
+
+type CrewService struct {
+	crewRepository CrewRepository
+	transactor     Transactor
+	userService    *UserService
+}
+
+func NewCrewService(crewRepository CrewRepository, transactor Transactor, userService *UserService) *CrewService {
+	return &CrewService{
+		crewRepository: crewRepository,
+		transactor:     transactor,
+		userService:    userService,
+	}
+}
+
+// CreateCrew starts a new crew with the user who created it as its first admin. Only global
+// admins can create crews.
+func (s *CrewService) CreateCrew(ctx context.Context, user *UserEntity, name string) (*CrewEntity, error) {
+	if !user.IsAdmin {
+		return nil, ErrCrewsNotAllowed
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > MaxCrewNameLength {
+		return nil, ErrInvalidCrewName
+	}
+
+	var crew *CrewEntity
+	err := s.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		existing, err := s.crewRepository.GetCrewByName(ctx, name)
+		if err != nil {
+			return err
+		} else if existing != nil {
+			return ErrCrewNameAlreadyExists
+		}
+
+		crew, err = s.crewRepository.CreateCrew(ctx, &CrewEntity{
+			Name:      name,
+			CreatedBy: user.Id,
+			CreatedAt: Now(),
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.crewRepository.AddCrewMember(ctx, &CrewMemberEntity{
+			CrewId:   crew.Id,
+			UserId:   user.Id,
+			Role:     CrewRoleAdmin,
+			JoinedAt: crew.CreatedAt,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return crew, nil
+}
+
+func (s *CrewService) GetCrewById(ctx context.Context, crewId int64) (*CrewEntity, error) {
+	crew, err := s.crewRepository.GetCrewById(ctx, crewId)
+	if err != nil {
+		return nil, err
+	} else if crew == nil {
+		return nil, ErrCrewNotFound
+	}
+	return crew, nil
+}
+
+// GetUserCrews returns the crews the user is a member of.
+func (s *CrewService) GetUserCrews(ctx context.Context, userId int64) ([]CrewDto, error) {
+	memberships, err := s.crewRepository.GetUserCrewMemberships(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	crews := make([]CrewDto, 0, len(memberships))
+	for _, membership := range memberships {
+		crew, err := s.GetCrewById(ctx, membership.CrewId)
+		if err != nil {
+			return nil, err
+		}
+
+		crews = append(crews, CrewDto{
+			CrewEntity:    *crew,
+			CurrentMember: &membership,
+		})
+	}
+
+	return crews, nil
+}
+
+// GetVisibleCrews returns the crews the user can look at: their own, or every crew for global
+// admins.
+func (s *CrewService) GetVisibleCrews(ctx context.Context, user *UserEntity) ([]CrewDto, error) {
+	crews, err := s.GetUserCrews(ctx, user.Id)
+	if err != nil || !user.IsAdmin {
+		return crews, err
+	}
+
+	isMember := make(map[int64]bool, len(crews))
+	for _, crew := range crews {
+		isMember[crew.Id] = true
+	}
+
+	allCrews, err := s.crewRepository.GetAllCrews(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, crew := range allCrews {
+		if !isMember[crew.Id] {
+			crews = append(crews, CrewDto{CrewEntity: crew})
+		}
+	}
+
+	return crews, nil
+}
+
+// GetManagedCrews returns the crews the user can make sessions for.
+func (s *CrewService) GetManagedCrews(ctx context.Context, user *UserEntity) ([]CrewDto, error) {
+	crews, err := s.GetVisibleCrews(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	managed := make([]CrewDto, 0)
+	for _, crew := range crews {
+		if crew.CanManage(user) {
+			managed = append(managed, crew)
+		}
+	}
+
+	return managed, nil
+}
+
+// GetCrewView returns a crew with its members. Crews the user can't see are reported as not found.
+func (s *CrewService) GetCrewView(ctx context.Context, crewId int64, user *UserEntity) (*CrewDto, error) {
+	crew, err := s.GetCrewById(ctx, crewId)
+	if err != nil {
+		return nil, err
+	}
+
+	currentMember, err := s.crewRepository.GetCrewMember(ctx, crewId, user.Id)
+	if err != nil {
+		return nil, err
+	} else if currentMember == nil && !user.IsAdmin {
+		return nil, ErrCrewNotFound
+	}
+
+	members, err := s.getCrewMembers(ctx, crewId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CrewDto{
+		CrewEntity:    *crew,
+		CurrentMember: currentMember,
+		Members:       members,
+	}, nil
+}
+
+func (s *CrewService) getCrewMembers(ctx context.Context, crewId int64) (*[]CrewMemberDto, error) {
+	memberEntities, err := s.crewRepository.GetCrewMembers(ctx, crewId)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]CrewMemberDto, 0, len(memberEntities))
+	for _, memberEntity := range memberEntities {
+		user, err := s.userService.GetUserById(ctx, memberEntity.UserId)
+		if err != nil {
+			return nil, err
+		}
+
+		members = append(members, CrewMemberDto{
+			CrewMemberEntity: memberEntity,
+			Username:         user.Username,
+			DisplayName:      user.DisplayName,
+		})
+	}
+
+	return &members, nil
+}
+
+// IsMember reports whether the user belongs to the crew.
+func (s *CrewService) IsMember(ctx context.Context, crewId, userId int64) (bool, error) {
+	member, err := s.crewRepository.GetCrewMember(ctx, crewId, userId)
+	if err != nil {
+		return false, err
+	}
+	return member != nil, nil
+}
+
+// CheckCrewAdmin returns ErrNotCrewAdmin unless the user can manage the crew, either as one of its
+// admins or as a global admin.
+func (s *CrewService) CheckCrewAdmin(ctx context.Context, crewId int64, user *UserEntity) error {
+	if _, err := s.GetCrewById(ctx, crewId); err != nil {
+		return err
+	}
+
+	if user.IsAdmin {
+		return nil
+	}
+
+	member, err := s.crewRepository.GetCrewMember(ctx, crewId, user.Id)
+	if err != nil {
+		return err
+	} else if member == nil || !member.IsAdmin() {
+		return ErrNotCrewAdmin
+	}
+
+	return nil
+}
+
+// AddMember adds the user with the given username to the crew as a regular member.
+func (s *CrewService) AddMember(ctx context.Context, actor *UserEntity, crewId int64, username string) (*CrewMemberEntity, error) {
+	if err := s.CheckCrewAdmin(ctx, crewId, actor); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userService.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.crewRepository.GetCrewMember(ctx, crewId, user.Id)
+	if err != nil {
+		return nil, err
+	} else if existing != nil {
+		return nil, ErrCrewMemberExists
+	}
+
+	member := &CrewMemberEntity{
+		CrewId:   crewId,
+		UserId:   user.Id,
+		Role:     CrewRoleMember,
+		JoinedAt: Now(),
+	}
+	if err := s.crewRepository.AddCrewMember(ctx, member); err != nil {
+		return nil, err
+	}
+
+	return member, nil
+}
+
+// SetMemberRole promotes a member to crew admin or demotes them back. The last admin of a crew
+// can't be demoted.
+func (s *CrewService) SetMemberRole(ctx context.Context, actor *UserEntity, crewId, userId int64, role CrewRole) error {
+	if role != CrewRoleMember && role != CrewRoleAdmin {
+		return ErrInvalidCrewRole
+	}
+
+	if err := s.CheckCrewAdmin(ctx, crewId, actor); err != nil {
+		return err
+	}
+
+	return s.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		member, err := s.crewRepository.GetCrewMember(ctx, crewId, userId)
+		if err != nil {
+			return err
+		} else if member == nil {
+			return ErrCrewMemberNotFound
+		} else if member.Role == role {
+			return nil
+		}
+
+		if member.IsAdmin() {
+			if err := s.checkOtherAdmins(ctx, crewId, userId); err != nil {
+				return err
+			}
+		}
+
+		return s.crewRepository.UpdateCrewMemberRole(ctx, crewId, userId, role)
+	})
+}
+
+// RemoveMember takes a user out of the crew. Crew admins can remove anyone and members can remove
+// themselves, but the last admin can't leave. Sessions the user already joined aren't affected.
+func (s *CrewService) RemoveMember(ctx context.Context, actor *UserEntity, crewId, userId int64) error {
+	if actor.Id != userId {
+		if err := s.CheckCrewAdmin(ctx, crewId, actor); err != nil {
+			return err
+		}
+	}
+
+	return s.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		member, err := s.crewRepository.GetCrewMember(ctx, crewId, userId)
+		if err != nil {
+			return err
+		} else if member == nil {
+			return ErrCrewMemberNotFound
+		}
+
+		if member.IsAdmin() {
+			if err := s.checkOtherAdmins(ctx, crewId, userId); err != nil {
+				return err
+			}
+		}
+
+		return s.crewRepository.DeleteCrewMember(ctx, crewId, userId)
+	})
+}
+
+func (s *CrewService) checkOtherAdmins(ctx context.Context, crewId, userId int64) error {
+	members, err := s.crewRepository.GetCrewMembers(ctx, crewId)
+	if err != nil {
+		return err
+	}
+
+	for _, member := range members {
+		if member.UserId != userId && member.IsAdmin() {
+			return nil
+		}
+	}
+
+	return ErrLastCrewAdmin
+}
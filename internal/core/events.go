@@ -0,0 +1,281 @@
+package core
+
+import (
+	"context"
+	"sync"
+)
+
+type EventName string
+
+const (
+	EventSessionCreated       EventName = "session.created"
+	EventPlayerJoined         EventName = "player.joined"
+	EventSubmissionsFinalized EventName = "submissions.finalized"
+	EventCandidateSubmitted   EventName = "candidate.submitted"
+	EventCandidateRemoved     EventName = "candidate.removed"
+	EventVoteCast             EventName = "vote.cast"
+	EventVoteRemoved          EventName = "vote.removed"
+	EventPhaseChanged         EventName = "session.phase_changed"
+	EventResultsPublished     EventName = "results.published"
+	EventUserCreated          EventName = "user.created"
+	EventUserEmailChanged     EventName = "user.email_changed"
+	EventUserPasswordChanged  EventName = "user.password_changed"
+
+	// AllEvents subscribes a handler to every event.
+	AllEvents EventName = "*"
+)
+
+// Event is something that happened in the domain. Events are plain values so subscribers can't
+// change what other subscribers see.
+type Event interface {
+	EventName() EventName
+}
+
+type SessionCreatedEvent struct {
+	Session SessionEntity
+}
+
+func (SessionCreatedEvent) EventName() EventName { return EventSessionCreated }
+
+type PlayerJoinedEvent struct {
+	Session SessionEntity
+	User    UserEntity
+}
+
+func (PlayerJoinedEvent) EventName() EventName { return EventPlayerJoined }
+
+type SubmissionsFinalizedEvent struct {
+	Session SessionEntity
+	User    UserEntity
+}
+
+func (SubmissionsFinalizedEvent) EventName() EventName { return EventSubmissionsFinalized }
+
+type CandidateSubmittedEvent struct {
+	Candidate CandidateEntity
+}
+
+func (CandidateSubmittedEvent) EventName() EventName { return EventCandidateSubmitted }
+
+type CandidateRemovedEvent struct {
+	SessionId   int64
+	CandidateId int64
+	NominatorId int64
+}
+
+func (CandidateRemovedEvent) EventName() EventName { return EventCandidateRemoved }
+
+type VoteCastEvent struct {
+	Vote VoteEntity
+}
+
+func (VoteCastEvent) EventName() EventName { return EventVoteCast }
+
+type VoteRemovedEvent struct {
+	Vote VoteEntity
+}
+
+func (VoteRemovedEvent) EventName() EventName { return EventVoteRemoved }
+
+// PhaseChangedEvent is published once per phase, shortly after the phase begins.
+type PhaseChangedEvent struct {
+	Session SessionEntity
+	Phase   SessionPhase
+}
+
+func (PhaseChangedEvent) EventName() EventName { return EventPhaseChanged }
+
+type ResultsPublishedEvent struct {
+	Session SessionEntity
+	Results []ResultEntity
+}
+
+func (ResultsPublishedEvent) EventName() EventName { return EventResultsPublished }
+
+type UserCreatedEvent struct {
+	User UserEntity
+}
+
+func (UserCreatedEvent) EventName() EventName { return EventUserCreated }
+
+type UserEmailChangedEvent struct {
+	UserId     int64
+	Email      string
+	IsVerified bool
+}
+
+func (UserEmailChangedEvent) EventName() EventName { return EventUserEmailChanged }
+
+type UserPasswordChangedEvent struct {
+	UserId int64
+}
+
+func (UserPasswordChangedEvent) EventName() EventName { return EventUserPasswordChanged }
+
+type EventHandler func(ctx context.Context, event Event) error
+
+type EventBusOpts struct {
+	// AsyncErrorHandler is called with errors returned by async subscribers, which have no
+	// publisher to return them to.
+	AsyncErrorHandler func(event Event, err error)
+}
+
+type EventBusOption func(*EventBusOpts)
+
+func WithAsyncErrorHandler(handler func(event Event, err error)) EventBusOption {
+	return func(opts *EventBusOpts) {
+		opts.AsyncErrorHandler = handler
+	}
+}
+
+// EventBus delivers domain events to in-process subscribers.
+//
+// Sync subscribers run in order before Publish returns, with the publisher's context, so inside a
+// transaction they take part in it and an error aborts the publisher. They should stick to quick
+// local work like queueing a message. Async subscribers run on their own goroutine once the
+// publisher's transaction commits, or right away outside one, and never block the publisher.
+type EventBus struct {
+	opts  EventBusOpts
+	mu    sync.RWMutex
+	sync  map[EventName][]EventHandler
+	async map[EventName][]EventHandler
+	wg    sync.WaitGroup
+}
+
+func NewEventBus(options ...EventBusOption) *EventBus {
+	opts := EventBusOpts{
+		AsyncErrorHandler: func(event Event, err error) {},
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	return &EventBus{
+		opts:  opts,
+		sync:  make(map[EventName][]EventHandler),
+		async: make(map[EventName][]EventHandler),
+	}
+}
+
+// Subscribe adds a sync subscriber for the named event, or every event with AllEvents.
+func (b *EventBus) Subscribe(name EventName, handler EventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sync[name] = append(b.sync[name], handler)
+}
+
+// SubscribeAsync adds an async subscriber for the named event, or every event with AllEvents.
+func (b *EventBus) SubscribeAsync(name EventName, handler EventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.async[name] = append(b.async[name], handler)
+}
+
+// On adds a sync subscriber for the events of type E.
+func On[E Event](b *EventBus, handler func(ctx context.Context, event E) error) {
+	var event E
+	b.Subscribe(event.EventName(), typedHandler(handler))
+}
+
+// OnAsync adds an async subscriber for the events of type E.
+func OnAsync[E Event](b *EventBus, handler func(ctx context.Context, event E) error) {
+	var event E
+	b.SubscribeAsync(event.EventName(), typedHandler(handler))
+}
+
+func typedHandler[E Event](handler func(ctx context.Context, event E) error) EventHandler {
+	return func(ctx context.Context, event Event) error {
+		typed, ok := event.(E)
+		if !ok {
+			return nil
+		}
+		return handler(ctx, typed)
+	}
+}
+
+// Publish delivers an event to its subscribers. It returns the first error from a sync subscriber,
+// after which the remaining subscribers aren't called. Publishing on a nil bus does nothing.
+func (b *EventBus) Publish(ctx context.Context, event Event) error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.RLock()
+	syncHandlers := append(append([]EventHandler{}, b.sync[event.EventName()]...), b.sync[AllEvents]...)
+	asyncHandlers := append(append([]EventHandler{}, b.async[event.EventName()]...), b.async[AllEvents]...)
+	b.mu.RUnlock()
+
+	for _, handler := range syncHandlers {
+		if err := handler(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	if len(asyncHandlers) == 0 {
+		return nil
+	}
+
+	AfterCommit(ctx, func() {
+		b.wg.Add(1)
+		go func() {
+			defer b.wg.Done()
+
+			// The request that published the event may be long gone by the time this runs.
+			ctx := context.WithoutCancel(ctx)
+			for _, handler := range asyncHandlers {
+				if err := handler(ctx, event); err != nil {
+					b.opts.AsyncErrorHandler(event, err)
+				}
+			}
+		}()
+	})
+
+	return nil
+}
+
+// Wait blocks until running async subscribers finish or ctx is done.
+func (b *EventBus) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type serviceOpts struct {
+	events     *EventBus
+	transactor Transactor
+}
+
+// ServiceOption configures the optional dependencies of the core services.
+type ServiceOption func(*serviceOpts)
+
+// WithEventBus makes a service publish its domain events to bus.
+func WithEventBus(bus *EventBus) ServiceOption {
+	return func(opts *serviceOpts) {
+		opts.events = bus
+	}
+}
+
+// WithTransactor makes a service write its changes, and publish the events about them, in a single
+// transaction. Without one, each repository call commits on its own.
+func WithTransactor(transactor Transactor) ServiceOption {
+	return func(opts *serviceOpts) {
+		opts.transactor = transactor
+	}
+}
+
+func newServiceOpts(options []ServiceOption) serviceOpts {
+	opts := serviceOpts{transactor: noTransactor{}}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	return opts
+}
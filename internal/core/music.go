@@ -1,5 +1,7 @@
 package core
 
+import "context"
+
 type TrackEntity struct {
 	Id       string
 	Name     string
@@ -28,13 +30,13 @@ type PlaylistEntity struct {
 }
 
 type MusicRepository interface {
-	AuthenticateUser(user *UserEntity) error
+	AuthenticateUser(ctx context.Context, user *UserEntity) error
 
-	GetTrackById(trackId string) (*TrackEntity, error)
-	SearchTracks(query string) ([]TrackEntity, error)
+	GetTrackById(ctx context.Context, trackId string) (*TrackEntity, error)
+	SearchTracks(ctx context.Context, query string) ([]TrackEntity, error)
 
-	CreatePlaylist(name string, trackIds []string) (*PlaylistEntity, error)
-	GetPlaylistById(playlistId string) (*PlaylistEntity, error)
+	CreatePlaylist(ctx context.Context, name string, trackIds []string) (*PlaylistEntity, error)
+	GetPlaylistById(ctx context.Context, playlistId string) (*PlaylistEntity, error)
 }
 
 type MusicService struct {
@@ -47,36 +49,36 @@ func NewMusicService(trackRepository MusicRepository) *MusicService {
 	}
 }
 
-func (s *MusicService) Authenticate(user *UserEntity) error {
-	err := s.musicRepository.AuthenticateUser(user)
+func (s *MusicService) Authenticate(ctx context.Context, user *UserEntity) error {
+	err := s.musicRepository.AuthenticateUser(ctx, user)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (s *MusicService) GetTrackById(trackId string) (*TrackEntity, error) {
-	return s.musicRepository.GetTrackById(trackId)
+func (s *MusicService) GetTrackById(ctx context.Context, trackId string) (*TrackEntity, error) {
+	return s.musicRepository.GetTrackById(ctx, trackId)
 }
 
-func (s *MusicService) SearchTracks(query string) ([]TrackEntity, error) {
-	tracks, err := s.musicRepository.SearchTracks(query)
+func (s *MusicService) SearchTracks(ctx context.Context, query string) ([]TrackEntity, error) {
+	tracks, err := s.musicRepository.SearchTracks(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 	return tracks, nil
 }
 
-func (s *MusicService) CreatePlaylist(name string, trackIds []string) (*PlaylistEntity, error) {
-	playlist, err := s.musicRepository.CreatePlaylist(name, trackIds)
+func (s *MusicService) CreatePlaylist(ctx context.Context, name string, trackIds []string) (*PlaylistEntity, error) {
+	playlist, err := s.musicRepository.CreatePlaylist(ctx, name, trackIds)
 	if err != nil {
 		return nil, err
 	}
 	return playlist, nil
 }
 
-func (s *MusicService) GetPlaylistById(playlistId string) (*PlaylistEntity, error) {
-	playlist, err := s.musicRepository.GetPlaylistById(playlistId)
+func (s *MusicService) GetPlaylistById(ctx context.Context, playlistId string) (*PlaylistEntity, error) {
+	playlist, err := s.musicRepository.GetPlaylistById(ctx, playlistId)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,144 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+var (
+	ErrSessionNotInResultPhase = errors.New("session is not in the results phase")
+)
+
+// ResultEntity is a frozen row of a session's final standings. Nominator and track details
+// are copied at the time the results are computed so later changes don't rewrite history.
+type ResultEntity struct {
+	SessionId     int64
+	CandidateId   int64
+	Place         int
+	Votes         int
+	NominatorId   int64
+	NominatorName string
+	Track         TrackEntity
+	CreatedAt     time.Time
+}
+
+func (r *ResultEntity) CandidateDto() CandidateDto {
+	track := r.Track
+	return CandidateDto{
+		CandidateEntity: CandidateEntity{
+			Id:          r.CandidateId,
+			SessionId:   r.SessionId,
+			NominatorId: r.NominatorId,
+			TrackId:     r.Track.Id,
+			Votes:       r.Votes,
+		},
+		Track: &track,
+		Nominator: &UserEntity{
+			Id:          r.NominatorId,
+			DisplayName: r.NominatorName,
+		},
+		Place: r.Place,
+	}
+}
+
+func (s *SessionService) computeResults(ctx context.Context, sessionId int64) ([]ResultEntity, error) {
+	candidates, err := s.sessionRepository.GetAllCandidates(ctx, sessionId)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := make([]CandidateEntity, len(*candidates))
+	copy(sorted, *candidates)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Votes > sorted[j].Votes
+	})
+
+	now := Now()
+	userCache := make(map[int64]*UserEntity)
+	results := make([]ResultEntity, 0, len(sorted))
+
+	place := 1
+	for i, candidate := range sorted {
+		if i > 0 && candidate.Votes < sorted[i-1].Votes {
+			place += 1
+		}
+
+		resultPlace := place
+		if candidate.Votes == 0 {
+			resultPlace = -1
+		}
+
+		track, err := s.musicService.GetTrackById(ctx, candidate.TrackId)
+		if err != nil {
+			return nil, err
+		}
+
+		nominator, ok := userCache[candidate.NominatorId]
+		if !ok {
+			nominator, err = s.userService.GetUserById(ctx, candidate.NominatorId)
+			if err != nil {
+				return nil, err
+			}
+			userCache[candidate.NominatorId] = nominator
+		}
+
+		results = append(results, ResultEntity{
+			SessionId:     sessionId,
+			CandidateId:   candidate.Id,
+			Place:         resultPlace,
+			Votes:         candidate.Votes,
+			NominatorId:   candidate.NominatorId,
+			NominatorName: nominator.DisplayName,
+			Track:         *track,
+			CreatedAt:     now,
+		})
+	}
+
+	return results, nil
+}
+
+// FreezeSessionResults stores a results snapshot for a finished session if one doesn't exist yet.
+func (s *SessionService) FreezeSessionResults(ctx context.Context, sessionId int64) ([]ResultEntity, error) {
+	session, err := s.GetSessionData(ctx, sessionId)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.ResultsFrozenAt != nil {
+		results, err := s.sessionRepository.GetResults(ctx, sessionId)
+		if err != nil {
+			return nil, err
+		}
+		return *results, nil
+	}
+
+	return s.RecomputeSessionResults(ctx, sessionId)
+}
+
+// RecomputeSessionResults replaces a finished session's results snapshot with the current standings.
+func (s *SessionService) RecomputeSessionResults(ctx context.Context, sessionId int64) ([]ResultEntity, error) {
+	session, err := s.sessionRepository.GetSessionById(ctx, sessionId)
+	if err != nil {
+		return nil, err
+	} else if session == nil {
+		return nil, ErrSessionNotFound
+	}
+
+	if session.Phase() != ResultPhase {
+		return nil, ErrSessionNotInResultPhase
+	}
+
+	results, err := s.computeResults(ctx, sessionId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.sessionRepository.ReplaceResults(ctx, sessionId, results, Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
@@ -1,10 +1,10 @@
 package core
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math"
-	"sort"
 	"time"
 
 	"github.com/CaribouBlue/mixtape/internal/utils"
@@ -26,17 +26,33 @@ var (
 	ErrUserCannotFinalizeSubmissions = errors.New("user cannot finalize submissions")
 	ErrSubmissionsRemaining          = errors.New("not all submissions have been made")
 	ErrSubmissionsFinalized          = errors.New("submissions have already been finalized")
+	ErrSessionNotFound               = errors.New("session not found")
+	ErrInvalidFastForwardPhase       = errors.New("sessions can only be fast-forwarded to the voting or results phase")
+	ErrSessionPhaseAlreadyReached    = errors.New("session has already reached this phase")
+	ErrWrongSessionPhase             = errors.New("not allowed in the session's current phase")
+	ErrPlayerNotFound                = errors.New("user has not joined this session")
+	ErrPlayerAlreadyJoined           = errors.New("user has already joined this session")
+	ErrCandidateNotFound             = errors.New("candidate not found")
+	ErrCannotVoteForOwnCandidate     = errors.New("players cannot vote for their own submissions")
+	ErrDuplicateVote                 = errors.New("candidate already voted for")
 )
 
 type SessionEntity struct {
 	Id                      int64
 	Name                    string
+	CrewId                  int64
 	CreatedBy               int64
 	CreatedAt               time.Time
 	MaxSubmissions          int
 	StartAt                 time.Time
 	SubmissionPhaseDuration time.Duration
 	VotePhaseDuration       time.Duration
+	// ResultsFrozenAt is when the results snapshot was last stored, nil while the results are
+	// pending. A session without candidates has a frozen but empty snapshot.
+	ResultsFrozenAt *time.Time
+	// ResultsFreezeFailedAt is when freezing the results last failed, so it isn't retried on every
+	// check.
+	ResultsFreezeFailedAt *time.Time
 }
 
 type SessionOption func(*SessionEntity)
@@ -47,6 +63,12 @@ func WithSessionStartAt(startAt time.Time) SessionOption {
 	}
 }
 
+func WithSessionCrew(crewId int64) SessionOption {
+	return func(session *SessionEntity) {
+		session.CrewId = crewId
+	}
+}
+
 func WithSubmissionDuration(submissionDuration time.Duration) SessionOption {
 	return func(session *SessionEntity) {
 		session.SubmissionPhaseDuration = submissionDuration
@@ -61,7 +83,7 @@ func WithVoteDuration(voteDuration time.Duration) SessionOption {
 
 func NewSessionEntity(name string, createdBy int64, options ...SessionOption) *SessionEntity {
 	day := 24 * time.Hour
-	now := time.Now()
+	now := Now()
 
 	session := &SessionEntity{
 		Name:                    name,
@@ -81,25 +103,43 @@ func NewSessionEntity(name string, createdBy int64, options ...SessionOption) *S
 }
 
 func (s *SessionEntity) Phase() SessionPhase {
-	if time.Since(s.StartAt) < s.SubmissionPhaseDuration {
+	return s.PhaseAt(Now())
+}
+
+func (s *SessionEntity) PhaseAt(t time.Time) SessionPhase {
+	elapsed := t.Sub(s.StartAt)
+
+	if elapsed < s.SubmissionPhaseDuration {
 		return SubmissionPhase
 	}
 
-	if time.Since(s.StartAt) < s.SubmissionPhaseDuration+s.VotePhaseDuration {
+	if elapsed < s.SubmissionPhaseDuration+s.VotePhaseDuration {
 		return VotePhase
 	}
 
 	return ResultPhase
 }
 
+// PhaseStartAt returns the time at which the given phase begins on this session's timeline.
+func (s *SessionEntity) PhaseStartAt(phase SessionPhase) time.Time {
+	switch phase {
+	case VotePhase:
+		return s.StartAt.Add(s.SubmissionPhaseDuration)
+	case ResultPhase:
+		return s.StartAt.Add(s.SubmissionPhaseDuration + s.VotePhaseDuration)
+	default:
+		return s.StartAt
+	}
+}
+
 func (s *SessionEntity) RemainingPhaseDuration() time.Duration {
-	sessionPhase := s.Phase()
+	now := Now()
 
-	switch {
-	case sessionPhase == SubmissionPhase:
-		return s.SubmissionPhaseDuration - time.Since(s.StartAt)
-	case sessionPhase == VotePhase:
-		return s.SubmissionPhaseDuration + s.VotePhaseDuration - time.Since(s.StartAt)
+	switch s.PhaseAt(now) {
+	case SubmissionPhase:
+		return s.PhaseStartAt(VotePhase).Sub(now)
+	case VotePhase:
+		return s.PhaseStartAt(ResultPhase).Sub(now)
 	default:
 		return 0
 	}
@@ -137,11 +177,14 @@ type PlayerEntity struct {
 
 type SessionDto struct {
 	SessionEntity
+	Crew                *CrewEntity
 	SubmittedCandidates *[]CandidateDto
 	BallotCandidates    *[]CandidateDto
 	Results             *[]CandidateDto
-	CurrentPlayer       *PlayerDto
-	Players             *[]PlayerDto
+	// ResultsPending is set in the results phase until the scheduled job has frozen the results.
+	ResultsPending bool
+	CurrentPlayer  *PlayerDto
+	Players        *[]PlayerDto
 }
 
 func (s *SessionDto) VoteCount() int {
@@ -186,6 +229,7 @@ type PlayerDto struct {
 	PlayerEntity
 	PlaylistUrl string
 	DisplayName string
+	VoteCount   int
 }
 
 func (p *PlayerDto) IsJoinedSession() bool {
@@ -193,50 +237,68 @@ func (p *PlayerDto) IsJoinedSession() bool {
 }
 
 type SessionRepository interface {
-	CreateSession(session *SessionEntity) (*SessionEntity, error)
-	GetSessionById(id int64) (*SessionEntity, error)
-	GetAllSessions() (*[]SessionEntity, error)
-
-	AddCandidate(sessionId int64, candidate *CandidateEntity) (*CandidateEntity, error)
-	GetAllCandidates(sessionId int64) (*[]CandidateEntity, error)
-	GetCandidatesByUserId(sessionId int64, userId int64) (*[]CandidateEntity, error)
-	GetCandidateByNotUserId(sessionId int64, userId int64) (*[]CandidateEntity, error)
-	GetCandidateById(sessionId int64, candidateId int64) (*CandidateEntity, error)
-	DeleteCandidate(sessionId int64, candidateId int64) error
-
-	AddVote(sessionId int64, vote *VoteEntity) (*VoteEntity, error)
-	GetVotesByUserId(sessionId int64, userId int64) (*[]VoteEntity, error)
-	GetVote(sessionId int64, userId int64, candidateId int64) (*VoteEntity, error)
-	DeleteVote(sessionId int64, userId int64, candidateId int64) error
-
-	AddPlayer(sessionId int64, player *PlayerEntity) (*PlayerEntity, error)
-	GetPlayer(sessionId int64, playerId int64) (*PlayerEntity, error)
-	GetPlayers(sessionId int64) (*[]PlayerEntity, error)
-	UpdatePlayerPlaylist(sessionId int64, playerId int64, playlistId string) error
-	FinalizePlayerSubmissions(sessionId, playerId int64) error
+	CreateSession(ctx context.Context, session *SessionEntity) (*SessionEntity, error)
+	GetSessionById(ctx context.Context, id int64) (*SessionEntity, error)
+	GetAllSessions(ctx context.Context) (*[]SessionEntity, error)
+	GetCrewMemberSessions(ctx context.Context, userId int64) (*[]SessionEntity, error)
+	UpdateSessionStartAt(ctx context.Context, id int64, startAt time.Time) error
+	DeleteSession(ctx context.Context, id int64) error
+
+	// GetResults returns the session's results snapshot, which is empty while it's pending or
+	// when the session had no candidates. ResultsFrozenAt tells the two apart.
+	GetResults(ctx context.Context, sessionId int64) (*[]ResultEntity, error)
+	// ReplaceResults stores the session's results snapshot and marks the results as frozen.
+	ReplaceResults(ctx context.Context, sessionId int64, results []ResultEntity, frozenAt time.Time) error
+	MarkResultsFreezeFailed(ctx context.Context, sessionId int64, failedAt time.Time) error
+
+	AddCandidate(ctx context.Context, sessionId int64, candidate *CandidateEntity) (*CandidateEntity, error)
+	GetAllCandidates(ctx context.Context, sessionId int64) (*[]CandidateEntity, error)
+	GetCandidatesByUserId(ctx context.Context, sessionId int64, userId int64) (*[]CandidateEntity, error)
+	GetCandidateByNotUserId(ctx context.Context, sessionId int64, userId int64) (*[]CandidateEntity, error)
+	GetCandidateById(ctx context.Context, sessionId int64, candidateId int64) (*CandidateEntity, error)
+	DeleteCandidate(ctx context.Context, sessionId int64, candidateId int64) error
+
+	AddVote(ctx context.Context, sessionId int64, vote *VoteEntity) (*VoteEntity, error)
+	GetVotesByUserId(ctx context.Context, sessionId int64, userId int64) (*[]VoteEntity, error)
+	GetVote(ctx context.Context, sessionId int64, userId int64, candidateId int64) (*VoteEntity, error)
+	DeleteVote(ctx context.Context, sessionId int64, userId int64, candidateId int64) error
+
+	AddPlayer(ctx context.Context, sessionId int64, player *PlayerEntity) (*PlayerEntity, error)
+	GetPlayer(ctx context.Context, sessionId int64, playerId int64) (*PlayerEntity, error)
+	GetPlayers(ctx context.Context, sessionId int64) (*[]PlayerEntity, error)
+	UpdatePlayerPlaylist(ctx context.Context, sessionId int64, playerId int64, playlistId string) error
+	FinalizePlayerSubmissions(ctx context.Context, sessionId, playerId int64) error
 }
 
 type SessionService struct {
 	sessionRepository SessionRepository
 	userService       *UserService
+	crewService       *CrewService
 	musicService      *MusicService
+	events            *EventBus
+	transactor        Transactor
 }
 
-func NewSessionService(sessionRepository SessionRepository, userService *UserService, musicService *MusicService) *SessionService {
+func NewSessionService(sessionRepository SessionRepository, userService *UserService, crewService *CrewService, musicService *MusicService, options ...ServiceOption) *SessionService {
+	opts := newServiceOpts(options)
+
 	return &SessionService{
 		sessionRepository: sessionRepository,
 		userService:       userService,
+		crewService:       crewService,
 		musicService:      musicService,
+		events:            opts.events,
+		transactor:        opts.transactor,
 	}
 }
 
-func (s *SessionService) getCandidateDtoFromEntity(entity *CandidateEntity, userId int64) (*CandidateDto, error) {
-	track, err := s.musicService.GetTrackById(entity.TrackId)
+func (s *SessionService) getCandidateDtoFromEntity(ctx context.Context, entity *CandidateEntity, userId int64) (*CandidateDto, error) {
+	track, err := s.musicService.GetTrackById(ctx, entity.TrackId)
 	if err != nil {
 		return nil, err
 	}
 
-	vote, err := s.sessionRepository.GetVote(entity.SessionId, userId, entity.Id)
+	vote, err := s.sessionRepository.GetVote(ctx, entity.SessionId, userId, entity.Id)
 	if err != nil {
 		return nil, err
 	}
@@ -250,15 +312,36 @@ func (s *SessionService) getCandidateDtoFromEntity(entity *CandidateEntity, user
 	return dto, nil
 }
 
-func (s *SessionService) CreateSession(session *SessionEntity) (*SessionEntity, error) {
-	session, err := s.sessionRepository.CreateSession(session)
+// CreateSession makes a new session in the session's crew, which its creator must be able to
+// manage, and adds the creator as its first player.
+func (s *SessionService) CreateSession(ctx context.Context, session *SessionEntity) (*SessionEntity, error) {
+	creator, err := s.userService.GetUserById(ctx, session.CreatedBy)
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = s.sessionRepository.AddPlayer(session.Id, &PlayerEntity{
-		SessionId: session.Id,
-		PlayerId:  session.CreatedBy,
+	if session.CrewId == 0 {
+		return nil, ErrCrewNotFound
+	} else if err := s.crewService.CheckCrewAdmin(ctx, session.CrewId, creator); err != nil {
+		return nil, err
+	}
+
+	// Sync subscribers, like webhook deliveries, write in the same transaction as the change.
+	err = s.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		session, err = s.sessionRepository.CreateSession(ctx, session)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.sessionRepository.AddPlayer(ctx, session.Id, &PlayerEntity{
+			SessionId: session.Id,
+			PlayerId:  session.CreatedBy,
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.events.Publish(ctx, SessionCreatedEvent{Session: *session})
 	})
 	if err != nil {
 		return nil, err
@@ -267,13 +350,15 @@ func (s *SessionService) CreateSession(session *SessionEntity) (*SessionEntity,
 	return session, nil
 }
 
-func (s *SessionService) GetSessionsListForUser(userId int64) (*[]SessionDto, error) {
-	sessionEntities, err := s.sessionRepository.GetAllSessions()
+// GetSessionsListForUser returns the sessions of the crews the user is a member of.
+func (s *SessionService) GetSessionsListForUser(ctx context.Context, userId int64) (*[]SessionDto, error) {
+	sessionEntities, err := s.sessionRepository.GetCrewMemberSessions(ctx, userId)
 	if err != nil {
 		return nil, err
 	}
 
 	sessions := make([]SessionDto, len(*sessionEntities))
+	crews := make(map[int64]*CrewEntity)
 
 	for i, sessionEntity := range *sessionEntities {
 		session := SessionDto{
@@ -281,7 +366,15 @@ func (s *SessionService) GetSessionsListForUser(userId int64) (*[]SessionDto, er
 			CurrentPlayer: &PlayerDto{},
 		}
 
-		player, err := s.sessionRepository.GetPlayer(sessionEntity.Id, userId)
+		if _, ok := crews[sessionEntity.CrewId]; !ok {
+			crews[sessionEntity.CrewId], err = s.crewService.GetCrewById(ctx, sessionEntity.CrewId)
+			if err != nil {
+				return nil, err
+			}
+		}
+		session.Crew = crews[sessionEntity.CrewId]
+
+		player, err := s.sessionRepository.GetPlayer(ctx, sessionEntity.Id, userId)
 		if err != nil {
 			return nil, err
 		} else if player != nil {
@@ -294,47 +387,123 @@ func (s *SessionService) GetSessionsListForUser(userId int64) (*[]SessionDto, er
 	return &sessions, nil
 }
 
-func (s *SessionService) GetSessionData(id int64) (*SessionEntity, error) {
-	session, err := s.sessionRepository.GetSessionById(id)
+func (s *SessionService) GetSessionData(ctx context.Context, id int64) (*SessionEntity, error) {
+	session, err := s.sessionRepository.GetSessionById(ctx, id)
 	if err != nil {
 		return nil, err
+	} else if session == nil {
+		return nil, ErrSessionNotFound
 	}
 
 	return session, nil
 }
 
-func (s *SessionService) GetSessionView(sessionId, userId int64) (*SessionDto, error) {
-	session, err := s.sessionRepository.GetSessionById(sessionId)
+// CheckSessionAccess returns ErrSessionNotFound unless the user is a member of the session's crew
+// or a global admin, so sessions of other crews look like they don't exist.
+func (s *SessionService) CheckSessionAccess(ctx context.Context, sessionId int64, user *UserEntity) error {
+	session, err := s.GetSessionData(ctx, sessionId)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	submittedCandidates := []CandidateDto{}
-	ballotCandidates := []CandidateDto{}
-	results := []CandidateDto{}
-	players := []PlayerDto{}
-	currentPlayer := PlayerDto{}
+	return s.checkSessionAccess(ctx, session, user)
+}
 
-	playerEntities, err := s.sessionRepository.GetPlayers(sessionId)
+func (s *SessionService) checkSessionAccess(ctx context.Context, session *SessionEntity, user *UserEntity) error {
+	if user.IsAdmin {
+		return nil
+	}
+
+	isMember, err := s.crewService.IsMember(ctx, session.CrewId, user.Id)
+	if err != nil {
+		return err
+	} else if !isMember {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// GetPlayedSessions returns the sessions the user has joined.
+func (s *SessionService) GetPlayedSessions(ctx context.Context, userId int64) (*[]SessionDto, error) {
+	sessions, err := s.GetSessionsListForUser(ctx, userId)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, playerEntity := range *playerEntities {
-		player := PlayerDto{
-			PlayerEntity: playerEntity,
+	played := make([]SessionDto, 0)
+	for _, session := range *sessions {
+		if session.CurrentPlayer.IsJoinedSession() {
+			played = append(played, session)
 		}
+	}
 
-		user, err := s.userService.GetUserById(playerEntity.PlayerId)
-		if err != nil {
-			return nil, err
-		}
-		player.DisplayName = user.DisplayName
+	return &played, nil
+}
 
-		players = append(players, player)
+func (s *SessionService) GetAllSessions(ctx context.Context) (*[]SessionEntity, error) {
+	return s.sessionRepository.GetAllSessions(ctx)
+}
+
+// DeleteSession removes a session along with its players, submissions, votes and results.
+func (s *SessionService) DeleteSession(ctx context.Context, sessionId int64) error {
+	session, err := s.sessionRepository.GetSessionById(ctx, sessionId)
+	if err != nil {
+		return err
+	} else if session == nil {
+		return ErrSessionNotFound
+	}
+
+	return s.sessionRepository.DeleteSession(ctx, sessionId)
+}
+
+// FastForwardSession moves a session's timeline so that the given phase starts now.
+func (s *SessionService) FastForwardSession(ctx context.Context, sessionId int64, phase SessionPhase) (*SessionEntity, error) {
+	if phase != VotePhase && phase != ResultPhase {
+		return nil, ErrInvalidFastForwardPhase
+	}
+
+	session, err := s.sessionRepository.GetSessionById(ctx, sessionId)
+	if err != nil {
+		return nil, err
+	} else if session == nil {
+		return nil, ErrSessionNotFound
+	}
+
+	currentPhase := session.Phase()
+	if currentPhase == phase || currentPhase == ResultPhase {
+		return nil, ErrSessionPhaseAlreadyReached
+	}
+
+	session.StartAt = Now().Add(-session.PhaseStartAt(phase).Sub(session.StartAt))
+
+	err = s.sessionRepository.UpdateSessionStartAt(ctx, session.Id, session.StartAt)
+	if err != nil {
+		return nil, err
 	}
 
-	currentPlayerEntity, err := s.sessionRepository.GetPlayer(sessionId, userId)
+	return session, nil
+}
+
+func (s *SessionService) GetSessionView(ctx context.Context, sessionId, userId int64) (*SessionDto, error) {
+	session, err := s.sessionRepository.GetSessionById(ctx, sessionId)
+	if err != nil {
+		return nil, err
+	} else if session == nil {
+		return nil, ErrSessionNotFound
+	}
+
+	submittedCandidates := []CandidateDto{}
+	ballotCandidates := []CandidateDto{}
+	results := []CandidateDto{}
+	currentPlayer := PlayerDto{}
+
+	players, err := s.GetSessionPlayers(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	currentPlayerEntity, err := s.sessionRepository.GetPlayer(ctx, sessionId, userId)
 	if err != nil {
 		return nil, err
 	}
@@ -344,13 +513,13 @@ func (s *SessionService) GetSessionView(sessionId, userId int64) (*SessionDto, e
 	}
 
 	if session.Phase() != ResultPhase {
-		candidatesSubmittedByUser, err := s.sessionRepository.GetCandidatesByUserId(sessionId, userId)
+		candidatesSubmittedByUser, err := s.sessionRepository.GetCandidatesByUserId(ctx, sessionId, userId)
 		if err != nil {
 			return nil, err
 		}
 
 		for _, candidate := range *candidatesSubmittedByUser {
-			candidateDto, err := s.getCandidateDtoFromEntity(&candidate, userId)
+			candidateDto, err := s.getCandidateDtoFromEntity(ctx, &candidate, userId)
 			if err != nil {
 				return nil, err
 			}
@@ -362,7 +531,7 @@ func (s *SessionService) GetSessionView(sessionId, userId int64) (*SessionDto, e
 	if session.Phase() != SubmissionPhase {
 		if currentPlayer.IsJoinedSession() {
 			if currentPlayer.PlaylistId != "" {
-				playlistDetails, err := s.musicService.GetPlaylistById(currentPlayer.PlaylistId)
+				playlistDetails, err := s.musicService.GetPlaylistById(ctx, currentPlayer.PlaylistId)
 				if err != nil {
 					return nil, err
 				}
@@ -372,13 +541,13 @@ func (s *SessionService) GetSessionView(sessionId, userId int64) (*SessionDto, e
 	}
 
 	if session.Phase() == VotePhase {
-		candidatesNotSubmittedByUser, err := s.sessionRepository.GetCandidateByNotUserId(sessionId, userId)
+		candidatesNotSubmittedByUser, err := s.sessionRepository.GetCandidateByNotUserId(ctx, sessionId, userId)
 		if err != nil {
 			return nil, err
 		}
 
 		for _, candidate := range *candidatesNotSubmittedByUser {
-			candidateDto, err := s.getCandidateDtoFromEntity(&candidate, userId)
+			candidateDto, err := s.getCandidateDtoFromEntity(ctx, &candidate, userId)
 			if err != nil {
 				return nil, err
 			}
@@ -387,71 +556,150 @@ func (s *SessionService) GetSessionView(sessionId, userId int64) (*SessionDto, e
 		}
 	}
 
+	// Results are frozen by the notifier once the phase begins, viewing a session never writes.
+	resultsPending := false
 	if session.Phase() == ResultPhase {
-		candidates, err := s.sessionRepository.GetAllCandidates(sessionId)
+		if session.ResultsFrozenAt == nil {
+			resultsPending = true
+		} else {
+			snapshot, err := s.sessionRepository.GetResults(ctx, sessionId)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, result := range *snapshot {
+				results = append(results, result.CandidateDto())
+			}
+		}
+	}
+
+	sessionView := &SessionDto{
+		SessionEntity:       *session,
+		SubmittedCandidates: &submittedCandidates,
+		BallotCandidates:    &ballotCandidates,
+		Results:             &results,
+		ResultsPending:      resultsPending,
+		CurrentPlayer:       &currentPlayer,
+		Players:             players,
+	}
+
+	return sessionView, nil
+}
+
+// GetSessionPlayers returns a session's players along with how many votes each has cast.
+func (s *SessionService) GetSessionPlayers(ctx context.Context, session *SessionEntity) (*[]PlayerDto, error) {
+	playerEntities, err := s.sessionRepository.GetPlayers(ctx, session.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	players := []PlayerDto{}
+	for _, playerEntity := range *playerEntities {
+		player := PlayerDto{
+			PlayerEntity: playerEntity,
+		}
+
+		user, err := s.userService.GetUserById(ctx, playerEntity.PlayerId)
 		if err != nil {
 			return nil, err
 		}
+		player.DisplayName = user.DisplayName
 
-		for _, candidate := range *candidates {
-			candidateDto, err := s.getCandidateDtoFromEntity(&candidate, userId)
+		if session.Phase() != SubmissionPhase {
+			votes, err := s.sessionRepository.GetVotesByUserId(ctx, session.Id, playerEntity.PlayerId)
 			if err != nil {
 				return nil, err
 			}
-
-			results = append(results, *candidateDto)
+			player.VoteCount = len(*votes)
 		}
 
-		sort.Sort(ByVoteCountDesc(results))
-
-		userCache := make(map[int64]*UserEntity)
+		players = append(players, player)
+	}
 
-		place := 1
-		currentBest := results[0].Votes
-		for i, result := range results {
-			if result.Votes < currentBest {
-				place += 1
-			}
+	return &players, nil
+}
 
-			if result.Votes == 0 {
-				place = -1
-			}
+// getAccessibleSession returns a session if the user is allowed to see it, see CheckSessionAccess.
+// Every action on a session goes through it, so the sessions of other crews stay hidden no
+// matter which handler the request came through.
+func (s *SessionService) getAccessibleSession(ctx context.Context, sessionId, userId int64) (*SessionEntity, error) {
+	session, err := s.GetSessionData(ctx, sessionId)
+	if err != nil {
+		return nil, err
+	}
 
-			currentBest = result.Votes
-			result.Place = place
+	user, err := s.userService.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
 
-			if user, ok := userCache[result.NominatorId]; ok {
-				result.Nominator = user
-			} else {
-				user, err := s.userService.GetUserById(result.NominatorId)
-				if err != nil {
-					return nil, err
-				}
-				userCache[result.NominatorId] = user
-				result.Nominator = user
-			}
+	if err := s.checkSessionAccess(ctx, session, user); err != nil {
+		return nil, err
+	}
 
-			results[i] = result
-		}
+	return session, nil
+}
 
+// getSessionPlayer returns a session along with the user's place in it, for actions that only
+// players can take.
+func (s *SessionService) getSessionPlayer(ctx context.Context, sessionId, userId int64) (*SessionEntity, *PlayerEntity, error) {
+	session, err := s.getAccessibleSession(ctx, sessionId, userId)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	sessionView := &SessionDto{
-		SessionEntity:       *session,
-		SubmittedCandidates: &submittedCandidates,
-		BallotCandidates:    &ballotCandidates,
-		Results:             &results,
-		CurrentPlayer:       &currentPlayer,
-		Players:             &players,
+	player, err := s.sessionRepository.GetPlayer(ctx, sessionId, userId)
+	if err != nil {
+		return nil, nil, err
+	} else if player == nil {
+		return nil, nil, ErrPlayerNotFound
 	}
 
-	return sessionView, nil
+	return session, player, nil
 }
 
-func (s *SessionService) JoinSession(sessionId, userId int64) (*PlayerDto, error) {
-	player, err := s.sessionRepository.AddPlayer(sessionId, &PlayerEntity{
-		SessionId: sessionId,
-		PlayerId:  userId,
+func (s *SessionService) JoinSession(ctx context.Context, sessionId, userId int64) (*PlayerDto, error) {
+	session, err := s.sessionRepository.GetSessionById(ctx, sessionId)
+	if err != nil {
+		return nil, err
+	} else if session == nil {
+		return nil, ErrSessionNotFound
+	}
+
+	isMember, err := s.crewService.IsMember(ctx, session.CrewId, userId)
+	if err != nil {
+		return nil, err
+	} else if !isMember {
+		return nil, ErrNotCrewMember
+	}
+
+	if session.Phase() != SubmissionPhase {
+		return nil, ErrWrongSessionPhase
+	}
+
+	existingPlayer, err := s.sessionRepository.GetPlayer(ctx, sessionId, userId)
+	if err != nil {
+		return nil, err
+	} else if existingPlayer != nil {
+		return nil, ErrPlayerAlreadyJoined
+	}
+
+	user, err := s.userService.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	var player *PlayerEntity
+	err = s.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		player, err = s.sessionRepository.AddPlayer(ctx, sessionId, &PlayerEntity{
+			SessionId: sessionId,
+			PlayerId:  userId,
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.events.Publish(ctx, PlayerJoinedEvent{Session: *session, User: *user})
 	})
 	if err != nil {
 		return nil, err
@@ -462,8 +710,12 @@ func (s *SessionService) JoinSession(sessionId, userId int64) (*PlayerDto, error
 	}, nil
 }
 
-func (s *SessionService) FinalizePlayerSubmissions(sessionId, userId int64) error {
-	session, err := s.GetSessionView(sessionId, userId)
+func (s *SessionService) FinalizePlayerSubmissions(ctx context.Context, sessionId, userId int64) error {
+	if _, err := s.getAccessibleSession(ctx, sessionId, userId); err != nil {
+		return err
+	}
+
+	session, err := s.GetSessionView(ctx, sessionId, userId)
 	if err != nil {
 		return err
 	}
@@ -472,20 +724,39 @@ func (s *SessionService) FinalizePlayerSubmissions(sessionId, userId int64) erro
 		return ErrUserCannotFinalizeSubmissions
 	}
 
+	if session.Phase() != SubmissionPhase {
+		return ErrWrongSessionPhase
+	}
+
+	if session.CurrentPlayer.IsSubmissionsFinalized {
+		return ErrSubmissionsFinalized
+	}
+
 	if session.SubmissionsRemaining() > 0 {
 		return ErrSubmissionsRemaining
 	}
 
-	err = s.sessionRepository.FinalizePlayerSubmissions(sessionId, userId)
+	user, err := s.userService.GetUserById(ctx, userId)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return s.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		err := s.sessionRepository.FinalizePlayerSubmissions(ctx, sessionId, userId)
+		if err != nil {
+			return err
+		}
+
+		return s.events.Publish(ctx, SubmissionsFinalizedEvent{Session: session.SessionEntity, User: *user})
+	})
 }
 
-func (s *SessionService) SearchCandidateSubmissions(sessionId int64, query string) (*[]CandidateDto, error) {
-	tracks, err := s.musicService.SearchTracks(query)
+func (s *SessionService) SearchCandidateSubmissions(ctx context.Context, sessionId, userId int64, query string) (*[]CandidateDto, error) {
+	if _, err := s.getAccessibleSession(ctx, sessionId, userId); err != nil {
+		return nil, err
+	}
+
+	tracks, err := s.musicService.SearchTracks(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -501,23 +772,21 @@ func (s *SessionService) SearchCandidateSubmissions(sessionId int64, query strin
 	return &candidates, nil
 }
 
-func (s *SessionService) SubmitCandidate(sessionId, userId int64, trackId string) (*CandidateDto, error) {
-	// TODO: improve validation logic
-	player, err := s.sessionRepository.GetPlayer(sessionId, userId)
+func (s *SessionService) SubmitCandidate(ctx context.Context, sessionId, userId int64, trackId string) (*CandidateDto, error) {
+	session, player, err := s.getSessionPlayer(ctx, sessionId, userId)
 	if err != nil {
 		return nil, err
 	}
 
-	if player.IsSubmissionsFinalized {
-		return nil, ErrSubmissionsFinalized
+	if session.Phase() != SubmissionPhase {
+		return nil, ErrWrongSessionPhase
 	}
 
-	session, err := s.sessionRepository.GetSessionById(sessionId)
-	if err != nil {
-		return nil, err
+	if player.IsSubmissionsFinalized {
+		return nil, ErrSubmissionsFinalized
 	}
 
-	candidates, err := s.sessionRepository.GetCandidatesByUserId(sessionId, userId)
+	candidates, err := s.sessionRepository.GetCandidatesByUserId(ctx, sessionId, userId)
 	if err != nil {
 		return nil, err
 	}
@@ -532,16 +801,24 @@ func (s *SessionService) SubmitCandidate(sessionId, userId int64, trackId string
 		}
 	}
 
-	candidate, err := s.sessionRepository.AddCandidate(sessionId, &CandidateEntity{
-		SessionId:   sessionId,
-		NominatorId: userId,
-		TrackId:     trackId,
+	var candidate *CandidateEntity
+	err = s.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		candidate, err = s.sessionRepository.AddCandidate(ctx, sessionId, &CandidateEntity{
+			SessionId:   sessionId,
+			NominatorId: userId,
+			TrackId:     trackId,
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.events.Publish(ctx, CandidateSubmittedEvent{Candidate: *candidate})
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	candidateDto, err := s.getCandidateDtoFromEntity(candidate, userId)
+	candidateDto, err := s.getCandidateDtoFromEntity(ctx, candidate, userId)
 	if err != nil {
 		return nil, err
 	}
@@ -549,43 +826,50 @@ func (s *SessionService) SubmitCandidate(sessionId, userId int64, trackId string
 	return candidateDto, nil
 }
 
-func (s *SessionService) RemoveCandidate(sessionId, userId, candidateId int64) error {
-	player, err := s.sessionRepository.GetPlayer(sessionId, userId)
+func (s *SessionService) RemoveCandidate(ctx context.Context, sessionId, userId, candidateId int64) error {
+	session, player, err := s.getSessionPlayer(ctx, sessionId, userId)
 	if err != nil {
 		return err
 	}
 
+	if session.Phase() != SubmissionPhase {
+		return ErrWrongSessionPhase
+	}
+
 	if player.IsSubmissionsFinalized {
 		return ErrSubmissionsFinalized
 	}
 
-	err = s.sessionRepository.DeleteCandidate(sessionId, candidateId)
+	// Players can only take back their own submissions.
+	candidate, err := s.sessionRepository.GetCandidateById(ctx, sessionId, candidateId)
 	if err != nil {
 		return err
+	} else if candidate == nil || candidate.NominatorId != userId {
+		return ErrCandidateNotFound
 	}
 
-	return nil
-}
-
-func (s *SessionService) CreatePlayerPlaylist(sessionId, playerId int64) (*PlayerDto, error) {
-	player := &PlayerDto{}
+	return s.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		err := s.sessionRepository.DeleteCandidate(ctx, sessionId, candidateId)
+		if err != nil {
+			return err
+		}
 
-	session, err := s.sessionRepository.GetSessionById(sessionId)
-	if err != nil {
-		return nil, err
-	}
+		return s.events.Publish(ctx, CandidateRemovedEvent{SessionId: sessionId, CandidateId: candidateId, NominatorId: userId})
+	})
+}
 
-	playerEntity, err := s.sessionRepository.GetPlayer(sessionId, playerId)
+func (s *SessionService) CreatePlayerPlaylist(ctx context.Context, sessionId, playerId int64) (*PlayerDto, error) {
+	session, playerEntity, err := s.getSessionPlayer(ctx, sessionId, playerId)
 	if err != nil {
 		return nil, err
 	}
-	player.PlayerEntity = *playerEntity
+	player := &PlayerDto{PlayerEntity: *playerEntity}
 
 	if playerEntity.PlaylistId != "" {
 		return nil, ErrPlaylistAlreadyExists
 	}
 
-	candidates, err := s.sessionRepository.GetAllCandidates(sessionId)
+	candidates, err := s.sessionRepository.GetAllCandidates(ctx, sessionId)
 	if err != nil {
 		return nil, err
 	}
@@ -596,12 +880,12 @@ func (s *SessionService) CreatePlayerPlaylist(sessionId, playerId int64) (*Playe
 	}
 
 	playlistName := fmt.Sprintf("Mixtape: %s %s", session.Name, session.CreatedAt.Format("02-01-06"))
-	playlistDetails, err := s.musicService.musicRepository.CreatePlaylist(playlistName, trackIds)
+	playlistDetails, err := s.musicService.musicRepository.CreatePlaylist(ctx, playlistName, trackIds)
 	if err != nil {
 		return nil, err
 	}
 
-	err = s.sessionRepository.UpdatePlayerPlaylist(sessionId, player.PlayerId, playlistDetails.Id)
+	err = s.sessionRepository.UpdatePlayerPlaylist(ctx, sessionId, player.PlayerId, playlistDetails.Id)
 	if err != nil {
 		return nil, err
 	}
@@ -611,57 +895,94 @@ func (s *SessionService) CreatePlayerPlaylist(sessionId, playerId int64) (*Playe
 	return player, nil
 }
 
-func (s *SessionService) VoteForCandidate(sessionId, userId, candidateId int64) (*CandidateDto, error) {
-	// TODO: improve validation logic
+func (s *SessionService) VoteForCandidate(ctx context.Context, sessionId, userId, candidateId int64) (*CandidateDto, error) {
+	session, _, err := s.getSessionPlayer(ctx, sessionId, userId)
+	if err != nil {
+		return nil, err
+	}
 
-	session, err := s.sessionRepository.GetSessionById(sessionId)
+	if session.Phase() != VotePhase {
+		return nil, ErrWrongSessionPhase
+	}
+
+	candidate, err := s.sessionRepository.GetCandidateById(ctx, sessionId, candidateId)
 	if err != nil {
 		return nil, err
+	} else if candidate == nil {
+		return nil, ErrCandidateNotFound
+	} else if candidate.NominatorId == userId {
+		return nil, ErrCannotVoteForOwnCandidate
 	}
 
-	votes, err := s.sessionRepository.GetVotesByUserId(sessionId, userId)
+	votes, err := s.sessionRepository.GetVotesByUserId(ctx, sessionId, userId)
 	if err != nil {
 		return nil, err
 	}
 
+	for _, vote := range *votes {
+		if vote.CandidateId == candidateId {
+			return nil, ErrDuplicateVote
+		}
+	}
+
 	if len(*votes) >= session.MaxVotes() {
 		return nil, ErrNoVotesLeft
 	}
 
-	_, err = s.sessionRepository.AddVote(sessionId, &VoteEntity{
-		SessionId:   sessionId,
-		VoterId:     userId,
-		CandidateId: candidateId,
+	err = s.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		vote, err := s.sessionRepository.AddVote(ctx, sessionId, &VoteEntity{
+			SessionId:   sessionId,
+			VoterId:     userId,
+			CandidateId: candidateId,
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.events.Publish(ctx, VoteCastEvent{Vote: *vote})
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	candidate, err := s.sessionRepository.GetCandidateById(sessionId, candidateId)
+	candidateDto, err := s.getCandidateDtoFromEntity(ctx, candidate, userId)
 	if err != nil {
 		return nil, err
 	}
 
-	candidateDto, err := s.getCandidateDtoFromEntity(candidate, userId)
+	return candidateDto, nil
+}
+
+func (s *SessionService) RemoveVoteForCandidate(ctx context.Context, sessionId, userId, candidateId int64) (*CandidateDto, error) {
+	session, _, err := s.getSessionPlayer(ctx, sessionId, userId)
 	if err != nil {
 		return nil, err
 	}
 
-	return candidateDto, nil
-}
+	if session.Phase() != VotePhase {
+		return nil, ErrWrongSessionPhase
+	}
 
-func (s *SessionService) RemoveVoteForCandidate(sessionId, userId, candidateId int64) (*CandidateDto, error) {
-	err := s.sessionRepository.DeleteVote(sessionId, userId, candidateId)
+	candidate, err := s.sessionRepository.GetCandidateById(ctx, sessionId, candidateId)
 	if err != nil {
 		return nil, err
+	} else if candidate == nil {
+		return nil, ErrCandidateNotFound
 	}
 
-	candidate, err := s.sessionRepository.GetCandidateById(sessionId, candidateId)
+	err = s.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		err := s.sessionRepository.DeleteVote(ctx, sessionId, userId, candidateId)
+		if err != nil {
+			return err
+		}
+
+		return s.events.Publish(ctx, VoteRemovedEvent{Vote: VoteEntity{SessionId: sessionId, VoterId: userId, CandidateId: candidateId}})
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	candidateDto, err := s.getCandidateDtoFromEntity(candidate, userId)
+	candidateDto, err := s.getCandidateDtoFromEntity(ctx, candidate, userId)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,157 @@
+package core_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/storage"
+)
+
+// newAccessFixture sets up a session of a crew along with users that have different ties to it.
+func newAccessFixture(t *testing.T) (*storage.SqliteStore, *core.SessionService, *core.SessionEntity, map[string]*core.UserEntity) {
+	t.Helper()
+	ctx := context.Background()
+
+	store, err := storage.NewSqliteDb(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	if err := store.Setup(); err != nil {
+		t.Fatal(err)
+	}
+
+	users := make(map[string]*core.UserEntity)
+	for _, username := range []string{"member", "crewadmin", "outsider", "former", "admin"} {
+		user, err := store.CreateUser(ctx, &core.UserEntity{Username: username, IsAdmin: username == "admin"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		users[username] = user
+	}
+
+	crew, err := store.CreateCrew(ctx, &core.CrewEntity{Name: "Crew", CreatedBy: users["crewadmin"].Id, CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherCrew, err := store.CreateCrew(ctx, &core.CrewEntity{Name: "Other", CreatedBy: users["outsider"].Id, CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	members := []core.CrewMemberEntity{
+		{CrewId: crew.Id, UserId: users["member"].Id, Role: core.CrewRoleMember},
+		{CrewId: crew.Id, UserId: users["crewadmin"].Id, Role: core.CrewRoleAdmin},
+		{CrewId: crew.Id, UserId: users["former"].Id, Role: core.CrewRoleMember},
+		{CrewId: otherCrew.Id, UserId: users["outsider"].Id, Role: core.CrewRoleAdmin},
+	}
+	for _, member := range members {
+		member.JoinedAt = crew.CreatedAt
+		if err := store.AddCrewMember(ctx, &member); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.DeleteCrewMember(ctx, crew.Id, users["former"].Id); err != nil {
+		t.Fatal(err)
+	}
+
+	session, err := store.CreateSession(ctx, core.NewSessionEntity("Session", users["crewadmin"].Id, core.WithSessionCrew(crew.Id)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userService := core.NewUserService(store)
+	sessionService := core.NewSessionService(store, userService, core.NewCrewService(store, store, userService), nil)
+
+	return store, sessionService, session, users
+}
+
+func TestCheckSessionAccess(t *testing.T) {
+	ctx := context.Background()
+	_, sessionService, session, users := newAccessFixture(t)
+
+	tests := []struct {
+		name      string
+		user      string
+		sessionId int64
+		want      error
+	}{
+		{"crew member", "member", session.Id, nil},
+		{"crew admin", "crewadmin", session.Id, nil},
+		{"member of another crew", "outsider", session.Id, core.ErrSessionNotFound},
+		{"removed from the crew", "former", session.Id, core.ErrSessionNotFound},
+		{"global admin outside the crew", "admin", session.Id, nil},
+		{"missing session", "member", session.Id + 1, core.ErrSessionNotFound},
+		{"missing session for a global admin", "admin", session.Id + 1, core.ErrSessionNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := sessionService.CheckSessionAccess(ctx, tt.sessionId, users[tt.user]); err != tt.want {
+				t.Errorf("CheckSessionAccess() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionActionsCheckAccess(t *testing.T) {
+	ctx := context.Background()
+	store, sessionService, session, users := newAccessFixture(t)
+
+	// A player who has since left the crew keeps their player row but loses access.
+	if _, err := store.AddPlayer(ctx, session.Id, &core.PlayerEntity{SessionId: session.Id, PlayerId: users["former"].Id}); err != nil {
+		t.Fatal(err)
+	}
+
+	actions := []struct {
+		name string
+		act  func(userId int64) error
+	}{
+		{"search tracks", func(userId int64) error {
+			_, err := sessionService.SearchCandidateSubmissions(ctx, session.Id, userId, "query")
+			return err
+		}},
+		{"submit candidate", func(userId int64) error {
+			_, err := sessionService.SubmitCandidate(ctx, session.Id, userId, "track")
+			return err
+		}},
+		{"remove candidate", func(userId int64) error {
+			return sessionService.RemoveCandidate(ctx, session.Id, userId, 1)
+		}},
+		{"finalize submissions", func(userId int64) error {
+			return sessionService.FinalizePlayerSubmissions(ctx, session.Id, userId)
+		}},
+		{"create playlist", func(userId int64) error {
+			_, err := sessionService.CreatePlayerPlaylist(ctx, session.Id, userId)
+			return err
+		}},
+		{"vote", func(userId int64) error {
+			_, err := sessionService.VoteForCandidate(ctx, session.Id, userId, 1)
+			return err
+		}},
+		{"remove vote", func(userId int64) error {
+			_, err := sessionService.RemoveVoteForCandidate(ctx, session.Id, userId, 1)
+			return err
+		}},
+	}
+
+	for _, action := range actions {
+		for _, user := range []string{"outsider", "former"} {
+			t.Run(action.name+" as "+user, func(t *testing.T) {
+				if err := action.act(users[user].Id); err != core.ErrSessionNotFound {
+					t.Errorf("error = %v, want %v", err, core.ErrSessionNotFound)
+				}
+			})
+		}
+	}
+
+	t.Run("create playlist without joining", func(t *testing.T) {
+		if _, err := sessionService.CreatePlayerPlaylist(ctx, session.Id, users["member"].Id); err != core.ErrPlayerNotFound {
+			t.Errorf("error = %v, want %v", err, core.ErrPlayerNotFound)
+		}
+	})
+}
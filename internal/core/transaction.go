@@ -0,0 +1,57 @@
+package core
+
+import (
+	"context"
+	"sync"
+)
+
+// Transactor groups repository calls into a single transaction. Calls made with the context
+// passed to fn are committed together when fn returns nil and rolled back otherwise.
+type Transactor interface {
+	InTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// noTransactor runs fn without a transaction, for services that weren't given a Transactor.
+type noTransactor struct{}
+
+func (noTransactor) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type commitHooksCtxKey struct{}
+
+type commitHooks struct {
+	mu  sync.Mutex
+	fns []func()
+}
+
+// WithCommitHooks returns a context that collects the functions passed to AfterCommit, along with
+// a function that runs them. Transactor implementations call it when a transaction begins and run
+// the hooks once it commits; hooks registered in a transaction that rolls back are dropped.
+func WithCommitHooks(ctx context.Context) (context.Context, func()) {
+	hooks := &commitHooks{}
+	return context.WithValue(ctx, commitHooksCtxKey{}, hooks), func() {
+		hooks.mu.Lock()
+		fns := hooks.fns
+		hooks.fns = nil
+		hooks.mu.Unlock()
+
+		for _, fn := range fns {
+			fn()
+		}
+	}
+}
+
+// AfterCommit runs fn once the transaction ctx belongs to commits, or right away when ctx isn't
+// part of a transaction.
+func AfterCommit(ctx context.Context, fn func()) {
+	hooks, ok := ctx.Value(commitHooksCtxKey{}).(*commitHooks)
+	if !ok {
+		fn()
+		return
+	}
+
+	hooks.mu.Lock()
+	defer hooks.mu.Unlock()
+	hooks.fns = append(hooks.fns, fn)
+}
@@ -1,12 +1,16 @@
 package core
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"errors"
+	"net/mail"
 	"regexp"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
-	"github.com/CaribouBlue/mixtape/internal/config"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -15,9 +19,14 @@ var (
 	ErrPasswordsDoNotMatch   = errors.New("passwords do not match")
 	ErrIncorrectPassword     = errors.New("incorrect password")
 	ErrUserNotFound          = errors.New("user not found")
-	ErrIncorrectAccessCode   = errors.New("incorrect access code")
+	ErrInvalidEmail          = errors.New("invalid email address")
+	ErrEmailAlreadyInUse     = errors.New("email already in use")
+	ErrUserHasSessions       = errors.New("user has created sessions")
+	ErrInvalidDisplayName    = errors.New("invalid display name")
 )
 
+const MaxDisplayNameLength = 50
+
 type UserEntity struct {
 	Id             int64
 	Username       string
@@ -26,6 +35,10 @@ type UserEntity struct {
 	SpotifyEmail   string
 	HashedPassword []byte
 	IsAdmin        bool
+	EmailOptOut    bool
+	// Email is the address the user entered, which is only used once IsEmailVerified is set.
+	Email           string
+	IsEmailVerified bool
 }
 
 func (u *UserEntity) IdString() string {
@@ -36,21 +49,56 @@ func (u *UserEntity) IsAuthenticatedWithSpotify() bool {
 	return u.SpotifyToken != ""
 }
 
+// ContactEmail returns the address notifications should be sent to, or an empty string if
+// the user can't be reached by email. A verified email is preferred over the Spotify account's.
+func (u *UserEntity) ContactEmail() string {
+	if u.Email != "" && u.IsEmailVerified {
+		return u.Email
+	}
+	return u.SpotifyEmail
+}
+
+// VerifiedEmail returns the email the user has verified, or an empty string if there isn't one.
+// Mail that gives access to the account, like password resets, only goes to this address.
+func (u *UserEntity) VerifiedEmail() string {
+	if u.IsEmailVerified {
+		return u.Email
+	}
+	return ""
+}
+
+func (u *UserEntity) IsEmailReachable() bool {
+	return u.ContactEmail() != "" && !u.EmailOptOut
+}
+
 type UserRepository interface {
-	CreateUser(user *UserEntity) (*UserEntity, error)
-	GetUserById(userId int64) (*UserEntity, error)
-	GetUserByUsername(username string) (*UserEntity, error)
-	GetAllUsers() (*[]UserEntity, error)
-	UpdateUserSpotifyInfo(userId int64, spotifyToken string, spotifyEmail string) (*UserEntity, error)
+	CreateUser(ctx context.Context, user *UserEntity) (*UserEntity, error)
+	GetUserById(ctx context.Context, userId int64) (*UserEntity, error)
+	GetUserByUsername(ctx context.Context, username string) (*UserEntity, error)
+	GetUserByEmail(ctx context.Context, email string) (*UserEntity, error)
+	GetAllUsers(ctx context.Context) (*[]UserEntity, error)
+	UpdateUserSpotifyInfo(ctx context.Context, userId int64, spotifyToken string, spotifyEmail string) (*UserEntity, error)
+	UpdateUserEmailOptOut(ctx context.Context, userId int64, optOut bool) error
+	UpdateUserEmail(ctx context.Context, userId int64, email string, isVerified bool) error
+	UpdateUserPassword(ctx context.Context, userId int64, hashedPassword []byte) error
+	UpdateUserDisplayName(ctx context.Context, userId int64, displayName string) error
+	UpdateUserIsAdmin(ctx context.Context, userId int64, isAdmin bool) error
+	// DeleteUser removes a user along with everything they did in sessions, or returns
+	// ErrUserHasSessions if they created a session, since that would be deleted too.
+	DeleteUser(ctx context.Context, userId int64) error
 }
 
 type UserService struct {
 	userRepository UserRepository
+	events         *EventBus
 }
 
-func NewUserService(userRepository UserRepository) *UserService {
+func NewUserService(userRepository UserRepository, options ...ServiceOption) *UserService {
+	opts := newServiceOpts(options)
+
 	return &UserService{
 		userRepository: userRepository,
+		events:         opts.events,
 	}
 }
 
@@ -60,11 +108,9 @@ func (s *UserService) NormalizeUsername(username string) string {
 	return normalizedUsername
 }
 
-func (s *UserService) SignUpNewUser(username, password, confirmPassword, accessCode string) (*UserEntity, error) {
-	if accessCode != config.GetConfigValue(config.ConfAccessCode) {
-		return nil, ErrIncorrectAccessCode
-	}
-
+// CreateUser creates a user. People signing up go through an invitation, which calls this once the
+// invitation code is checked.
+func (s *UserService) CreateUser(ctx context.Context, username, password, confirmPassword string) (*UserEntity, error) {
 	if password != confirmPassword {
 		return nil, ErrPasswordsDoNotMatch
 	}
@@ -80,7 +126,7 @@ func (s *UserService) SignUpNewUser(username, password, confirmPassword, accessC
 		HashedPassword: hashedPassword,
 	}
 
-	existingUser, err := s.userRepository.GetUserByUsername(user.Username)
+	existingUser, err := s.userRepository.GetUserByUsername(ctx, user.Username)
 	if err != nil {
 		return nil, err
 	}
@@ -88,12 +134,21 @@ func (s *UserService) SignUpNewUser(username, password, confirmPassword, accessC
 		return nil, ErrUsernameAlreadyExists
 	}
 
-	return s.userRepository.CreateUser(user)
+	user, err = s.userRepository.CreateUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.events.Publish(ctx, UserCreatedEvent{User: *user}); err != nil {
+		return nil, err
+	}
+
+	return user, nil
 }
 
-func (s *UserService) LoginUser(username string, password string) (*UserEntity, error) {
+func (s *UserService) LoginUser(ctx context.Context, username string, password string) (*UserEntity, error) {
 	normalizedUsername := s.NormalizeUsername(username)
-	user, err := s.userRepository.GetUserByUsername(normalizedUsername)
+	user, err := s.userRepository.GetUserByUsername(ctx, normalizedUsername)
 	if err != nil {
 		return nil, err
 	} else if user == nil {
@@ -111,8 +166,8 @@ func (s *UserService) LoginUser(username string, password string) (*UserEntity,
 	return user, nil
 }
 
-func (s *UserService) AuthenticateSpotify(userId int64, spotifyToken string, spotifyEmail string) (*UserEntity, error) {
-	user, err := s.userRepository.UpdateUserSpotifyInfo(userId, spotifyToken, spotifyEmail)
+func (s *UserService) AuthenticateSpotify(ctx context.Context, userId int64, spotifyToken string, spotifyEmail string) (*UserEntity, error) {
+	user, err := s.userRepository.UpdateUserSpotifyInfo(ctx, userId, spotifyToken, spotifyEmail)
 	if err != nil {
 		return nil, err
 	}
@@ -120,12 +175,49 @@ func (s *UserService) AuthenticateSpotify(userId int64, spotifyToken string, spo
 	return user, nil
 }
 
+// UnlinkSpotify forgets the user's Spotify account, so they have to link one again to play.
+func (s *UserService) UnlinkSpotify(ctx context.Context, userId int64) (*UserEntity, error) {
+	return s.AuthenticateSpotify(ctx, userId, "", "")
+}
+
 func (s *UserService) IsAuthenticated(user *UserEntity) (bool, error) {
 	return user != nil && user.SpotifyToken != "", nil
 }
 
-func (s *UserService) GetUserById(userId int64) (*UserEntity, error) {
-	user, err := s.userRepository.GetUserById(userId)
+func (s *UserService) GetUserById(ctx context.Context, userId int64) (*UserEntity, error) {
+	user, err := s.userRepository.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (s *UserService) GetUserByUsername(ctx context.Context, username string) (*UserEntity, error) {
+	user, err := s.userRepository.GetUserByUsername(ctx, s.NormalizeUsername(username))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (s *UserService) GetAllUsers(ctx context.Context) (*[]UserEntity, error) {
+	return s.userRepository.GetAllUsers(ctx)
+}
+
+// GetUserByEmail returns the user who has verified the given email address.
+func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*UserEntity, error) {
+	email, err := NormalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepository.GetUserByEmail(ctx, email)
 	if err != nil {
 		return nil, err
 	}
@@ -135,6 +227,111 @@ func (s *UserService) GetUserById(userId int64) (*UserEntity, error) {
 	return user, nil
 }
 
+// SetEmail updates a user's email address. A verified address must not be verified by another user.
+func (s *UserService) SetEmail(ctx context.Context, userId int64, email string, isVerified bool) error {
+	email, err := NormalizeEmail(email)
+	if err != nil {
+		return err
+	}
+
+	if isVerified {
+		existingUser, err := s.userRepository.GetUserByEmail(ctx, email)
+		if err != nil {
+			return err
+		}
+		if existingUser != nil && existingUser.Id != userId {
+			return ErrEmailAlreadyInUse
+		}
+	}
+
+	err = s.userRepository.UpdateUserEmail(ctx, userId, email, isVerified)
+	if err != nil {
+		return err
+	}
+
+	return s.events.Publish(ctx, UserEmailChangedEvent{UserId: userId, Email: email, IsVerified: isVerified})
+}
+
+func (s *UserService) SetPassword(ctx context.Context, userId int64, password, confirmPassword string) error {
+	if password != confirmPassword {
+		return ErrPasswordsDoNotMatch
+	}
+
+	hashedPassword, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	err = s.userRepository.UpdateUserPassword(ctx, userId, hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	return s.events.Publish(ctx, UserPasswordChangedEvent{UserId: userId})
+}
+
+// ChangePassword sets a new password for a user who knows their current one.
+func (s *UserService) ChangePassword(ctx context.Context, userId int64, currentPassword, password, confirmPassword string) error {
+	user, err := s.GetUserById(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	if err := bcrypt.CompareHashAndPassword(user.HashedPassword, []byte(currentPassword)); err != nil {
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return ErrIncorrectPassword
+		}
+
+		return err
+	}
+
+	return s.SetPassword(ctx, userId, password, confirmPassword)
+}
+
+// SetDisplayName changes the name other players see, returning the trimmed name that was saved.
+func (s *UserService) SetDisplayName(ctx context.Context, userId int64, displayName string) (string, error) {
+	displayName = strings.TrimSpace(displayName)
+	if displayName == "" || utf8.RuneCountInString(displayName) > MaxDisplayNameLength {
+		return "", ErrInvalidDisplayName
+	}
+
+	err := s.userRepository.UpdateUserDisplayName(ctx, userId, displayName)
+	if err != nil {
+		return "", err
+	}
+
+	return displayName, nil
+}
+
+func (s *UserService) SetAdmin(ctx context.Context, userId int64, isAdmin bool) error {
+	if _, err := s.GetUserById(ctx, userId); err != nil {
+		return err
+	}
+
+	return s.userRepository.UpdateUserIsAdmin(ctx, userId, isAdmin)
+}
+
+func (s *UserService) DeleteUser(ctx context.Context, userId int64) error {
+	if _, err := s.GetUserById(ctx, userId); err != nil {
+		return err
+	}
+
+	return s.userRepository.DeleteUser(ctx, userId)
+}
+
+func (s *UserService) SetEmailOptOut(ctx context.Context, userId int64, optOut bool) error {
+	return s.userRepository.UpdateUserEmailOptOut(ctx, userId, optOut)
+}
+
+// NormalizeEmail validates a bare email address and lowercases it.
+func NormalizeEmail(email string) (string, error) {
+	address, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil || address.Name != "" {
+		return "", ErrInvalidEmail
+	}
+	return strings.ToLower(address.Address), nil
+}
+
 func HashPassword(password string) ([]byte, error) {
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
@@ -142,3 +339,12 @@ func HashPassword(password string) ([]byte, error) {
 	}
 	return hash, nil
 }
+
+// GeneratePassword returns a random password for when an admin sets one on a user's behalf.
+func GeneratePassword() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -1,6 +1,9 @@
 package featureflag
 
-import "errors"
+import (
+	"errors"
+	"sort"
+)
 
 type FeatureFlagMode string
 
@@ -36,6 +39,17 @@ func NewFeatureFlagService(featureFlagRepository FeatureFlagRepository) *Feature
 	}
 }
 
+// GetAllFeatureFlags returns every flag, sorted by key.
+func (f *FeatureFlagService) GetAllFeatureFlags() ([]*FeatureFlagEntity, error) {
+	flags, err := f.featureFlagRepository.GetAllFeatureFlags()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Key < flags[j].Key })
+	return flags, nil
+}
+
 func (f *FeatureFlagService) IsFeatureEnabled(key string, userId int64) (bool, error) {
 	flag, err := f.featureFlagRepository.GetFeatureFlag(key)
 	if err != nil {
@@ -15,12 +15,17 @@ type JsonFeatureFlagRepository struct {
 }
 
 func NewJsonFeatureFlagRepository() *JsonFeatureFlagRepository {
-	featureFlags := make(map[string]FeatureFlagEntity)
-	json.Unmarshal([]byte(featureFlagsJson), &featureFlags)
+	repo := &JsonFeatureFlagRepository{
+		FeatureFlags: make(featureFlags),
+	}
+	json.Unmarshal([]byte(featureFlagsJson), repo)
 
-	return &JsonFeatureFlagRepository{
-		FeatureFlags: featureFlags,
+	for key, featureFlag := range repo.FeatureFlags {
+		featureFlag.Key = key
+		repo.FeatureFlags[key] = featureFlag
 	}
+
+	return repo
 }
 
 func (r *JsonFeatureFlagRepository) GetAllFeatureFlags() ([]*FeatureFlagEntity, error) {
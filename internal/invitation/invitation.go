@@ -0,0 +1,124 @@
+// Package invitation lets admins, and users within a quota, invite people to sign up with codes
+// that can only be used a limited number of times.
+package invitation
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvitationNotFound    = errors.New("invitation not found")
+	ErrInvalidCode           = errors.New("invalid, expired, revoked or used up invitation code")
+	ErrInvalidMaxUses        = errors.New("invalid number of invitation uses")
+	ErrInvalidExpiry         = errors.New("invitation expiry must be in the future")
+	ErrInvalidDisplayName    = errors.New("invalid invitation display name")
+	ErrInvitationsNotAllowed = errors.New("only admins can create invitations")
+	ErrTooManyInvitations    = errors.New("too many open invitations")
+	ErrInvitationUsedUp      = errors.New("invitation has been used up")
+)
+
+const (
+	// MaxUses caps how many people a single invitation can sign up.
+	MaxUses = 100
+	// codeAlphabet leaves out characters that are easy to mix up when a code is read out.
+	codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	codeLength   = 10
+)
+
+// Invitation is a code people can sign up with. Codes are meant to be shared, so unlike access
+// tokens they're stored in the clear.
+type Invitation struct {
+	Id   int64
+	Code string
+	// CreatedBy is 0 once the user who created the invitation is deleted.
+	CreatedBy int64
+	// DisplayName pre-fills the display name of the people who sign up with the invitation.
+	DisplayName string
+	MaxUses     int
+	Uses        int
+	ExpiresAt   *time.Time
+	RevokedAt   *time.Time
+	CreatedAt   time.Time
+}
+
+func (i *Invitation) IsExpired(now time.Time) bool {
+	return i.ExpiresAt != nil && !now.Before(*i.ExpiresAt)
+}
+
+func (i *Invitation) IsRevoked() bool {
+	return i.RevokedAt != nil
+}
+
+func (i *Invitation) IsUsedUp() bool {
+	return i.Uses >= i.MaxUses
+}
+
+func (i *Invitation) IsActive(now time.Time) bool {
+	return !i.IsRevoked() && !i.IsExpired(now) && !i.IsUsedUp()
+}
+
+// Use records that a user signed up with an invitation.
+type Use struct {
+	InvitationId int64
+	UserId       int64
+	Username     string
+	UsedAt       time.Time
+}
+
+type Repository interface {
+	CreateInvitation(ctx context.Context, invitation *Invitation) (*Invitation, error)
+	// GetInvitation returns nil when no invitation has the given ID.
+	GetInvitation(ctx context.Context, invitationId int64) (*Invitation, error)
+	// GetInvitationByCode returns nil when no invitation has the given code.
+	GetInvitationByCode(ctx context.Context, code string) (*Invitation, error)
+	// GetInvitations returns every invitation, newest first.
+	GetInvitations(ctx context.Context) ([]Invitation, error)
+	// GetUserInvitations returns the invitations the user created, newest first.
+	GetUserInvitations(ctx context.Context, userId int64) ([]Invitation, error)
+	// CountActiveUserInvitations counts the user's invitations that can still be used.
+	CountActiveUserInvitations(ctx context.Context, userId int64, now time.Time) (int, error)
+	RevokeInvitation(ctx context.Context, invitationId int64, now time.Time) error
+	// UseInvitation records a use of the invitation if it has uses left, returning ErrInvalidCode
+	// otherwise.
+	UseInvitation(ctx context.Context, invitationId int64, userId int64, now time.Time) error
+	// GetInvitationUses returns who signed up with the given invitations, oldest first.
+	GetInvitationUses(ctx context.Context, invitationIds []int64) ([]Use, error)
+}
+
+func newCode() (string, error) {
+	b := make([]byte, codeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	code := make([]byte, codeLength)
+	for i := range b {
+		// The alphabet has 32 characters, so this doesn't favor any of them.
+		code[i] = codeAlphabet[int(b[i])%len(codeAlphabet)]
+	}
+
+	return FormatCode(string(code)), nil
+}
+
+// NormalizeCode undoes the formatting people add when copying codes, e.g. dashes and lower case.
+func NormalizeCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(code)))
+}
+
+// FormatCode splits a code in two halves so it's easier to read.
+func FormatCode(code string) string {
+	code = NormalizeCode(code)
+	if len(code) != codeLength {
+		return code
+	}
+	return code[:codeLength/2] + "-" + code[codeLength/2:]
+}
@@ -0,0 +1,212 @@
+package invitation
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+)
+
+type InvitationServiceOpts struct {
+	// UserQuota is how many open invitations a user who isn't an admin can have at once. Zero
+	// leaves inviting to admins.
+	UserQuota int
+	// UserMaxTTL is the longest a user who isn't an admin can keep an invitation open.
+	UserMaxTTL time.Duration
+}
+
+type InvitationServiceOption func(*InvitationServiceOpts)
+
+func WithUserQuota(quota int) InvitationServiceOption {
+	return func(opts *InvitationServiceOpts) {
+		opts.UserQuota = quota
+	}
+}
+
+func WithUserMaxTTL(ttl time.Duration) InvitationServiceOption {
+	return func(opts *InvitationServiceOpts) {
+		opts.UserMaxTTL = ttl
+	}
+}
+
+type InvitationService struct {
+	opts        InvitationServiceOpts
+	repository  Repository
+	transactor  core.Transactor
+	userService *core.UserService
+}
+
+func NewInvitationService(repository Repository, transactor core.Transactor, userService *core.UserService, options ...InvitationServiceOption) *InvitationService {
+	opts := InvitationServiceOpts{
+		UserQuota:  0,
+		UserMaxTTL: 30 * 24 * time.Hour,
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	return &InvitationService{
+		opts:        opts,
+		repository:  repository,
+		transactor:  transactor,
+		userService: userService,
+	}
+}
+
+// CanInvite reports whether the user is allowed to create invitations at all.
+func (s *InvitationService) CanInvite(user *core.UserEntity) bool {
+	return user.IsAdmin || s.opts.UserQuota > 0
+}
+
+// CreateInvitation creates an invitation that can be used maxUses times. A zero ttl creates an
+// invitation that never expires. Users who aren't admins can only create single-use invitations
+// that expire within UserMaxTTL, and only up to their quota.
+func (s *InvitationService) CreateInvitation(ctx context.Context, user *core.UserEntity, displayName string, maxUses int, ttl time.Duration) (*Invitation, error) {
+	if !s.CanInvite(user) {
+		return nil, ErrInvitationsNotAllowed
+	}
+
+	displayName = strings.TrimSpace(displayName)
+	if utf8.RuneCountInString(displayName) > core.MaxDisplayNameLength {
+		return nil, ErrInvalidDisplayName
+	}
+
+	if maxUses < 1 || maxUses > MaxUses || (!user.IsAdmin && maxUses != 1) {
+		return nil, ErrInvalidMaxUses
+	}
+
+	if ttl < 0 || (!user.IsAdmin && (ttl == 0 || ttl > s.opts.UserMaxTTL)) {
+		return nil, ErrInvalidExpiry
+	}
+
+	now := core.Now()
+	if !user.IsAdmin {
+		count, err := s.repository.CountActiveUserInvitations(ctx, user.Id, now)
+		if err != nil {
+			return nil, err
+		}
+		if count >= s.opts.UserQuota {
+			return nil, ErrTooManyInvitations
+		}
+	}
+
+	code, err := newCode()
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := &Invitation{
+		Code:        code,
+		CreatedBy:   user.Id,
+		DisplayName: displayName,
+		MaxUses:     maxUses,
+		CreatedAt:   now,
+	}
+	if ttl > 0 {
+		expiresAt := now.Add(ttl)
+		invitation.ExpiresAt = &expiresAt
+	}
+
+	return s.repository.CreateInvitation(ctx, invitation)
+}
+
+func (s *InvitationService) GetAllInvitations(ctx context.Context) ([]Invitation, error) {
+	return s.repository.GetInvitations(ctx)
+}
+
+func (s *InvitationService) GetUserInvitations(ctx context.Context, userId int64) ([]Invitation, error) {
+	return s.repository.GetUserInvitations(ctx, userId)
+}
+
+// GetUses returns who signed up with each of the invitations, keyed by invitation ID.
+func (s *InvitationService) GetUses(ctx context.Context, invitations []Invitation) (map[int64][]Use, error) {
+	invitationIds := make([]int64, len(invitations))
+	for i, invitation := range invitations {
+		invitationIds[i] = invitation.Id
+	}
+
+	uses, err := s.repository.GetInvitationUses(ctx, invitationIds)
+	if err != nil {
+		return nil, err
+	}
+
+	usesByInvitation := make(map[int64][]Use)
+	for _, use := range uses {
+		usesByInvitation[use.InvitationId] = append(usesByInvitation[use.InvitationId], use)
+	}
+	return usesByInvitation, nil
+}
+
+// RevokeInvitation stops an invitation from being used again. Admins can revoke any invitation,
+// other users only their own, and invitations of other users are reported as not found.
+func (s *InvitationService) RevokeInvitation(ctx context.Context, user *core.UserEntity, invitationId int64) (*Invitation, error) {
+	invitation, err := s.repository.GetInvitation(ctx, invitationId)
+	if err != nil {
+		return nil, err
+	}
+	if invitation == nil || (!user.IsAdmin && invitation.CreatedBy != user.Id) {
+		return nil, ErrInvitationNotFound
+	}
+
+	if invitation.IsRevoked() {
+		return invitation, nil
+	}
+	if invitation.IsUsedUp() {
+		return nil, ErrInvitationUsedUp
+	}
+
+	now := core.Now()
+	if err := s.repository.RevokeInvitation(ctx, invitationId, now); err != nil {
+		return nil, err
+	}
+	invitation.RevokedAt = &now
+
+	return invitation, nil
+}
+
+// CheckCode returns the invitation with the given code if it can still be used.
+func (s *InvitationService) CheckCode(ctx context.Context, code string) (*Invitation, error) {
+	invitation, err := s.repository.GetInvitationByCode(ctx, FormatCode(code))
+	if err != nil {
+		return nil, err
+	}
+	if invitation == nil || !invitation.IsActive(core.Now()) {
+		return nil, ErrInvalidCode
+	}
+
+	return invitation, nil
+}
+
+// SignUp creates a user with an invitation code and records the invitation as used, so the user
+// and their inviter are linked.
+func (s *InvitationService) SignUp(ctx context.Context, code, username, password, confirmPassword string) (*core.UserEntity, error) {
+	var user *core.UserEntity
+	err := s.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		invitation, err := s.CheckCode(ctx, code)
+		if err != nil {
+			return err
+		}
+
+		user, err = s.userService.CreateUser(ctx, username, password, confirmPassword)
+		if err != nil {
+			return err
+		}
+
+		if invitation.DisplayName != "" {
+			displayName, err := s.userService.SetDisplayName(ctx, user.Id, invitation.DisplayName)
+			if err != nil {
+				return err
+			}
+			user.DisplayName = displayName
+		}
+
+		return s.repository.UseInvitation(ctx, invitation.Id, user.Id, core.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
@@ -0,0 +1,151 @@
+package invitation_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/invitation"
+	"github.com/CaribouBlue/mixtape/internal/storage"
+)
+
+const password = "password123"
+
+// newTestService sets up an invitation service on a database in a temp directory, along with an
+// admin to create invitations.
+func newTestService(t *testing.T) (*invitation.InvitationService, *storage.SqliteStore, *core.UserEntity) {
+	t.Helper()
+
+	store, err := storage.NewSqliteDb(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	if err := store.Setup(); err != nil {
+		t.Fatal(err)
+	}
+
+	admin, err := store.CreateUser(context.Background(), &core.UserEntity{Username: "admin", IsAdmin: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return invitation.NewInvitationService(store, store, core.NewUserService(store)), store, admin
+}
+
+func TestSignUpCountsUses(t *testing.T) {
+	tests := []struct {
+		name    string
+		maxUses int
+		signUps int
+		// revoke revokes the invitation before anyone signs up.
+		revoke   bool
+		wantUses int
+	}{
+		{name: "single use", maxUses: 1, signUps: 1, wantUses: 1},
+		{name: "single use used twice", maxUses: 1, signUps: 2, wantUses: 1},
+		{name: "uses left", maxUses: 3, signUps: 2, wantUses: 2},
+		{name: "used up", maxUses: 3, signUps: 5, wantUses: 3},
+		{name: "revoked", maxUses: 3, signUps: 1, revoke: true, wantUses: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			service, store, admin := newTestService(t)
+
+			inv, err := service.CreateInvitation(ctx, admin, "", tt.maxUses, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.revoke {
+				if _, err := service.RevokeInvitation(ctx, admin, inv.Id); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for i := range tt.signUps {
+				username := fmt.Sprintf("user%d", i)
+				user, err := service.SignUp(ctx, inv.Code, username, password, password)
+
+				wantErr := error(nil)
+				if tt.revoke || i >= tt.maxUses {
+					wantErr = invitation.ErrInvalidCode
+				}
+				if err != wantErr {
+					t.Fatalf("SignUp() #%d error = %v, want %v", i+1, err, wantErr)
+				}
+
+				existing, err := store.GetUserByUsername(ctx, username)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if wantErr == nil && (user == nil || existing == nil) {
+					t.Errorf("SignUp() #%d didn't create the user", i+1)
+				} else if wantErr != nil && existing != nil {
+					t.Errorf("SignUp() #%d created the user despite failing", i+1)
+				}
+			}
+
+			inv, err = store.GetInvitation(ctx, inv.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if inv.Uses != tt.wantUses {
+				t.Errorf("Uses = %d, want %d", inv.Uses, tt.wantUses)
+			}
+
+			uses, err := service.GetUses(ctx, []invitation.Invitation{*inv})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(uses[inv.Id]) != tt.wantUses {
+				t.Errorf("recorded %d uses, want %d", len(uses[inv.Id]), tt.wantUses)
+			}
+		})
+	}
+}
+
+func TestSignUpFailureDoesNotUseInvitation(t *testing.T) {
+	tests := []struct {
+		name            string
+		username        string
+		confirmPassword string
+		want            error
+	}{
+		{"passwords don't match", "newuser", "other", core.ErrPasswordsDoNotMatch},
+		{"username taken", "admin", password, core.ErrUsernameAlreadyExists},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			service, store, admin := newTestService(t)
+
+			inv, err := service.CreateInvitation(ctx, admin, "", 1, time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := service.SignUp(ctx, inv.Code, tt.username, password, tt.confirmPassword); err != tt.want {
+				t.Fatalf("SignUp() error = %v, want %v", err, tt.want)
+			}
+
+			inv, err = store.GetInvitation(ctx, inv.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if inv.Uses != 0 {
+				t.Errorf("Uses = %d after a failed sign up, want 0", inv.Uses)
+			}
+
+			if _, err := service.SignUp(ctx, invitation.NormalizeCode(inv.Code), "newuser", password, password); err != nil {
+				t.Errorf("SignUp() with the unused invitation error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/log"
+)
+
+// Names of the messages sent to a session's clients. They only tell the page what changed, the
+// page then fetches the parts it needs, since most of a session page depends on who is viewing it.
+const (
+	MessagePlayers = "players"
+	MessagePhase   = "phase"
+)
+
+var (
+	ErrTooManyConnections = errors.New("too many live connections")
+	ErrBroadcasterClosed  = errors.New("broadcaster closed")
+)
+
+// Activity counts what players do in a session that its clients show live.
+type Activity struct {
+	Players   int
+	Finalized int
+	Votes     int
+}
+
+// Repository is where the broadcaster watches sessions for changes. Every server reads the same
+// database, so clients hear about changes whichever server made them.
+type Repository interface {
+	GetSessionById(ctx context.Context, id int64) (*core.SessionEntity, error)
+	// GetSessionsActivity returns the activity of the given sessions by ID, sessions without any
+	// are left out.
+	GetSessionsActivity(ctx context.Context, sessionIds []int64) (map[int64]Activity, error)
+}
+
+// sessionState is what clients were last told about a session.
+type sessionState struct {
+	phase core.SessionPhase
+	// resultsFrozenAt is when the results were last frozen, zero while they're pending.
+	resultsFrozenAt time.Time
+	activity        Activity
+}
+
+// Message is a single server-sent event.
+type Message struct {
+	Event string
+	Data  string
+}
+
+type BroadcasterOpts struct {
+	MaxConnections           int
+	MaxConnectionsPerSession int
+	HeartbeatInterval        time.Duration
+	// PollInterval is how often watched sessions are checked for changes. Phase changes are
+	// pushed within an interval of the phase starting.
+	PollInterval time.Duration
+}
+
+type BroadcasterOption func(*BroadcasterOpts)
+
+func WithMaxConnections(max int) BroadcasterOption {
+	return func(opts *BroadcasterOpts) {
+		opts.MaxConnections = max
+	}
+}
+
+func WithMaxConnectionsPerSession(max int) BroadcasterOption {
+	return func(opts *BroadcasterOpts) {
+		opts.MaxConnectionsPerSession = max
+	}
+}
+
+func WithHeartbeatInterval(interval time.Duration) BroadcasterOption {
+	return func(opts *BroadcasterOpts) {
+		opts.HeartbeatInterval = interval
+	}
+}
+
+func WithPollInterval(interval time.Duration) BroadcasterOption {
+	return func(opts *BroadcasterOpts) {
+		opts.PollInterval = interval
+	}
+}
+
+// Broadcaster fans messages out to the clients watching each session. It polls the sessions that
+// have clients rather than listening to events, which only reach the server that published them.
+type Broadcaster struct {
+	opts       BroadcasterOpts
+	repository Repository
+	mu         sync.Mutex
+	sessions   map[int64]map[*Connection]struct{}
+	count      int
+	closed     bool
+
+	// states is only used by the poll loop.
+	states map[int64]sessionState
+	stop   context.CancelFunc
+	done   chan struct{}
+}
+
+func NewBroadcaster(repository Repository, options ...BroadcasterOption) *Broadcaster {
+	opts := BroadcasterOpts{
+		MaxConnections:           1000,
+		MaxConnectionsPerSession: 100,
+		HeartbeatInterval:        30 * time.Second,
+		PollInterval:             time.Second,
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	return &Broadcaster{
+		opts:       opts,
+		repository: repository,
+		sessions:   make(map[int64]map[*Connection]struct{}),
+		states:     make(map[int64]sessionState),
+	}
+}
+
+// Start polls the watched sessions for changes until the broadcaster is closed.
+func (b *Broadcaster) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	b.stop = cancel
+	b.done = make(chan struct{})
+
+	go b.poll(ctx)
+}
+
+// Connect registers a client for a session's messages. The connection must be closed once the
+// client goes away.
+func (b *Broadcaster) Connect(sessionId int64) (*Connection, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, ErrBroadcasterClosed
+	}
+
+	conns := b.sessions[sessionId]
+	if b.count >= b.opts.MaxConnections || len(conns) >= b.opts.MaxConnectionsPerSession {
+		return nil, ErrTooManyConnections
+	}
+
+	if conns == nil {
+		conns = make(map[*Connection]struct{})
+		b.sessions[sessionId] = conns
+	}
+
+	conn := &Connection{
+		broadcaster: b,
+		sessionId:   sessionId,
+		pending:     make(map[string]Message),
+		notify:      make(chan struct{}, 1),
+		done:        make(chan struct{}),
+	}
+	conns[conn] = struct{}{}
+	b.count++
+
+	return conn, nil
+}
+
+// Publish sends a message to every client of a session without waiting on them.
+func (b *Broadcaster) Publish(sessionId int64, message Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for conn := range b.sessions[sessionId] {
+		conn.queue(message)
+	}
+}
+
+// ConnectionCount returns the number of open connections.
+func (b *Broadcaster) ConnectionCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.count
+}
+
+// Close disconnects every client and refuses new ones. Open streams would otherwise hold up a
+// graceful server shutdown until it times out.
+func (b *Broadcaster) Close() {
+	if b.stop != nil {
+		b.stop()
+		<-b.done
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+
+	for _, conns := range b.sessions {
+		for conn := range conns {
+			close(conn.done)
+		}
+	}
+	b.sessions = make(map[int64]map[*Connection]struct{})
+	b.count = 0
+}
+
+func (b *Broadcaster) disconnect(conn *Connection) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	conns, ok := b.sessions[conn.sessionId]
+	if !ok {
+		return
+	}
+	if _, ok := conns[conn]; !ok {
+		return
+	}
+
+	delete(conns, conn)
+	if len(conns) == 0 {
+		delete(b.sessions, conn.sessionId)
+	}
+	b.count--
+	close(conn.done)
+}
+
+func (b *Broadcaster) poll(ctx context.Context) {
+	defer close(b.done)
+
+	ticker := time.NewTicker(b.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := b.check(ctx); err != nil && ctx.Err() == nil {
+				log.Logger().Error().Err(err).Msg("Failed to check live sessions")
+			}
+		}
+	}
+}
+
+// check pushes what changed in the watched sessions since the last check. Sessions are only
+// compared from their second check on, clients loaded them just before connecting.
+func (b *Broadcaster) check(ctx context.Context) error {
+	b.mu.Lock()
+	sessionIds := make([]int64, 0, len(b.sessions))
+	for sessionId := range b.sessions {
+		sessionIds = append(sessionIds, sessionId)
+	}
+	b.mu.Unlock()
+
+	watched := make(map[int64]bool, len(sessionIds))
+	for _, sessionId := range sessionIds {
+		watched[sessionId] = true
+	}
+	for sessionId := range b.states {
+		if !watched[sessionId] {
+			delete(b.states, sessionId)
+		}
+	}
+	if len(sessionIds) == 0 {
+		return nil
+	}
+
+	activity, err := b.repository.GetSessionsActivity(ctx, sessionIds)
+	if err != nil {
+		return err
+	}
+
+	now := core.Now()
+	for _, sessionId := range sessionIds {
+		session, err := b.repository.GetSessionById(ctx, sessionId)
+		if err != nil {
+			return err
+		} else if session == nil {
+			continue
+		}
+
+		state := sessionState{phase: session.PhaseAt(now), activity: activity[sessionId]}
+		if session.ResultsFrozenAt != nil {
+			state.resultsFrozenAt = *session.ResultsFrozenAt
+		}
+		last, ok := b.states[sessionId]
+		b.states[sessionId] = state
+		if !ok {
+			continue
+		}
+
+		// Results being frozen reloads the page like a phase change, it's what the results phase
+		// shows.
+		if state.phase != last.phase || !state.resultsFrozenAt.Equal(last.resultsFrozenAt) {
+			b.Publish(sessionId, Message{Event: MessagePhase, Data: string(state.phase)})
+		}
+
+		if state.activity != last.activity {
+			b.Publish(sessionId, Message{Event: MessagePlayers, Data: MessagePlayers})
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package live
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Connection is one client's stream of a session's messages.
+type Connection struct {
+	broadcaster *Broadcaster
+	sessionId   int64
+	done        chan struct{}
+
+	// Messages only prompt clients to refresh, so a client that falls behind just needs the
+	// latest message of each kind. Keeping one per event bounds what a slow client can hold.
+	mu      sync.Mutex
+	pending map[string]Message
+	order   []string
+	notify  chan struct{}
+}
+
+func (conn *Connection) queue(message Message) {
+	conn.mu.Lock()
+	if _, ok := conn.pending[message.Event]; !ok {
+		conn.order = append(conn.order, message.Event)
+	}
+	conn.pending[message.Event] = message
+	conn.mu.Unlock()
+
+	select {
+	case conn.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (conn *Connection) takePending() []Message {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	messages := make([]Message, len(conn.order))
+	for i, event := range conn.order {
+		messages[i] = conn.pending[event]
+	}
+	conn.pending = make(map[string]Message)
+	conn.order = nil
+
+	return messages
+}
+
+// Close disconnects the client from the broadcaster. It's safe to call more than once.
+func (conn *Connection) Close() {
+	conn.broadcaster.disconnect(conn)
+}
+
+// Serve streams messages to the client as server-sent events until the client goes away or the
+// broadcaster closes. A comment is sent whenever the stream has been quiet for the heartbeat
+// interval, so proxies don't drop the connection and dead clients are noticed.
+func (conn *Connection) Serve(w http.ResponseWriter, r *http.Request) error {
+	defer conn.Close()
+
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// Sending a comment right away lets the client know the stream is open.
+	if _, err := io.WriteString(w, ": connected\n\n"); err != nil {
+		return err
+	}
+	if err := rc.Flush(); err != nil {
+		return err
+	}
+
+	heartbeat := time.NewTicker(conn.broadcaster.opts.HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return nil
+		case <-conn.done:
+			return nil
+		case <-conn.notify:
+			for _, message := range conn.takePending() {
+				if err = writeMessage(w, message); err != nil {
+					break
+				}
+			}
+		case <-heartbeat.C:
+			_, err = io.WriteString(w, ": heartbeat\n\n")
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := rc.Flush(); err != nil {
+			return err
+		}
+		heartbeat.Reset(conn.broadcaster.opts.HeartbeatInterval)
+	}
+}
+
+func writeMessage(w io.Writer, message Message) error {
+	var b strings.Builder
+	if message.Event != "" {
+		fmt.Fprintf(&b, "event: %s\n", message.Event)
+	}
+	for _, line := range strings.Split(message.Data, "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -6,7 +6,7 @@ import (
 	"github.com/rs/zerolog"
 )
 
-var defaultLogger zerolog.Logger = zerolog.New(os.Stderr).With().Timestamp().Logger()
+var defaultLogger zerolog.Logger = zerolog.New(zerolog.MultiLevelWriter(os.Stderr, defaultRecentErrors)).With().Timestamp().Logger()
 
 func Logger() *zerolog.Logger {
 	logger := defaultLogger
@@ -0,0 +1,99 @@
+package log
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const defaultRecentErrorsSize = 100
+
+// RecentEntry is a log entry kept in memory so it can be shown without reading the log file.
+type RecentEntry struct {
+	Time    time.Time
+	Level   zerolog.Level
+	Message string
+	Error   string
+	Path    string
+	// Raw is the entry as it was logged.
+	Raw string
+}
+
+// RecentErrors is a zerolog writer that keeps the last entries logged at error level or above in
+// a ring buffer.
+type RecentErrors struct {
+	mu      sync.Mutex
+	entries []RecentEntry
+	next    int
+	full    bool
+}
+
+func NewRecentErrors(size int) *RecentErrors {
+	return &RecentErrors{
+		entries: make([]RecentEntry, size),
+	}
+}
+
+var defaultRecentErrors = NewRecentErrors(defaultRecentErrorsSize)
+
+// DefaultRecentErrors is the buffer the default logger writes to. Loggers set with
+// SetDefaultLogger should include it in their writers.
+func DefaultRecentErrors() *RecentErrors {
+	return defaultRecentErrors
+}
+
+func (r *RecentErrors) Write(p []byte) (int, error) {
+	return r.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (r *RecentErrors) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if level < zerolog.ErrorLevel || level == zerolog.NoLevel || len(r.entries) == 0 {
+		return len(p), nil
+	}
+
+	var fields struct {
+		Time    string `json:"time"`
+		Message string `json:"message"`
+		Error   string `json:"error"`
+		Path    string `json:"path"`
+	}
+	entry := RecentEntry{Level: level, Raw: string(p), Time: time.Now()}
+	if err := json.Unmarshal(p, &fields); err == nil {
+		entry.Message = fields.Message
+		entry.Error = fields.Error
+		entry.Path = fields.Path
+		if t, err := time.Parse(zerolog.TimeFieldFormat, fields.Time); err == nil {
+			entry.Time = t
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries[r.next] = entry
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+
+	return len(p), nil
+}
+
+// Entries returns the kept entries, newest first.
+func (r *RecentErrors) Entries() []RecentEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := r.next
+	if r.full {
+		count = len(r.entries)
+	}
+
+	entries := make([]RecentEntry, 0, count)
+	for i := 1; i <= count; i++ {
+		entries = append(entries, r.entries[(r.next-i+len(r.entries))%len(r.entries)])
+	}
+	return entries
+}
@@ -0,0 +1,202 @@
+package loginlimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+)
+
+type LimiterOpts struct {
+	// FreeAttempts is how many failures a username can have before attempts are backed off.
+	FreeAttempts int
+	// BaseDelay is how long attempts are blocked after the first failure past the free ones, it
+	// doubles with every failure after that.
+	BaseDelay time.Duration
+	// MaxDelay caps how long attempts are backed off for.
+	MaxDelay time.Duration
+	// LockoutThreshold is how many failures lock a username out.
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+	// IpFactor scales the number of failures allowed for IP addresses, which many people can
+	// share.
+	IpFactor int
+	// ResetAfter is how long it takes without failures for the count to start over.
+	ResetAfter time.Duration
+}
+
+type LimiterOption func(*LimiterOpts)
+
+func WithFreeAttempts(attempts int) LimiterOption {
+	return func(opts *LimiterOpts) {
+		opts.FreeAttempts = attempts
+	}
+}
+
+func WithLockoutThreshold(threshold int) LimiterOption {
+	return func(opts *LimiterOpts) {
+		opts.LockoutThreshold = threshold
+	}
+}
+
+func WithLockoutDuration(duration time.Duration) LimiterOption {
+	return func(opts *LimiterOpts) {
+		opts.LockoutDuration = duration
+	}
+}
+
+type Limiter struct {
+	opts       LimiterOpts
+	repository Repository
+}
+
+func NewLimiter(repository Repository, options ...LimiterOption) *Limiter {
+	opts := LimiterOpts{
+		FreeAttempts:     3,
+		BaseDelay:        time.Second,
+		MaxDelay:         5 * time.Minute,
+		LockoutThreshold: 10,
+		LockoutDuration:  15 * time.Minute,
+		IpFactor:         5,
+		ResetAfter:       24 * time.Hour,
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	return &Limiter{
+		opts:       opts,
+		repository: repository,
+	}
+}
+
+// Check returns an error when attempts from the IP address or for the username are blocked, along
+// with when they're allowed again. An empty username only checks the IP address.
+func (l *Limiter) Check(ctx context.Context, ip string, username string) (time.Time, error) {
+	now := core.Now()
+
+	var retryAt time.Time
+	var err error
+	for _, key := range keys(ip, username) {
+		failures, getErr := l.repository.GetLoginFailures(ctx, key.kind, key.value)
+		if getErr != nil {
+			return time.Time{}, getErr
+		}
+		if failures == nil || !failures.IsBlocked(now) {
+			continue
+		}
+
+		if failures.BlockedUntil.After(retryAt) {
+			retryAt = failures.BlockedUntil
+		}
+		if failures.Count >= l.lockoutThreshold(key.kind) {
+			err = ErrLockedOut
+		} else if err == nil {
+			err = ErrTooManyAttempts
+		}
+	}
+
+	return retryAt, err
+}
+
+// RecordFailure counts a failed attempt from the IP address and for the username, blocking further
+// attempts for a while once there have been too many. It returns the updated failures.
+func (l *Limiter) RecordFailure(ctx context.Context, ip string, username string) ([]Failures, error) {
+	now := core.Now()
+	resetBefore := now.Add(-l.opts.ResetAfter)
+
+	if err := l.repository.DeleteStaleLoginFailures(ctx, resetBefore); err != nil {
+		return nil, err
+	}
+
+	updated := make([]Failures, 0, 2)
+	for _, key := range keys(ip, username) {
+		failures, err := l.repository.RecordLoginFailure(ctx, key.kind, key.value, now, resetBefore)
+		if err != nil {
+			return nil, err
+		}
+
+		if delay := l.delay(key.kind, failures.Count); delay > 0 {
+			until := now.Add(delay)
+			if err := l.repository.BlockLoginAttempts(ctx, key.kind, key.value, until); err != nil {
+				return nil, err
+			}
+			if until.After(failures.BlockedUntil) {
+				failures.BlockedUntil = until
+			}
+		}
+
+		updated = append(updated, *failures)
+	}
+
+	return updated, nil
+}
+
+// RecordSuccess clears the failures for the username after a successful login. The IP address keeps
+// its failures, otherwise logging in to one account would reset guessing the passwords of others.
+func (l *Limiter) RecordSuccess(ctx context.Context, username string) error {
+	return l.repository.DeleteLoginFailures(ctx, KeyKindUsername, username)
+}
+
+// GetBlocked returns the IP addresses and usernames that can't log in right now.
+func (l *Limiter) GetBlocked(ctx context.Context) ([]Failures, error) {
+	return l.repository.GetBlockedLoginFailures(ctx, core.Now())
+}
+
+// IsLockedOut reports whether the failures are past backing off and into a lockout.
+func (l *Limiter) IsLockedOut(failures Failures) bool {
+	return failures.Count >= l.lockoutThreshold(failures.Kind)
+}
+
+// Unlock clears the failures for an IP address or username so it can log in again right away.
+func (l *Limiter) Unlock(ctx context.Context, kind KeyKind, value string) error {
+	if !IsKeyKind(string(kind)) {
+		return ErrInvalidKeyKind
+	}
+	return l.repository.DeleteLoginFailures(ctx, kind, value)
+}
+
+func (l *Limiter) factor(kind KeyKind) int {
+	if kind == KeyKindIp {
+		return l.opts.IpFactor
+	}
+	return 1
+}
+
+func (l *Limiter) lockoutThreshold(kind KeyKind) int {
+	return l.opts.LockoutThreshold * l.factor(kind)
+}
+
+// delay is how long attempts are blocked for after the given number of failures.
+func (l *Limiter) delay(kind KeyKind, count int) time.Duration {
+	if count >= l.lockoutThreshold(kind) {
+		return l.opts.LockoutDuration
+	}
+
+	backoffs := count - l.opts.FreeAttempts*l.factor(kind)
+	if backoffs < 0 {
+		return 0
+	}
+
+	delay := l.opts.BaseDelay
+	for range backoffs {
+		delay *= 2
+		if delay >= l.opts.MaxDelay {
+			return l.opts.MaxDelay
+		}
+	}
+	return delay
+}
+
+type key struct {
+	kind  KeyKind
+	value string
+}
+
+func keys(ip string, username string) []key {
+	keys := []key{{KeyKindIp, ip}}
+	if username != "" {
+		keys = append(keys, key{KeyKindUsername, username})
+	}
+	return keys
+}
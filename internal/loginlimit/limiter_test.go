@@ -0,0 +1,156 @@
+package loginlimit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+)
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+// useTestClock replaces the clock until the test ends.
+func useTestClock(t *testing.T) *testClock {
+	clock := &testClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	core.SetClock(clock)
+	t.Cleanup(func() { core.SetClock(core.SystemClock{}) })
+	return clock
+}
+
+func TestLimiterDelay(t *testing.T) {
+	limiter := NewLimiter(NewMemoryRepository())
+
+	tests := []struct {
+		kind  KeyKind
+		count int
+		want  time.Duration
+	}{
+		{KeyKindUsername, 1, 0},
+		{KeyKindUsername, 2, 0},
+		{KeyKindUsername, 3, time.Second},
+		{KeyKindUsername, 4, 2 * time.Second},
+		{KeyKindUsername, 5, 4 * time.Second},
+		{KeyKindUsername, 9, 64 * time.Second},
+		{KeyKindUsername, 10, 15 * time.Minute},
+		{KeyKindUsername, 25, 15 * time.Minute},
+		{KeyKindIp, 3, 0},
+		{KeyKindIp, 14, 0},
+		{KeyKindIp, 15, time.Second},
+		{KeyKindIp, 16, 2 * time.Second},
+		{KeyKindIp, 24, 5 * time.Minute},
+		{KeyKindIp, 49, 5 * time.Minute},
+		{KeyKindIp, 50, 15 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %d", tt.kind, tt.count), func(t *testing.T) {
+			if got := limiter.delay(tt.kind, tt.count); got != tt.want {
+				t.Errorf("delay(%s, %d) = %v, want %v", tt.kind, tt.count, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimiterBackoffAndLockout(t *testing.T) {
+	const username = "user"
+	ctx := context.Background()
+
+	// Each step waits, records failures from new IP addresses or logs in, then checks whether the
+	// username can log in from another IP address. Steps run in order on one limiter.
+	tests := []struct {
+		name      string
+		failures  int
+		success   bool
+		wait      time.Duration
+		wantErr   error
+		wantRetry time.Duration
+	}{
+		{name: "free attempts", failures: 2, wantErr: nil},
+		{name: "backed off", failures: 1, wantErr: ErrTooManyAttempts, wantRetry: time.Second},
+		{name: "backoff expires", wait: time.Second, wantErr: nil},
+		{name: "backoff doubles", failures: 1, wantErr: ErrTooManyAttempts, wantRetry: 2 * time.Second},
+		{name: "still backed off", wait: time.Second, wantErr: ErrTooManyAttempts, wantRetry: time.Second},
+		{name: "locked out", failures: 6, wantErr: ErrLockedOut, wantRetry: 15 * time.Minute},
+		{name: "still locked out", wait: 10 * time.Minute, wantErr: ErrLockedOut, wantRetry: 5 * time.Minute},
+		{name: "lockout expires", wait: 5 * time.Minute, wantErr: nil},
+		{name: "failure after lockout locks out again", failures: 1, wantErr: ErrLockedOut, wantRetry: 15 * time.Minute},
+		{name: "count starts over", wait: 25 * time.Hour, failures: 1, wantErr: nil},
+		{name: "success clears failures", failures: 1, success: true, wantErr: nil},
+		{name: "free attempts after success", failures: 2, wantErr: nil},
+	}
+
+	clock := useTestClock(t)
+	limiter := NewLimiter(NewMemoryRepository())
+	ip := 0
+	for _, tt := range tests {
+		clock.now = clock.now.Add(tt.wait)
+		for range tt.failures {
+			ip++
+			if _, err := limiter.RecordFailure(ctx, fmt.Sprintf("10.0.0.%d", ip), username); err != nil {
+				t.Fatalf("%s: RecordFailure() error = %v", tt.name, err)
+			}
+		}
+		if tt.success {
+			if err := limiter.RecordSuccess(ctx, username); err != nil {
+				t.Fatalf("%s: RecordSuccess() error = %v", tt.name, err)
+			}
+		}
+
+		retryAt, err := limiter.Check(ctx, "192.168.0.1", username)
+		if err != tt.wantErr {
+			t.Fatalf("%s: Check() error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+		if tt.wantErr != nil && retryAt.Sub(clock.now) != tt.wantRetry {
+			t.Errorf("%s: Check() retry in %v, want %v", tt.name, retryAt.Sub(clock.now), tt.wantRetry)
+		}
+	}
+}
+
+func TestLimiterIpKeepsFailuresAfterSuccess(t *testing.T) {
+	const ip = "10.0.0.1"
+	ctx := context.Background()
+	clock := useTestClock(t)
+	limiter := NewLimiter(NewMemoryRepository())
+
+	// Guessing many usernames from one address backs off the address, not the usernames.
+	for i := range 15 {
+		if _, err := limiter.RecordFailure(ctx, ip, fmt.Sprintf("user%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := limiter.RecordSuccess(ctx, "user0"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		ip       string
+		username string
+		want     error
+	}{
+		{"address", ip, "", ErrTooManyAttempts},
+		{"address with a username", ip, "user0", ErrTooManyAttempts},
+		{"username from another address", "10.0.0.2", "user1", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := limiter.Check(ctx, tt.ip, tt.username); err != tt.want {
+				t.Errorf("Check(%q, %q) error = %v, want %v", tt.ip, tt.username, err, tt.want)
+			}
+		})
+	}
+
+	clock.now = clock.now.Add(time.Second)
+	if _, err := limiter.Check(ctx, ip, ""); err != nil {
+		t.Errorf("Check() after the backoff error = %v, want nil", err)
+	}
+}
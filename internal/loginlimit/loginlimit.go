@@ -0,0 +1,61 @@
+// Package loginlimit slows down password guessing by backing off, and eventually locking out, IP
+// addresses and usernames that keep failing to log in.
+package loginlimit
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// KeyKind is what failed attempts are counted by.
+type KeyKind string
+
+const (
+	KeyKindIp       KeyKind = "ip"
+	KeyKindUsername KeyKind = "username"
+)
+
+func IsKeyKind(value string) bool {
+	return value == string(KeyKindIp) || value == string(KeyKindUsername)
+}
+
+var (
+	// ErrTooManyAttempts is returned while attempts are backed off after a few failures.
+	ErrTooManyAttempts = errors.New("too many failed login attempts")
+	// ErrLockedOut is returned while attempts are locked out after many failures.
+	ErrLockedOut      = errors.New("locked out after too many failed login attempts")
+	ErrInvalidKeyKind = errors.New("invalid login limit key kind")
+)
+
+// Failures counts the failed attempts for an IP address or username.
+type Failures struct {
+	Kind         KeyKind
+	Value        string
+	Count        int
+	LastFailedAt time.Time
+	// BlockedUntil is when attempts are allowed again, it's zero or in the past when they are.
+	BlockedUntil time.Time
+}
+
+func (f *Failures) IsBlocked(now time.Time) bool {
+	return now.Before(f.BlockedUntil)
+}
+
+// Repository stores failed attempts. Limits are enforced across every server sharing it, so an
+// in-memory one only suits a single server.
+type Repository interface {
+	// GetLoginFailures returns nil when there are no failures for the key.
+	GetLoginFailures(ctx context.Context, kind KeyKind, value string) (*Failures, error)
+	// RecordLoginFailure adds a failure for the key and returns the updated count. Counting starts
+	// over when the last failure was before resetBefore.
+	RecordLoginFailure(ctx context.Context, kind KeyKind, value string, now time.Time, resetBefore time.Time) (*Failures, error)
+	// BlockLoginAttempts blocks the key until the given time, unless it's already blocked longer.
+	BlockLoginAttempts(ctx context.Context, kind KeyKind, value string, until time.Time) error
+	DeleteLoginFailures(ctx context.Context, kind KeyKind, value string) error
+	// DeleteStaleLoginFailures deletes failures that would start over anyway.
+	DeleteStaleLoginFailures(ctx context.Context, before time.Time) error
+	// GetBlockedLoginFailures returns the keys that are blocked at the given time, latest failure
+	// first.
+	GetBlockedLoginFailures(ctx context.Context, now time.Time) ([]Failures, error)
+}
@@ -0,0 +1,105 @@
+package loginlimit
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+type memoryKey struct {
+	kind  KeyKind
+	value string
+}
+
+// MemoryRepository keeps failed attempts in memory. They're lost on restart and aren't shared
+// between servers.
+type MemoryRepository struct {
+	mu       sync.Mutex
+	failures map[memoryKey]*Failures
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		failures: make(map[memoryKey]*Failures),
+	}
+}
+
+func (r *MemoryRepository) GetLoginFailures(ctx context.Context, kind KeyKind, value string) (*Failures, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	failures, ok := r.failures[memoryKey{kind, value}]
+	if !ok {
+		return nil, nil
+	}
+	copied := *failures
+	return &copied, nil
+}
+
+func (r *MemoryRepository) RecordLoginFailure(ctx context.Context, kind KeyKind, value string, now time.Time, resetBefore time.Time) (*Failures, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := memoryKey{kind, value}
+	failures, ok := r.failures[key]
+	if !ok || failures.LastFailedAt.Before(resetBefore) {
+		failures = &Failures{Kind: kind, Value: value}
+		r.failures[key] = failures
+	}
+
+	failures.Count++
+	failures.LastFailedAt = now
+
+	copied := *failures
+	return &copied, nil
+}
+
+func (r *MemoryRepository) BlockLoginAttempts(ctx context.Context, kind KeyKind, value string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	failures, ok := r.failures[memoryKey{kind, value}]
+	if ok && until.After(failures.BlockedUntil) {
+		failures.BlockedUntil = until
+	}
+	return nil
+}
+
+func (r *MemoryRepository) DeleteLoginFailures(ctx context.Context, kind KeyKind, value string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.failures, memoryKey{kind, value})
+	return nil
+}
+
+func (r *MemoryRepository) DeleteStaleLoginFailures(ctx context.Context, before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, failures := range r.failures {
+		if failures.LastFailedAt.Before(before) && failures.BlockedUntil.Before(before) {
+			delete(r.failures, key)
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) GetBlockedLoginFailures(ctx context.Context, now time.Time) ([]Failures, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	blocked := make([]Failures, 0)
+	for _, failures := range r.failures {
+		if failures.IsBlocked(now) {
+			blocked = append(blocked, *failures)
+		}
+	}
+
+	slices.SortFunc(blocked, func(a, b Failures) int {
+		return b.LastFailedAt.Compare(a.LastFailedAt)
+	})
+
+	return blocked, nil
+}
@@ -0,0 +1,76 @@
+// Package loginsession keeps track of where users are logged in, so a login can be ended from the
+// server, e.g. when a device is lost or a cookie is stolen.
+package loginsession
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+var (
+	ErrLoginSessionNotFound = errors.New("login session not found")
+	ErrInvalidLoginSession  = errors.New("invalid, expired or revoked login session")
+)
+
+const (
+	// MaxUserAgentLength caps how much of a user agent is stored, they're only shown to the user.
+	MaxUserAgentLength = 500
+	keyBytes           = 32
+)
+
+// LoginSession is a login from one browser. Its Key goes in the auth cookie as the token ID (jti)
+// and is looked up on every request, so revoking the session logs the browser out.
+type LoginSession struct {
+	Id         int64
+	UserId     int64
+	Key        string
+	UserAgent  string
+	IpAddress  string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	// ExpiresAt moves forward while the session is used, so only idle sessions expire.
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+}
+
+func (s *LoginSession) IsExpired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
+}
+
+func (s *LoginSession) IsRevoked() bool {
+	return s.RevokedAt != nil
+}
+
+func (s *LoginSession) IsActive(now time.Time) bool {
+	return !s.IsRevoked() && !s.IsExpired(now)
+}
+
+type Repository interface {
+	CreateLoginSession(ctx context.Context, session *LoginSession) (*LoginSession, error)
+	// GetLoginSession returns nil when no session has the given ID.
+	GetLoginSession(ctx context.Context, sessionId int64) (*LoginSession, error)
+	// GetLoginSessionByKey returns nil when no session has the given key.
+	GetLoginSessionByKey(ctx context.Context, key string) (*LoginSession, error)
+	// GetActiveUserLoginSessions returns the user's sessions that are neither revoked nor expired,
+	// most recently seen first.
+	GetActiveUserLoginSessions(ctx context.Context, userId int64, now time.Time) ([]LoginSession, error)
+	SetLoginSessionLastSeen(ctx context.Context, sessionId int64, lastSeenAt time.Time, expiresAt time.Time) error
+	RevokeLoginSession(ctx context.Context, sessionId int64, now time.Time) error
+	// RevokeUserLoginSessions revokes all of the user's active sessions except the one with the
+	// given ID, pass 0 to revoke them all.
+	RevokeUserLoginSessions(ctx context.Context, userId int64, exceptSessionId int64, now time.Time) error
+	// DeleteEndedUserLoginSessions deletes the user's sessions that were revoked or expired before
+	// the given time.
+	DeleteEndedUserLoginSessions(ctx context.Context, userId int64, before time.Time) error
+}
+
+func newKey() (string, error) {
+	b := make([]byte, keyBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,159 @@
+package loginsession
+
+import (
+	"context"
+	"time"
+	"unicode/utf8"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+)
+
+type LoginSessionServiceOpts struct {
+	// IdleTimeout is how long a session lasts without being used.
+	IdleTimeout time.Duration
+	// LastSeenInterval is how stale a session's last use can get before it's recorded again, and
+	// its expiry pushed back, so browsers don't cause a write on every request.
+	LastSeenInterval time.Duration
+	// Retention is how long ended sessions are kept around before they're deleted.
+	Retention time.Duration
+}
+
+type LoginSessionServiceOption func(*LoginSessionServiceOpts)
+
+func WithIdleTimeout(timeout time.Duration) LoginSessionServiceOption {
+	return func(opts *LoginSessionServiceOpts) {
+		opts.IdleTimeout = timeout
+	}
+}
+
+func WithLastSeenInterval(interval time.Duration) LoginSessionServiceOption {
+	return func(opts *LoginSessionServiceOpts) {
+		opts.LastSeenInterval = interval
+	}
+}
+
+type LoginSessionService struct {
+	opts       LoginSessionServiceOpts
+	repository Repository
+}
+
+func NewLoginSessionService(repository Repository, options ...LoginSessionServiceOption) *LoginSessionService {
+	opts := LoginSessionServiceOpts{
+		IdleTimeout:      7 * 24 * time.Hour,
+		LastSeenInterval: time.Minute,
+		Retention:        30 * 24 * time.Hour,
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	return &LoginSessionService{
+		opts:       opts,
+		repository: repository,
+	}
+}
+
+// StartSession logs the user in from a new browser. Sessions of the user that ended a while ago
+// are cleaned up along the way.
+func (s *LoginSessionService) StartSession(ctx context.Context, userId int64, userAgent string, ipAddress string) (*LoginSession, error) {
+	key, err := newKey()
+	if err != nil {
+		return nil, err
+	}
+
+	now := core.Now()
+	if err := s.repository.DeleteEndedUserLoginSessions(ctx, userId, now.Add(-s.opts.Retention)); err != nil {
+		return nil, err
+	}
+
+	return s.repository.CreateLoginSession(ctx, &LoginSession{
+		UserId:     userId,
+		Key:        key,
+		UserAgent:  truncate(userAgent, MaxUserAgentLength),
+		IpAddress:  ipAddress,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(s.opts.IdleTimeout),
+	})
+}
+
+// Authenticate returns the active session with the given key. Using a session pushes its expiry
+// back, the returned bool reports whether it was, so the auth cookie can be renewed to match.
+func (s *LoginSessionService) Authenticate(ctx context.Context, key string) (*LoginSession, bool, error) {
+	if key == "" {
+		return nil, false, ErrInvalidLoginSession
+	}
+
+	session, err := s.repository.GetLoginSessionByKey(ctx, key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	now := core.Now()
+	if session == nil || !session.IsActive(now) {
+		return nil, false, ErrInvalidLoginSession
+	}
+
+	if now.Sub(session.LastSeenAt) < s.opts.LastSeenInterval {
+		return session, false, nil
+	}
+
+	expiresAt := now.Add(s.opts.IdleTimeout)
+	if err := s.repository.SetLoginSessionLastSeen(ctx, session.Id, now, expiresAt); err != nil {
+		return nil, false, err
+	}
+	session.LastSeenAt = now
+	session.ExpiresAt = expiresAt
+
+	return session, true, nil
+}
+
+// GetUserSessions returns the browsers the user is logged in from.
+func (s *LoginSessionService) GetUserSessions(ctx context.Context, userId int64) ([]LoginSession, error) {
+	return s.repository.GetActiveUserLoginSessions(ctx, userId, core.Now())
+}
+
+// RevokeSession logs one of the user's browsers out. Sessions of other users are reported as not
+// found.
+func (s *LoginSessionService) RevokeSession(ctx context.Context, userId int64, sessionId int64) (*LoginSession, error) {
+	session, err := s.repository.GetLoginSession(ctx, sessionId)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.UserId != userId {
+		return nil, ErrLoginSessionNotFound
+	}
+
+	if session.IsRevoked() {
+		return session, nil
+	}
+
+	now := core.Now()
+	if err := s.repository.RevokeLoginSession(ctx, sessionId, now); err != nil {
+		return nil, err
+	}
+	session.RevokedAt = &now
+
+	return session, nil
+}
+
+// RevokeUserSessions logs the user out everywhere, except for the session with the given ID when
+// it isn't 0.
+func (s *LoginSessionService) RevokeUserSessions(ctx context.Context, userId int64, exceptSessionId int64) error {
+	return s.repository.RevokeUserLoginSessions(ctx, userId, exceptSessionId, core.Now())
+}
+
+// Subscribe logs users out everywhere when their password changes, however it was changed. The
+// subscriber is sync, so a failure to revoke fails the password change too.
+func (s *LoginSessionService) Subscribe(bus *core.EventBus) {
+	core.On(bus, func(ctx context.Context, event core.UserPasswordChangedEvent) error {
+		return s.RevokeUserSessions(ctx, event.UserId, 0)
+	})
+}
+
+func truncate(value string, maxLength int) string {
+	if utf8.RuneCountInString(value) <= maxLength {
+		return value
+	}
+	return string([]rune(value)[:maxLength])
+}
@@ -1,29 +1,235 @@
 package mail
 
-import "errors"
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/log"
+	"github.com/CaribouBlue/mixtape/internal/scheduler"
+	"github.com/a-h/templ"
+	"github.com/wneessen/go-mail"
+)
 
 var (
 	ErrInvalidEmail = errors.New("invalid email address")
 )
 
+// Message is an email with a plain text body and an optional HTML alternative.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	Html    string
+	Headers map[string]string
+}
+
+func NewMessage(to, subject string) *Message {
+	return &Message{
+		To:      to,
+		Subject: subject,
+		Headers: make(map[string]string),
+	}
+}
+
+// SetHtml renders component as the message's HTML body.
+func (m *Message) SetHtml(ctx context.Context, component templ.Component) error {
+	buf := &bytes.Buffer{}
+	if err := component.Render(ctx, buf); err != nil {
+		return err
+	}
+	m.Html = buf.String()
+	return nil
+}
+
+func (m *Message) SetHeader(name, value string) {
+	if m.Headers == nil {
+		m.Headers = make(map[string]string)
+	}
+	m.Headers[name] = value
+}
+
+// build converts the message into a MIME message sent from the given address.
+func (m *Message) build(from string) (*mail.Msg, error) {
+	msg := mail.NewMsg()
+
+	if err := msg.From(from); err != nil {
+		return nil, err
+	}
+
+	if err := msg.To(m.To); err != nil {
+		return nil, ErrInvalidEmail
+	}
+
+	msg.Subject(m.Subject)
+	msg.SetDate()
+	msg.SetMessageID()
+	for name, value := range m.Headers {
+		msg.SetGenHeader(mail.Header(name), value)
+	}
+
+	msg.SetBodyString(mail.TypeTextPlain, m.Text)
+	if m.Html != "" {
+		msg.AddAlternativeString(mail.TypeTextHTML, m.Html)
+	}
+
+	return msg, nil
+}
+
 type Mailer interface {
-	SendMail(to, subject, body string) error
+	Send(ctx context.Context, message *Message) error
+}
+
+type MailServiceOpts struct {
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// BatchSize is how many outbox messages are delivered each time the outbox is checked.
+	BatchSize int
+}
+
+type MailServiceOption func(*MailServiceOpts)
+
+func WithMaxAttempts(maxAttempts int) MailServiceOption {
+	return func(opts *MailServiceOpts) {
+		opts.MaxAttempts = maxAttempts
+	}
+}
+
+func WithBackoff(base, max time.Duration) MailServiceOption {
+	return func(opts *MailServiceOpts) {
+		opts.BaseBackoff = base
+		opts.MaxBackoff = max
+	}
+}
+
+func WithBatchSize(batchSize int) MailServiceOption {
+	return func(opts *MailServiceOpts) {
+		opts.BatchSize = batchSize
+	}
 }
 
 type MailService struct {
 	Mailer Mailer
+	opts   MailServiceOpts
+	outbox OutboxRepository
 }
 
-func NewMailService(mailer Mailer) *MailService {
+func NewMailService(mailer Mailer, outbox OutboxRepository, options ...MailServiceOption) *MailService {
+	opts := MailServiceOpts{
+		MaxAttempts: 8,
+		BaseBackoff: 30 * time.Second,
+		MaxBackoff:  6 * time.Hour,
+		BatchSize:   50,
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
 	return &MailService{
 		Mailer: mailer,
+		opts:   opts,
+		outbox: outbox,
 	}
 }
 
-func (m *MailService) SendMail(to, subject, body string) error {
-	err := m.Mailer.SendMail(to, subject, body)
+// Send delivers a message immediately. Prefer Enqueue unless the caller can report a failure
+// back to the user.
+func (m *MailService) Send(ctx context.Context, message *Message) error {
+	return m.Mailer.Send(ctx, message)
+}
+
+// SendMail sends a plain text email immediately.
+func (m *MailService) SendMail(ctx context.Context, to, subject, body string) error {
+	message := NewMessage(to, subject)
+	message.Text = body
+	return m.Send(ctx, message)
+}
+
+// Enqueue stores a message in the outbox to be delivered in the background. Called inside a
+// transaction, the message is only sent if the transaction commits.
+func (m *MailService) Enqueue(ctx context.Context, message *Message) (*OutboxMessage, error) {
+	return m.outbox.CreateOutboxMessage(ctx, &OutboxMessage{
+		Message:       *message,
+		Status:        OutboxStatusPending,
+		MaxAttempts:   m.opts.MaxAttempts,
+		NextAttemptAt: core.Now(),
+	})
+}
+
+const JobDeliverOutbox = "mail.deliver-outbox"
+
+// RegisterJobs schedules outbox delivery to run every interval.
+func (m *MailService) RegisterJobs(ctx context.Context, jobScheduler *scheduler.Scheduler, interval time.Duration) error {
+	jobScheduler.Register(JobDeliverOutbox, func(ctx context.Context, job *scheduler.Job) error {
+		return m.DeliverOutbox(ctx)
+	})
+
+	_, err := jobScheduler.Every(ctx, JobDeliverOutbox, interval, nil)
+	return err
+}
+
+// DeliverOutbox sends the outbox messages that are due. Failed messages are retried with
+// exponential backoff until they run out of attempts.
+func (m *MailService) DeliverOutbox(ctx context.Context) error {
+	messages, err := m.outbox.GetDueOutboxMessages(ctx, core.Now(), m.opts.BatchSize)
 	if err != nil {
 		return err
 	}
+
+	for _, message := range messages {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := m.deliver(ctx, &message); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
+
+func (m *MailService) deliver(ctx context.Context, message *OutboxMessage) error {
+	logger := log.Logger().With().Int64("outboxId", message.Id).Str("to", message.Message.To).Int("attempt", message.Attempts+1).Logger()
+
+	err := m.Mailer.Send(ctx, &message.Message)
+	if err == nil {
+		logger.Debug().Msg("Delivered outbox message")
+		return m.outbox.MarkOutboxMessageSent(ctx, message.Id, core.Now())
+	}
+
+	var retryAt *time.Time
+	if message.Attempts+1 < message.MaxAttempts && !errors.Is(err, ErrInvalidEmail) {
+		next := core.Now().Add(m.backoff(message.Attempts + 1))
+		retryAt = &next
+		logger.Warn().Err(err).Time("retryAt", next).Msg("Failed to deliver outbox message, retrying")
+	} else {
+		logger.Error().Err(err).Msg("Failed to deliver outbox message, giving up")
+	}
+
+	return m.outbox.FailOutboxMessage(ctx, message.Id, err.Error(), retryAt)
+}
+
+// backoff doubles the delay with every attempt, starting at BaseBackoff and capped at MaxBackoff.
+func (m *MailService) backoff(attempts int) time.Duration {
+	delay := m.opts.BaseBackoff
+	for i := 1; i < attempts && delay < m.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, m.opts.MaxBackoff)
+}
+
+func (m *MailService) GetOutboxMessages(ctx context.Context, statuses []OutboxStatus, limit int) ([]OutboxMessage, error) {
+	return m.outbox.GetOutboxMessages(ctx, statuses, limit)
+}
+
+func (m *MailService) RequeueOutboxMessage(ctx context.Context, messageId int64) error {
+	return m.outbox.RequeueOutboxMessage(ctx, messageId)
+}
+
+func (m *MailService) RequeueFailedOutboxMessages(ctx context.Context) (int64, error) {
+	return m.outbox.RequeueFailedOutboxMessages(ctx)
+}
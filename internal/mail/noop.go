@@ -0,0 +1,19 @@
+package mail
+
+import (
+	"context"
+
+	"github.com/CaribouBlue/mixtape/internal/log"
+)
+
+// NoopMailer discards every message.
+type NoopMailer struct{}
+
+func NewNoopMailer() *NoopMailer {
+	return &NoopMailer{}
+}
+
+func (m *NoopMailer) Send(ctx context.Context, message *Message) error {
+	log.Logger().Debug().Str("to", message.To).Str("subject", message.Subject).Msg("Discarded email")
+	return nil
+}
@@ -0,0 +1,48 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+type OutboxStatus string
+
+const (
+	OutboxStatusPending OutboxStatus = "pending"
+	OutboxStatusSent    OutboxStatus = "sent"
+	OutboxStatusFailed  OutboxStatus = "failed"
+)
+
+var (
+	ErrOutboxMessageNotFound = errors.New("outbox message not found")
+)
+
+// OutboxMessage is a message waiting to be delivered, or the record of one that was.
+type OutboxMessage struct {
+	Id            int64
+	Message       Message
+	Status        OutboxStatus
+	Attempts      int
+	MaxAttempts   int
+	NextAttemptAt time.Time
+	LastError     string
+	SentAt        *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type OutboxRepository interface {
+	CreateOutboxMessage(ctx context.Context, message *OutboxMessage) (*OutboxMessage, error)
+	// GetDueOutboxMessages returns up to limit pending messages whose next attempt is due.
+	GetDueOutboxMessages(ctx context.Context, now time.Time, limit int) ([]OutboxMessage, error)
+	MarkOutboxMessageSent(ctx context.Context, messageId int64, sentAt time.Time) error
+	// FailOutboxMessage records a failed attempt and reschedules the message at retryAt, or
+	// marks it as failed when retryAt is nil.
+	FailOutboxMessage(ctx context.Context, messageId int64, lastError string, retryAt *time.Time) error
+	// RequeueOutboxMessage resets a failed message so it is delivered as soon as possible.
+	RequeueOutboxMessage(ctx context.Context, messageId int64) error
+	// RequeueFailedOutboxMessages resets every failed message and returns how many there were.
+	RequeueFailedOutboxMessages(ctx context.Context) (int64, error)
+	GetOutboxMessages(ctx context.Context, statuses []OutboxStatus, limit int) ([]OutboxMessage, error)
+}
@@ -0,0 +1,85 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/wneessen/go-mail"
+)
+
+var (
+	ErrInvalidTLSPolicy = errors.New("invalid TLS policy")
+)
+
+type TLSPolicy string
+
+const (
+	// TLSMandatory requires upgrading the connection with STARTTLS.
+	TLSMandatory TLSPolicy = "mandatory"
+	// TLSOpportunistic uses STARTTLS when the server supports it.
+	TLSOpportunistic TLSPolicy = "opportunistic"
+	// TLSImplicit connects over TLS from the start, usually on port 465.
+	TLSImplicit TLSPolicy = "implicit"
+	TLSNone     TLSPolicy = "none"
+)
+
+type SmtpOpts struct {
+	Host string
+	Port int
+	// Username and Password are used for PLAIN authentication when Username is set.
+	Username  string
+	Password  string
+	From      string
+	TLSPolicy TLSPolicy
+}
+
+type SmtpMailer struct {
+	client *mail.Client
+	from   string
+}
+
+func NewSmtpMailer(opts SmtpOpts) (*SmtpMailer, error) {
+	clientOpts := []mail.Option{mail.WithPort(opts.Port)}
+
+	switch opts.TLSPolicy {
+	case TLSMandatory, "":
+		clientOpts = append(clientOpts, mail.WithTLSPolicy(mail.TLSMandatory))
+	case TLSOpportunistic:
+		clientOpts = append(clientOpts, mail.WithTLSPolicy(mail.TLSOpportunistic))
+	case TLSImplicit:
+		clientOpts = append(clientOpts, mail.WithSSL())
+	case TLSNone:
+		clientOpts = append(clientOpts, mail.WithTLSPolicy(mail.NoTLS))
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTLSPolicy, opts.TLSPolicy)
+	}
+
+	if opts.Username != "" {
+		clientOpts = append(clientOpts, mail.WithSMTPAuth(mail.SMTPAuthPlain), mail.WithUsername(opts.Username), mail.WithPassword(opts.Password))
+	}
+
+	client, err := mail.NewClient(opts.Host, clientOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	from := opts.From
+	if from == "" {
+		from = opts.Username
+	}
+
+	return &SmtpMailer{
+		client: client,
+		from:   from,
+	}, nil
+}
+
+func (m *SmtpMailer) Send(ctx context.Context, message *Message) error {
+	msg, err := message.build(m.from)
+	if err != nil {
+		return err
+	}
+
+	return m.client.DialAndSendWithContext(ctx, msg)
+}
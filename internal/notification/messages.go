@@ -0,0 +1,112 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/mail"
+	"github.com/CaribouBlue/mixtape/internal/templates"
+)
+
+func sessionUrl(baseUrl string, sessionId int64) string {
+	return fmt.Sprintf("%s/app/session/%d", baseUrl, sessionId)
+}
+
+// newMessage renders content as a multipart email to a session player, with headers that let
+// mail clients offer their own unsubscribe button.
+func newMessage(ctx context.Context, to string, subject string, content templates.EmailContent) (*mail.Message, error) {
+	message := mail.NewMessage(to, subject)
+	message.Text = content.PlainText()
+	if err := message.SetHtml(ctx, templates.EmailMessage(subject, content)); err != nil {
+		return nil, err
+	}
+
+	if content.UnsubscribeUrl != "" {
+		message.SetHeader("List-Unsubscribe", "<"+content.UnsubscribeUrl+">")
+		message.SetHeader("List-Unsubscribe-Post", "List-Unsubscribe=One-Click")
+	}
+
+	return message, nil
+}
+
+type phaseMessage struct {
+	User           *core.UserEntity
+	Session        *core.SessionEntity
+	SessionUrl     string
+	PlaylistUrl    string
+	UnsubscribeUrl string
+	Results        []core.ResultEntity
+}
+
+func (m *phaseMessage) Render(ctx context.Context, phase core.SessionPhase) (*mail.Message, error) {
+	var subject string
+	content := templates.EmailContent{
+		Greeting:       fmt.Sprintf("Hi %s,", m.User.DisplayName),
+		Footer:         fmt.Sprintf("You're receiving this because you joined %s on Mixtape.", m.Session.Name),
+		UnsubscribeUrl: m.UnsubscribeUrl,
+	}
+
+	switch phase {
+	case core.SubmissionPhase:
+		subject = fmt.Sprintf("Mixtape: %s is open for submissions", m.Session.Name)
+		content.Paragraphs = append(content.Paragraphs, fmt.Sprintf("%s is open! Submit up to %d tracks before %s.", m.Session.Name, m.Session.MaxSubmissions, formatTime(m.Session.PhaseStartAt(core.VotePhase))))
+		content.Links = append(content.Links, templates.EmailLink{Text: "Submit your tracks", Url: m.SessionUrl})
+	case core.VotePhase:
+		subject = fmt.Sprintf("Mixtape: voting has started in %s", m.Session.Name)
+		content.Paragraphs = append(content.Paragraphs, fmt.Sprintf("Submissions for %s are in. You have %d votes to cast before %s.", m.Session.Name, m.Session.MaxVotes(), formatTime(m.Session.PhaseStartAt(core.ResultPhase))))
+		if m.PlaylistUrl != "" {
+			content.Links = append(content.Links, templates.EmailLink{Text: "Listen to your playlist", Url: m.PlaylistUrl})
+		} else {
+			content.Paragraphs = append(content.Paragraphs, "Create a playlist of every submission from the session page.")
+		}
+		content.Links = append(content.Links, templates.EmailLink{Text: "Cast your votes", Url: m.SessionUrl})
+	case core.ResultPhase:
+		subject = fmt.Sprintf("Mixtape: results are in for %s", m.Session.Name)
+		content.Paragraphs = append(content.Paragraphs, fmt.Sprintf("Voting for %s has closed.", m.Session.Name))
+		for _, result := range m.Results {
+			if result.Place != 1 {
+				continue
+			}
+			content.Paragraphs = append(content.Paragraphs, fmt.Sprintf("Winner: %s, submitted by %s with %d votes", result.Track.Name, result.NominatorName, result.Votes))
+		}
+		content.Links = append(content.Links, templates.EmailLink{Text: "See the full results", Url: m.SessionUrl})
+	}
+
+	return newMessage(ctx, m.User.ContactEmail(), subject, content)
+}
+
+type reminderMessage struct {
+	User           *core.UserEntity
+	Session        *core.SessionEntity
+	SessionUrl     string
+	UnsubscribeUrl string
+	VotesRemaining int
+}
+
+func (m *reminderMessage) Render(ctx context.Context, phase core.SessionPhase) (*mail.Message, error) {
+	var subject string
+	content := templates.EmailContent{
+		Greeting:       fmt.Sprintf("Hi %s,", m.User.DisplayName),
+		Footer:         fmt.Sprintf("You're receiving this because you joined %s on Mixtape.", m.Session.Name),
+		UnsubscribeUrl: m.UnsubscribeUrl,
+	}
+
+	switch phase {
+	case core.SubmissionPhase:
+		subject = fmt.Sprintf("Mixtape: finalize your submissions for %s", m.Session.Name)
+		content.Paragraphs = append(content.Paragraphs, fmt.Sprintf("Submissions for %s close at %s and yours aren't finalized yet.", m.Session.Name, formatTime(m.Session.PhaseStartAt(core.VotePhase))))
+		content.Links = append(content.Links, templates.EmailLink{Text: "Finish your submissions", Url: m.SessionUrl})
+	case core.VotePhase:
+		subject = fmt.Sprintf("Mixtape: cast your votes for %s", m.Session.Name)
+		content.Paragraphs = append(content.Paragraphs, fmt.Sprintf("Voting for %s closes at %s and you have %d votes left to cast.", m.Session.Name, formatTime(m.Session.PhaseStartAt(core.ResultPhase)), m.VotesRemaining))
+		content.Links = append(content.Links, templates.EmailLink{Text: "Cast your votes", Url: m.SessionUrl})
+	}
+
+	return newMessage(ctx, m.User.ContactEmail(), subject, content)
+}
+
+func formatTime(t time.Time) string {
+	return t.Format("Mon Jan 2 15:04 MST")
+}
@@ -0,0 +1,264 @@
+package notification
+
+import (
+	"context"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/log"
+	"github.com/CaribouBlue/mixtape/internal/mail"
+	"github.com/CaribouBlue/mixtape/internal/scheduler"
+)
+
+type NotificationRepository interface {
+	IsSessionPhaseNotified(ctx context.Context, sessionId int64, phase core.SessionPhase) (bool, error)
+	// MarkSessionPhaseNotified records that a session's phase change has been handled and
+	// reports whether this call was the first to do so.
+	MarkSessionPhaseNotified(ctx context.Context, sessionId int64, phase core.SessionPhase) (bool, error)
+	// MarkReminderSent records that a player has been reminded about a phase's deadline and
+	// reports whether this call was the first to do so.
+	MarkReminderSent(ctx context.Context, sessionId int64, playerId int64, phase core.SessionPhase) (bool, error)
+}
+
+// MusicServiceProvider returns a music service acting on behalf of the given user.
+type MusicServiceProvider func(ctx context.Context, user *core.UserEntity) (*core.MusicService, error)
+
+type NotifierOpts struct {
+	BaseUrl string
+	// MaxDelay is how long after a phase begins its notification is still worth sending.
+	// Older phase changes, e.g. sessions that finished before notifications existed, are
+	// recorded without emailing anyone.
+	MaxDelay time.Duration
+	// ReminderLeadTime is how long before a phase ends unfinished players are reminded.
+	// Reminders are disabled when it is zero.
+	ReminderLeadTime time.Duration
+	// FreezeRetryDelay is how long to wait before trying to freeze a session's results again
+	// after a failed attempt.
+	FreezeRetryDelay time.Duration
+}
+
+type Notifier struct {
+	opts                 NotifierOpts
+	repository           NotificationRepository
+	transactor           core.Transactor
+	sessionRepository    core.SessionRepository
+	userService          *core.UserService
+	mailService          *mail.MailService
+	events               *core.EventBus
+	musicServiceProvider MusicServiceProvider
+}
+
+func NewNotifier(opts NotifierOpts, repository NotificationRepository, transactor core.Transactor, sessionRepository core.SessionRepository, userService *core.UserService, mailService *mail.MailService, events *core.EventBus, musicServiceProvider MusicServiceProvider) *Notifier {
+	return &Notifier{
+		opts:                 opts,
+		repository:           repository,
+		transactor:           transactor,
+		sessionRepository:    sessionRepository,
+		userService:          userService,
+		mailService:          mailService,
+		events:               events,
+		musicServiceProvider: musicServiceProvider,
+	}
+}
+
+const (
+	JobCheckPhaseChanges = "notification.check-phase-changes"
+	JobCheckReminders    = "notification.check-reminders"
+)
+
+// RegisterJobs schedules the phase change and reminder checks to run every interval.
+func (n *Notifier) RegisterJobs(ctx context.Context, jobScheduler *scheduler.Scheduler, interval time.Duration) error {
+	jobScheduler.Register(JobCheckPhaseChanges, func(ctx context.Context, job *scheduler.Job) error {
+		return n.CheckSessions(ctx)
+	})
+	jobScheduler.Register(JobCheckReminders, func(ctx context.Context, job *scheduler.Job) error {
+		return n.CheckReminders(ctx)
+	})
+
+	if _, err := jobScheduler.Every(ctx, JobCheckPhaseChanges, interval, nil); err != nil {
+		return err
+	}
+	if _, err := jobScheduler.Every(ctx, JobCheckReminders, interval, nil); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CheckSessions freezes the results of finished sessions that haven't been frozen yet, then queues
+// notifications and publishes a PhaseChangedEvent for every session whose current phase hasn't been
+// announced yet. A ResultsPublishedEvent follows once a session's results are frozen.
+func (n *Notifier) CheckSessions(ctx context.Context) error {
+	sessions, err := n.sessionRepository.GetAllSessions(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := core.Now()
+	for _, session := range *sessions {
+		phase := session.PhaseAt(now)
+
+		// Results are only frozen here, sessions show them as pending until then. A failed freeze
+		// is retried after FreezeRetryDelay, the phase change is announced without results meanwhile.
+		var results []core.ResultEntity
+		isFrozen := false
+		if n.shouldFreezeResults(&session, phase, now) {
+			results, err = n.freezeResults(ctx, &session)
+			if err != nil {
+				log.Logger().Warn().Err(err).Int64("sessionId", session.Id).Msg("Failed to freeze results")
+				if err := n.sessionRepository.MarkResultsFreezeFailed(ctx, session.Id, now); err != nil {
+					return err
+				}
+			} else {
+				isFrozen = true
+			}
+		}
+
+		isNotified, err := n.repository.IsSessionPhaseNotified(ctx, session.Id, phase)
+		if err != nil {
+			return err
+		} else if isNotified && !isFrozen {
+			continue
+		}
+
+		// Messages are rendered before the transaction so it isn't held open during music
+		// service calls.
+		isStale := now.Sub(session.PhaseStartAt(phase)) > n.opts.MaxDelay
+		var messages []*mail.Message
+		if isStale {
+			log.Logger().Debug().Int64("sessionId", session.Id).Str("phase", string(phase)).Msg("Skipping stale phase change notification")
+		} else if !isNotified {
+			messages, err = n.sessionPhaseMessages(ctx, &session, phase, results)
+			if err != nil {
+				log.Logger().Error().Err(err).Int64("sessionId", session.Id).Str("phase", string(phase)).Msg("Failed to prepare phase change notifications")
+				continue
+			}
+		}
+
+		err = n.transactor.InTransaction(ctx, func(ctx context.Context) error {
+			if !isNotified {
+				isFirst, err := n.repository.MarkSessionPhaseNotified(ctx, session.Id, phase)
+				if err != nil {
+					return err
+				}
+
+				if isFirst {
+					for _, message := range messages {
+						if _, err := n.mailService.Enqueue(ctx, message); err != nil {
+							return err
+						}
+					}
+
+					if !isStale {
+						if err := n.events.Publish(ctx, core.PhaseChangedEvent{Session: session, Phase: phase}); err != nil {
+							return err
+						}
+					}
+
+					log.Logger().Info().Int64("sessionId", session.Id).Str("phase", string(phase)).Int("emails", len(messages)).Msg("Queued phase change emails")
+				}
+			}
+
+			if isFrozen && !isStale {
+				return n.events.Publish(ctx, core.ResultsPublishedEvent{Session: session, Results: results})
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sessionPhaseMessages renders an email about the phase that just began for every reachable player
+// in the session.
+func (n *Notifier) sessionPhaseMessages(ctx context.Context, session *core.SessionEntity, phase core.SessionPhase, results []core.ResultEntity) ([]*mail.Message, error) {
+	players, err := n.sessionRepository.GetPlayers(ctx, session.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]*mail.Message, 0, len(*players))
+	for _, player := range *players {
+		user, err := n.userService.GetUserById(ctx, player.PlayerId)
+		if err != nil {
+			return nil, err
+		}
+
+		if !user.IsEmailReachable() {
+			continue
+		}
+
+		unsubscribeUrl, err := UnsubscribeUrl(n.opts.BaseUrl, user.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		message := phaseMessage{
+			User:           user,
+			Session:        session,
+			SessionUrl:     sessionUrl(n.opts.BaseUrl, session.Id),
+			UnsubscribeUrl: unsubscribeUrl,
+			Results:        results,
+		}
+		if phase == core.VotePhase {
+			message.PlaylistUrl = n.playlistUrl(ctx, user, &player)
+		}
+
+		email, err := message.Render(ctx, phase)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, email)
+	}
+
+	return messages, nil
+}
+
+// shouldFreezeResults reports whether the session's results are due to be frozen: the session is
+// in its result phase, the results aren't frozen yet and no attempt failed within FreezeRetryDelay.
+func (n *Notifier) shouldFreezeResults(session *core.SessionEntity, phase core.SessionPhase, now time.Time) bool {
+	if phase != core.ResultPhase || session.ResultsFrozenAt != nil {
+		return false
+	}
+	return session.ResultsFreezeFailedAt == nil || now.Sub(*session.ResultsFreezeFailedAt) >= n.opts.FreezeRetryDelay
+}
+
+// freezeResults stores the session's results snapshot using the session creator's music account
+// to look up track details.
+func (n *Notifier) freezeResults(ctx context.Context, session *core.SessionEntity) ([]core.ResultEntity, error) {
+	creator, err := n.userService.GetUserById(ctx, session.CreatedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	musicService, err := n.musicServiceProvider(ctx, creator)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionService := core.NewSessionService(n.sessionRepository, n.userService, nil, musicService)
+	return sessionService.FreezeSessionResults(ctx, session.Id)
+}
+
+func (n *Notifier) playlistUrl(ctx context.Context, user *core.UserEntity, player *core.PlayerEntity) string {
+	if player.PlaylistId == "" {
+		return ""
+	}
+
+	musicService, err := n.musicServiceProvider(ctx, user)
+	if err != nil {
+		log.Logger().Warn().Err(err).Int64("userId", user.Id).Msg("Failed to get music service for playlist link")
+		return ""
+	}
+
+	playlist, err := musicService.GetPlaylistById(ctx, player.PlaylistId)
+	if err != nil {
+		log.Logger().Warn().Err(err).Int64("userId", user.Id).Msg("Failed to get playlist for notification")
+		return ""
+	}
+
+	return playlist.Url
+}
@@ -0,0 +1,125 @@
+package notification
+
+import (
+	"context"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/log"
+)
+
+// CheckReminders emails players who haven't finished the current phase once it is within the
+// reminder lead time of ending. Each player is reminded at most once per phase.
+func (n *Notifier) CheckReminders(ctx context.Context) error {
+	if n.opts.ReminderLeadTime <= 0 {
+		return nil
+	}
+
+	sessions, err := n.sessionRepository.GetAllSessions(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := core.Now()
+	for _, session := range *sessions {
+		phase := session.PhaseAt(now)
+		if phase == core.ResultPhase {
+			continue
+		}
+
+		remaining := session.RemainingPhaseDuration()
+		if remaining <= 0 || remaining > n.opts.ReminderLeadTime {
+			continue
+		}
+
+		if err := n.remindPlayers(ctx, &session, phase); err != nil {
+			log.Logger().Error().Err(err).Int64("sessionId", session.Id).Str("phase", string(phase)).Msg("Failed to queue reminders")
+		}
+	}
+
+	return nil
+}
+
+func (n *Notifier) remindPlayers(ctx context.Context, session *core.SessionEntity, phase core.SessionPhase) error {
+	players, err := n.sessionRepository.GetPlayers(ctx, session.Id)
+	if err != nil {
+		return err
+	}
+
+	for _, player := range *players {
+		votesRemaining := 0
+		if phase == core.SubmissionPhase {
+			if player.IsSubmissionsFinalized {
+				continue
+			}
+		} else {
+			votesRemaining, err = n.votesRemaining(ctx, session, player.PlayerId)
+			if err != nil {
+				return err
+			} else if votesRemaining == 0 {
+				continue
+			}
+		}
+
+		user, err := n.userService.GetUserById(ctx, player.PlayerId)
+		if err != nil {
+			return err
+		}
+
+		if !user.IsEmailReachable() {
+			continue
+		}
+
+		unsubscribeUrl, err := UnsubscribeUrl(n.opts.BaseUrl, user.Id)
+		if err != nil {
+			return err
+		}
+
+		message := reminderMessage{
+			User:           user,
+			Session:        session,
+			SessionUrl:     sessionUrl(n.opts.BaseUrl, session.Id),
+			UnsubscribeUrl: unsubscribeUrl,
+			VotesRemaining: votesRemaining,
+		}
+
+		email, err := message.Render(ctx, phase)
+		if err != nil {
+			return err
+		}
+
+		err = n.transactor.InTransaction(ctx, func(ctx context.Context) error {
+			isFirst, err := n.repository.MarkReminderSent(ctx, session.Id, player.PlayerId, phase)
+			if err != nil || !isFirst {
+				return err
+			}
+
+			if _, err := n.mailService.Enqueue(ctx, email); err != nil {
+				return err
+			}
+
+			log.Logger().Info().Int64("userId", user.Id).Int64("sessionId", session.Id).Str("phase", string(phase)).Msg("Queued reminder email")
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// votesRemaining returns how many more votes a player can cast, capped by the number of
+// candidates they're allowed to vote for.
+func (n *Notifier) votesRemaining(ctx context.Context, session *core.SessionEntity, playerId int64) (int, error) {
+	ballot, err := n.sessionRepository.GetCandidateByNotUserId(ctx, session.Id, playerId)
+	if err != nil {
+		return 0, err
+	}
+
+	votes, err := n.sessionRepository.GetVotesByUserId(ctx, session.Id, playerId)
+	if err != nil {
+		return 0, err
+	}
+
+	return max(min(session.MaxVotes(), len(*ballot))-len(*votes), 0), nil
+}
@@ -0,0 +1,64 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+	netUrl "net/url"
+
+	"github.com/CaribouBlue/mixtape/internal/config"
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrInvalidUnsubscribeToken = errors.New("invalid unsubscribe token")
+)
+
+const unsubscribeTokenPurpose = "email-unsubscribe"
+
+// NewUnsubscribeToken signs a token that lets the holder change a user's email preference
+// without logging in. It doesn't expire so links in old emails keep working.
+func NewUnsubscribeToken(userId int64) (string, error) {
+	secretKey := config.GetConfigValue(config.ConfJwtSecret)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"userId":  userId,
+		"purpose": unsubscribeTokenPurpose,
+	})
+
+	return token.SignedString([]byte(secretKey))
+}
+
+func ParseUnsubscribeToken(tokenString string) (int64, error) {
+	secretKey := config.GetConfigValue(config.ConfJwtSecret)
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(secretKey), nil
+	})
+	if err != nil {
+		return 0, ErrInvalidUnsubscribeToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != unsubscribeTokenPurpose {
+		return 0, ErrInvalidUnsubscribeToken
+	}
+
+	userId, ok := claims["userId"].(float64)
+	if !ok {
+		return 0, ErrInvalidUnsubscribeToken
+	}
+
+	return int64(userId), nil
+}
+
+func UnsubscribeUrl(baseUrl string, userId int64) (string, error) {
+	token, err := NewUnsubscribeToken(userId)
+	if err != nil {
+		return "", err
+	}
+
+	return baseUrl + "/auth/email/unsubscribe?" + netUrl.Values{"token": {token}}.Encode(), nil
+}
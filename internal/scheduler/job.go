@@ -0,0 +1,101 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+)
+
+type JobStatus string
+
+const (
+	JobStatusPending   JobStatus = "pending"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusSucceeded JobStatus = "succeeded"
+	JobStatusFailed    JobStatus = "failed"
+)
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrNoHandler   = errors.New("no handler registered for job")
+)
+
+type Job struct {
+	Id          int64
+	Name        string
+	Payload     []byte
+	Status      JobStatus
+	RunAt       time.Time
+	Attempts    int
+	MaxAttempts int
+	// Interval reschedules the job after each run when greater than zero.
+	Interval    time.Duration
+	LastError   string
+	LockedUntil time.Time
+	// UniqueKey prevents a job from being enqueued more than once, e.g. for recurring jobs
+	// registered on every start up.
+	UniqueKey string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (j *Job) IsRecurring() bool {
+	return j.Interval > 0
+}
+
+// Decode unmarshals the job's JSON payload into v.
+func (j *Job) Decode(v any) error {
+	if len(j.Payload) == 0 {
+		return nil
+	}
+	return json.Unmarshal(j.Payload, v)
+}
+
+type JobOption func(*Job)
+
+func WithRunAt(runAt time.Time) JobOption {
+	return func(job *Job) {
+		job.RunAt = runAt
+	}
+}
+
+func WithDelay(delay time.Duration) JobOption {
+	return func(job *Job) {
+		job.RunAt = core.Now().Add(delay)
+	}
+}
+
+func WithMaxAttempts(maxAttempts int) JobOption {
+	return func(job *Job) {
+		job.MaxAttempts = maxAttempts
+	}
+}
+
+func WithUniqueKey(uniqueKey string) JobOption {
+	return func(job *Job) {
+		job.UniqueKey = uniqueKey
+	}
+}
+
+type JobRepository interface {
+	// CreateJob inserts a job. If a job with the same unique key exists, its interval is
+	// updated and the existing job is returned instead.
+	CreateJob(ctx context.Context, job *Job) (*Job, error)
+	// ClaimDueJobs leases up to limit jobs that are due, or whose lease has expired, and
+	// increments their attempt count.
+	ClaimDueJobs(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) ([]Job, error)
+	// CompleteJob marks a job as succeeded, or reschedules it at nextRunAt when set.
+	CompleteJob(ctx context.Context, jobId int64, nextRunAt *time.Time) error
+	// FailJob records a failed attempt and reschedules the job at retryAt, or marks it as
+	// failed when retryAt is nil. resetAttempts starts the attempts over, for a recurring job
+	// rescheduled for its next run.
+	FailJob(ctx context.Context, jobId int64, lastError string, retryAt *time.Time, resetAttempts bool) error
+	// RetryJob resets a job so it runs again as soon as possible.
+	RetryJob(ctx context.Context, jobId int64) error
+	GetJobs(ctx context.Context, statuses []JobStatus, limit int) ([]Job, error)
+}
+
+type Handler func(ctx context.Context, job *Job) error
@@ -0,0 +1,297 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/log"
+)
+
+type SchedulerOpts struct {
+	PollInterval time.Duration
+	// LeaseDuration is how long a claimed job may run before another worker may claim it
+	// again. Job handlers are canceled when it runs out.
+	LeaseDuration      time.Duration
+	Concurrency        int
+	DefaultMaxAttempts int
+	BaseBackoff        time.Duration
+	MaxBackoff         time.Duration
+}
+
+type SchedulerOption func(*SchedulerOpts)
+
+func WithPollInterval(pollInterval time.Duration) SchedulerOption {
+	return func(opts *SchedulerOpts) {
+		opts.PollInterval = pollInterval
+	}
+}
+
+func WithLeaseDuration(leaseDuration time.Duration) SchedulerOption {
+	return func(opts *SchedulerOpts) {
+		opts.LeaseDuration = leaseDuration
+	}
+}
+
+func WithConcurrency(concurrency int) SchedulerOption {
+	return func(opts *SchedulerOpts) {
+		opts.Concurrency = concurrency
+	}
+}
+
+func WithDefaultMaxAttempts(maxAttempts int) SchedulerOption {
+	return func(opts *SchedulerOpts) {
+		opts.DefaultMaxAttempts = maxAttempts
+	}
+}
+
+func WithBackoff(base, max time.Duration) SchedulerOption {
+	return func(opts *SchedulerOpts) {
+		opts.BaseBackoff = base
+		opts.MaxBackoff = max
+	}
+}
+
+// Scheduler runs jobs stored by a JobRepository. Jobs are leased while they run so a job
+// interrupted by a crash or restart is picked up again once its lease expires, which makes
+// execution at-least-once: handlers should be safe to run more than once.
+type Scheduler struct {
+	opts       SchedulerOpts
+	repository JobRepository
+
+	handlersMu sync.RWMutex
+	handlers   map[string]Handler
+
+	slots   chan struct{}
+	running sync.WaitGroup
+	stop    context.CancelFunc
+	done    chan struct{}
+}
+
+func NewScheduler(repository JobRepository, options ...SchedulerOption) *Scheduler {
+	opts := SchedulerOpts{
+		PollInterval:       time.Second,
+		LeaseDuration:      5 * time.Minute,
+		Concurrency:        4,
+		DefaultMaxAttempts: 5,
+		BaseBackoff:        10 * time.Second,
+		MaxBackoff:         time.Hour,
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	return &Scheduler{
+		opts:       opts,
+		repository: repository,
+		handlers:   make(map[string]Handler),
+		slots:      make(chan struct{}, max(opts.Concurrency, 1)),
+	}
+}
+
+// Register sets the handler for jobs with the given name.
+func (s *Scheduler) Register(name string, handler Handler) {
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+	s.handlers[name] = handler
+}
+
+func (s *Scheduler) handler(name string) (Handler, bool) {
+	s.handlersMu.RLock()
+	defer s.handlersMu.RUnlock()
+	handler, ok := s.handlers[name]
+	return handler, ok
+}
+
+// Enqueue stores a job to run once, immediately unless WithRunAt or WithDelay is given.
+func (s *Scheduler) Enqueue(ctx context.Context, name string, payload any, options ...JobOption) (*Job, error) {
+	return s.enqueue(ctx, name, payload, 0, options...)
+}
+
+// Every stores a recurring job that first runs immediately and then every interval after each
+// run finishes. It is keyed by name so calling it on every start up schedules the job once.
+func (s *Scheduler) Every(ctx context.Context, name string, interval time.Duration, payload any, options ...JobOption) (*Job, error) {
+	options = append([]JobOption{WithUniqueKey("every:" + name)}, options...)
+	return s.enqueue(ctx, name, payload, interval, options...)
+}
+
+func (s *Scheduler) enqueue(ctx context.Context, name string, payload any, interval time.Duration, options ...JobOption) (*Job, error) {
+	var data []byte
+	if payload != nil {
+		var err error
+		data, err = json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	job := &Job{
+		Name:        name,
+		Payload:     data,
+		Status:      JobStatusPending,
+		RunAt:       core.Now(),
+		MaxAttempts: s.opts.DefaultMaxAttempts,
+		Interval:    interval,
+	}
+	for _, opt := range options {
+		opt(job)
+	}
+
+	return s.repository.CreateJob(ctx, job)
+}
+
+// Start polls for due jobs in the background until Shutdown is called.
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stop = cancel
+	s.done = make(chan struct{})
+
+	go s.poll(ctx)
+}
+
+// Shutdown stops claiming new jobs and waits for running jobs to finish. Jobs still running
+// when ctx ends are canceled and retried after their lease expires.
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	if s.stop == nil {
+		return nil
+	}
+
+	s.stop()
+	<-s.done
+
+	finished := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		log.Logger().Info().Msg("Scheduler stopped")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) poll(ctx context.Context) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.runDueJobs(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) runDueJobs(ctx context.Context) {
+	available := cap(s.slots) - len(s.slots)
+	if available == 0 {
+		return
+	}
+
+	now := core.Now()
+	jobs, err := s.repository.ClaimDueJobs(ctx, now, now.Add(s.opts.LeaseDuration), available)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Logger().Error().Err(err).Msg("Failed to claim due jobs")
+		}
+		return
+	}
+
+	for _, job := range jobs {
+		s.slots <- struct{}{}
+		s.running.Add(1)
+		go func(job Job) {
+			defer func() {
+				<-s.slots
+				s.running.Done()
+			}()
+			s.run(&job)
+		}(job)
+	}
+}
+
+func (s *Scheduler) run(job *Job) {
+	logger := log.Logger().With().Int64("jobId", job.Id).Str("job", job.Name).Int("attempt", job.Attempts).Logger()
+
+	// Handlers get their own context so a shutdown lets in-flight jobs finish.
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.LeaseDuration)
+	defer cancel()
+
+	err := s.execute(ctx, job)
+	if err == nil {
+		var nextRunAt *time.Time
+		if job.IsRecurring() {
+			next := core.Now().Add(job.Interval)
+			nextRunAt = &next
+		}
+
+		if err := s.repository.CompleteJob(context.Background(), job.Id, nextRunAt); err != nil {
+			logger.Error().Err(err).Msg("Failed to complete job")
+		}
+		return
+	}
+
+	var retryAt *time.Time
+	resetAttempts := false
+	if job.Attempts < job.MaxAttempts {
+		next := core.Now().Add(s.backoff(job.Attempts))
+		retryAt = &next
+		logger.Warn().Err(err).Time("retryAt", next).Msg("Job failed, retrying")
+	} else if job.IsRecurring() {
+		// A recurring job that keeps failing waits for its next run instead of stopping for good.
+		next := core.Now().Add(job.Interval)
+		retryAt = &next
+		resetAttempts = true
+		logger.Error().Err(err).Time("nextRunAt", next).Msg("Recurring job failed on every attempt")
+	} else {
+		logger.Error().Err(err).Msg("Job failed on every attempt")
+	}
+
+	if err := s.repository.FailJob(context.Background(), job.Id, err.Error(), retryAt, resetAttempts); err != nil {
+		logger.Error().Err(err).Msg("Failed to record job failure")
+	}
+}
+
+func (s *Scheduler) execute(ctx context.Context, job *Job) (err error) {
+	handler, ok := s.handler(job.Name)
+	if !ok {
+		return ErrNoHandler
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	return handler(ctx, job)
+}
+
+// backoff doubles the delay with every attempt, starting at BaseBackoff and capped at MaxBackoff.
+func (s *Scheduler) backoff(attempts int) time.Duration {
+	delay := s.opts.BaseBackoff
+	for i := 1; i < attempts && delay < s.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, s.opts.MaxBackoff)
+}
+
+func (s *Scheduler) GetJobs(ctx context.Context, statuses []JobStatus, limit int) ([]Job, error) {
+	return s.repository.GetJobs(ctx, statuses, limit)
+}
+
+func (s *Scheduler) RetryJob(ctx context.Context, jobId int64) error {
+	return s.repository.RetryJob(ctx, jobId)
+}
@@ -1,13 +1,17 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
 	"slices"
+	"strings"
 	"time"
 
+	"github.com/CaribouBlue/mixtape/internal/accesstoken"
 	"github.com/CaribouBlue/mixtape/internal/core"
 	"github.com/CaribouBlue/mixtape/internal/log"
 	"github.com/CaribouBlue/mixtape/internal/log/rlog"
+	"github.com/CaribouBlue/mixtape/internal/loginsession"
 	"github.com/CaribouBlue/mixtape/internal/server/response"
 	"github.com/CaribouBlue/mixtape/internal/server/utils"
 	"github.com/CaribouBlue/mixtape/internal/spotify"
@@ -41,6 +45,11 @@ func (w *wrappedLoggerWriter) WriteHeader(statusCode int) {
 	w.ResponseWriter.WriteHeader(statusCode)
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to flush event streams.
+func (w *wrappedLoggerWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 func WithRequestLogging() Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -48,6 +57,14 @@ func WithRequestLogging() Middleware {
 
 			next.ServeHTTP(wrappedWriter, r)
 
+			if err := r.Context().Err(); err != nil {
+				rlog.Logger(r).Warn().
+					Err(err).
+					Int("status", wrappedWriter.statusCode).
+					Msg("Request canceled before completion")
+				return
+			}
+
 			var logger *zerolog.Event
 			if wrappedWriter.statusCode >= 500 {
 				logger = rlog.Logger(r).Error()
@@ -64,12 +81,14 @@ func WithRequestLogging() Middleware {
 
 type wrappedCustomNotFoundWriter struct {
 	http.ResponseWriter
-	statusCode int
+	isNotFound bool
 }
 
+// WriteHeader holds back 404s so the custom handler can answer instead. JSON responses are let
+// through since API clients expect their structured errors.
 func (w *wrappedCustomNotFoundWriter) WriteHeader(statusCode int) {
-	w.statusCode = statusCode
-	if w.statusCode == http.StatusNotFound {
+	if statusCode == http.StatusNotFound && w.Header().Get("Content-Type") != "application/json" {
+		w.isNotFound = true
 		return
 	}
 
@@ -77,21 +96,25 @@ func (w *wrappedCustomNotFoundWriter) WriteHeader(statusCode int) {
 }
 
 func (w *wrappedCustomNotFoundWriter) Write(b []byte) (int, error) {
-	if w.statusCode == http.StatusNotFound {
+	if w.isNotFound {
 		return len(b), nil
 	}
 
 	return w.ResponseWriter.Write(b)
 }
 
+func (w *wrappedCustomNotFoundWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 func WithCustomNotFoundHandler(notFoundHandler http.Handler) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			wrappedWriter := &wrappedCustomNotFoundWriter{w, http.StatusOK}
+			wrappedWriter := &wrappedCustomNotFoundWriter{w, false}
 
 			next.ServeHTTP(wrappedWriter, r)
 
-			if wrappedWriter.statusCode == http.StatusNotFound {
+			if wrappedWriter.isNotFound {
 				notFoundHandler.ServeHTTP(w, r)
 			}
 		})
@@ -135,9 +158,13 @@ func WithRequestMetadata() Middleware {
 }
 
 type WithUserOpts struct {
-	UserService *core.UserService
+	UserService         *core.UserService
+	LoginSessionService *loginsession.LoginSessionService
 }
 
+// WithUser loads the user logged in with the auth cookie. The cookie's login session is checked on
+// every request, so revoked sessions are logged out right away, and the cookie is renewed whenever
+// the session is extended.
 func WithUser(opts WithUserOpts) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -150,16 +177,35 @@ func WithUser(opts WithUserOpts) Middleware {
 			}
 
 			ctxUser := &core.UserEntity{}
-			authCookieUser, err := utils.ParseAuthCookie(w, r)
+			claims, err := utils.ParseAuthCookie(w, r)
 			if err == nil {
-				storedUser, err := opts.UserService.GetUserById(authCookieUser.Id)
-				if err == nil {
-					ctxUser = storedUser
-				} else if err != core.ErrUserNotFound {
-					log.Logger().Error().Err(err).Msg("Failed to get user by ID")
+				session, isExtended, err := opts.LoginSessionService.Authenticate(ctx, claims.SessionKey)
+				if err == loginsession.ErrInvalidLoginSession || (err == nil && session.UserId != claims.UserId) {
+					utils.DeleteCookie(w, r, utils.CookieNameAuthorization)
+					session = nil
+				} else if err != nil {
+					log.Logger().Error().Err(err).Msg("Failed to check login session")
 					http.Error(w, "Failed to get user", http.StatusInternalServerError)
 					return
 				}
+
+				if session != nil {
+					storedUser, err := opts.UserService.GetUserById(ctx, session.UserId)
+					if err == nil {
+						ctxUser = storedUser
+						ctx = utils.SetContextValue(ctx, utils.LoginSessionCtxKey, session)
+					} else if err != core.ErrUserNotFound {
+						log.Logger().Error().Err(err).Msg("Failed to get user by ID")
+						http.Error(w, "Failed to get user", http.StatusInternalServerError)
+						return
+					}
+
+					if isExtended {
+						if err := utils.SetAuthCookie(w, session); err != nil {
+							log.Logger().Error().Err(err).Msg("Failed to renew auth cookie")
+						}
+					}
+				}
 			}
 
 			ctx = utils.SetContextValue(ctx, utils.UserCtxKey, ctxUser)
@@ -169,6 +215,88 @@ func WithUser(opts WithUserOpts) Middleware {
 	}
 }
 
+type WithAccessTokenOpts struct {
+	AccessTokenService *accesstoken.AccessTokenService
+	UserService        *core.UserService
+}
+
+// WithAccessToken authenticates requests carrying a personal access token in an
+// `Authorization: Bearer` header, in place of any user from the auth cookie. A bad token is
+// rejected instead of falling back to an anonymous user so clients can tell what went wrong.
+func WithAccessToken(opts WithAccessTokenOpts) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			scheme, secret, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+			if !strings.EqualFold(scheme, "Bearer") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token, err := opts.AccessTokenService.Authenticate(ctx, strings.TrimSpace(secret))
+			if err == accesstoken.ErrInvalidToken {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				response.HandleJsonErrorResponse(w, r, http.StatusUnauthorized, "invalid_token", "The access token is invalid, expired or revoked", err)
+				return
+			} else if err != nil {
+				response.HandleJsonErrorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to check access token", err)
+				return
+			}
+
+			user, err := opts.UserService.GetUserById(ctx, token.UserId)
+			if err == core.ErrUserNotFound {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				response.HandleJsonErrorResponse(w, r, http.StatusUnauthorized, "invalid_token", "The access token is invalid, expired or revoked", err)
+				return
+			} else if err != nil {
+				response.HandleJsonErrorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to get user", err)
+				return
+			}
+
+			ctx = utils.SetContextValue(ctx, utils.UserCtxKey, user)
+			ctx = utils.SetContextValue(ctx, utils.AccessTokenCtxKey, token)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// WithRequiredScope turns away requests made with an access token that wasn't granted the scope.
+// Requests authenticated by the auth cookie aren't limited by scopes.
+func WithRequiredScope(scope accesstoken.Scope) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, err := utils.ContextValue(r.Context(), utils.AccessTokenCtxKey)
+			if err == nil && token != nil && !token.HasScope(scope) {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, scope))
+				response.HandleJsonErrorResponse(w, r, http.StatusForbidden, "insufficient_scope", fmt.Sprintf("The access token needs the %s scope", scope), nil)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// WithSameOriginCookieWrites turns away changes made with the auth cookie unless the request comes
+// from the app's own origin. Browsers attach the cookie to requests other sites trigger, so only
+// requests authenticated by an access token are accepted from anywhere.
+func WithSameOriginCookieWrites(origin string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			isSafeMethod := r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions
+			token, err := utils.ContextValue(r.Context(), utils.AccessTokenCtxKey)
+			if isSafeMethod || (err == nil && token != nil) || (origin != "" && r.Header.Get("Origin") == origin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			response.HandleJsonErrorResponse(w, r, http.StatusForbidden, "cross_origin_request", "Changes made with the auth cookie must come from the app, use an access token instead", nil)
+		})
+	}
+}
+
 func WithSpotifyClient() Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -179,7 +307,7 @@ func WithSpotifyClient() Middleware {
 			// TODO: handle token updates/invalidation
 			user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
 			if err == nil && user != nil && user.SpotifyToken != "" {
-				_, err := spotifyClient.Reauthenticate(user.SpotifyToken)
+				_, err := spotifyClient.Reauthenticate(ctx, user.SpotifyToken)
 				if err != nil {
 					log.Logger().Error().Err(err).Msg("Failed to reauthenticate Spotify client")
 				}
@@ -195,7 +323,10 @@ func WithSpotifyClient() Middleware {
 
 type WithEnforcedAuthenticationOpts struct {
 	UnauthenticatedRedirectPath string
-	UserService                 *core.UserService
+	// UnauthenticatedHandler answers unauthenticated requests instead of redirecting them, e.g.
+	// for API clients.
+	UnauthenticatedHandler http.Handler
+	UserService            *core.UserService
 }
 
 func WithEnforcedAuthentication(opts WithEnforcedAuthenticationOpts) Middleware {
@@ -216,6 +347,10 @@ func WithEnforcedAuthentication(opts WithEnforcedAuthenticationOpts) Middleware
 			}
 
 			if !isAuthenticated {
+				if opts.UnauthenticatedHandler != nil {
+					opts.UnauthenticatedHandler.ServeHTTP(w, r)
+					return
+				}
 				response.HandleRedirect(w, r, opts.UnauthenticatedRedirectPath)
 				return
 			}
@@ -224,3 +359,28 @@ func WithEnforcedAuthentication(opts WithEnforcedAuthenticationOpts) Middleware
 		})
 	}
 }
+
+type WithEnforcedAdminOpts struct {
+	ForbiddenRedirectPath string
+}
+
+// WithEnforcedAdmin only lets admins through and must be applied after WithUser.
+func WithEnforcedAdmin(opts WithEnforcedAdminOpts) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			u, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+			if err != nil || u == nil {
+				http.Error(w, "User not found in context, may need to apply WithUser middleware", http.StatusInternalServerError)
+				return
+			}
+
+			if !u.IsAdmin {
+				rlog.Logger(r).Warn().Int64("userId", u.Id).Msg("Non-admin user denied access to admin route")
+				response.HandleRedirect(w, r, opts.ForbiddenRedirectPath)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,613 @@
+package mux
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/featureflag"
+	"github.com/CaribouBlue/mixtape/internal/invitation"
+	mlog "github.com/CaribouBlue/mixtape/internal/log"
+	"github.com/CaribouBlue/mixtape/internal/log/rlog"
+	"github.com/CaribouBlue/mixtape/internal/loginlimit"
+	"github.com/CaribouBlue/mixtape/internal/scheduler"
+	"github.com/CaribouBlue/mixtape/internal/server/middleware"
+	"github.com/CaribouBlue/mixtape/internal/server/response"
+	"github.com/CaribouBlue/mixtape/internal/server/utils"
+	"github.com/CaribouBlue/mixtape/internal/templates"
+	"github.com/CaribouBlue/mixtape/internal/webhook"
+)
+
+type AdminMux struct {
+	Mux[AdminMuxOpts, AdminMuxServices]
+}
+
+func (mux *AdminMux) Opts() MuxOpts {
+	return mux.opts.MuxOpts
+}
+
+type AdminMuxOpts struct {
+	MuxOpts
+	BaseUrl string
+}
+
+type AdminMuxServices struct {
+	MuxServices
+	Scheduler          *scheduler.Scheduler
+	WebhookService     *webhook.WebhookService
+	UserService        *core.UserService
+	SessionService     *core.SessionService
+	FeatureFlagService *featureflag.FeatureFlagService
+	RecentErrors       *mlog.RecentErrors
+	InvitationService  *invitation.InvitationService
+	LoginLimiter       *loginlimit.Limiter
+}
+
+func NewAdminMux(opts AdminMuxOpts, services AdminMuxServices, middleware []middleware.Middleware, children []ChildMux) *AdminMux {
+	mux := &AdminMux{
+		*NewMux(
+			opts,
+			services,
+			children,
+			middleware,
+		),
+	}
+
+	mux.Handle("GET /{$}", http.HandlerFunc(mux.handleDashboardPage))
+
+	mux.Handle("POST /users/{userId}/admin", http.HandlerFunc(mux.handleSetUserAdmin))
+	mux.Handle("POST /users/{userId}/password", http.HandlerFunc(mux.handleResetUserPassword))
+
+	mux.Handle("POST /sessions/{sessionId}/phase", http.HandlerFunc(mux.handleForceSessionPhase))
+	mux.Handle("DELETE /sessions/{sessionId}", http.HandlerFunc(mux.handleDeleteSession))
+
+	mux.Handle("GET /jobs", http.HandlerFunc(mux.handleJobsPage))
+	mux.Handle("POST /jobs/{jobId}/retry", http.HandlerFunc(mux.handleRetryJob))
+
+	mux.Handle("GET /invitations", http.HandlerFunc(mux.handleInvitationsPage))
+	mux.Handle("POST /invitations/{invitationId}/revoke", http.HandlerFunc(mux.handleRevokeInvitation))
+
+	mux.Handle("GET /lockouts", http.HandlerFunc(mux.handleLockoutsPage))
+	mux.Handle("POST /lockouts/unlock", http.HandlerFunc(mux.handleUnlockLogin))
+
+	mux.Handle("GET /webhooks", http.HandlerFunc(mux.handleWebhooksPage))
+	mux.Handle("POST /webhooks", http.HandlerFunc(mux.handleCreateWebhook))
+	mux.Handle("GET /webhooks/{webhookId}", http.HandlerFunc(mux.handleWebhookPage))
+	mux.Handle("DELETE /webhooks/{webhookId}", http.HandlerFunc(mux.handleDeleteWebhook))
+	mux.Handle("POST /webhooks/{webhookId}/active", http.HandlerFunc(mux.handleSetWebhookActive))
+	mux.Handle("POST /webhooks/{webhookId}/test", http.HandlerFunc(mux.handleTestWebhook))
+	mux.Handle("POST /webhooks/{webhookId}/deliveries/{deliveryId}/redeliver", http.HandlerFunc(mux.handleRedeliverWebhook))
+
+	return mux
+}
+
+const adminDashboardJobsLimit = 10
+
+func (mux *AdminMux) handleDashboardPage(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil {
+		response.HandleErrorResponse(w, "Could not get user data", http.StatusUnauthorized, r, err)
+		return
+	}
+
+	users, err := mux.Services.UserService.GetAllUsers(r.Context())
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to get users", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	sessions, err := mux.Services.SessionService.GetAllSessions(r.Context())
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to get sessions", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	featureFlags, err := mux.Services.FeatureFlagService.GetAllFeatureFlags()
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to get feature flags", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	pending, err := mux.Services.Scheduler.GetJobs(r.Context(), []scheduler.JobStatus{scheduler.JobStatusPending, scheduler.JobStatusRunning}, adminDashboardJobsLimit)
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to get pending jobs", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	failed, err := mux.Services.Scheduler.GetJobs(r.Context(), []scheduler.JobStatus{scheduler.JobStatusFailed}, adminDashboardJobsLimit)
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to get failed jobs", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	usernames := make(map[int64]string, len(*users))
+	for _, u := range *users {
+		usernames[u.Id] = u.Username
+	}
+
+	response.HandleHtmlResponse(r, w, templates.AdminDashboardPage(templates.AdminDashboardProps{
+		CurrentUser:  *user,
+		Users:        *users,
+		Sessions:     *sessions,
+		Usernames:    usernames,
+		FeatureFlags: featureFlags,
+		PendingJobs:  pending,
+		FailedJobs:   failed,
+		RecentErrors: mux.Services.RecentErrors.Entries(),
+	}))
+}
+
+func (mux *AdminMux) handleSetUserAdmin(w http.ResponseWriter, r *http.Request) {
+	currentUser, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil {
+		response.HandleErrorResponse(w, "Could not get user data", http.StatusUnauthorized, r, err)
+		return
+	}
+
+	userId, err := strconv.ParseInt(r.PathValue("userId"), 10, 64)
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid user ID", http.StatusBadRequest, r, err)
+		return
+	}
+
+	isAdmin, err := strconv.ParseBool(r.FormValue("isAdmin"))
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid admin value", http.StatusBadRequest, r, err)
+		return
+	}
+
+	// Demoting yourself could leave nobody able to get back in here.
+	if userId == currentUser.Id && !isAdmin {
+		response.HandleErrorResponse(w, "You can't remove your own admin rights", http.StatusUnprocessableEntity, r, nil)
+		return
+	}
+
+	err = mux.Services.UserService.SetAdmin(r.Context(), userId, isAdmin)
+	if err == core.ErrUserNotFound {
+		response.HandleErrorResponse(w, "User not found", http.StatusNotFound, r, err)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to update user", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	user, err := mux.Services.UserService.GetUserById(r.Context(), userId)
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to get user", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	rlog.Logger(r).Info().Int64("userId", userId).Bool("isAdmin", isAdmin).Msg("User admin rights changed")
+
+	response.HandleHtmlResponse(r, w, templates.AdminUserRow(*user, currentUser.Id, ""))
+}
+
+func (mux *AdminMux) handleResetUserPassword(w http.ResponseWriter, r *http.Request) {
+	currentUser, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil {
+		response.HandleErrorResponse(w, "Could not get user data", http.StatusUnauthorized, r, err)
+		return
+	}
+
+	userId, err := strconv.ParseInt(r.PathValue("userId"), 10, 64)
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid user ID", http.StatusBadRequest, r, err)
+		return
+	}
+
+	user, err := mux.Services.UserService.GetUserById(r.Context(), userId)
+	if err == core.ErrUserNotFound {
+		response.HandleErrorResponse(w, "User not found", http.StatusNotFound, r, err)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to get user", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	password, err := core.GeneratePassword()
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to generate password", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	err = mux.Services.UserService.SetPassword(r.Context(), user.Id, password, password)
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to reset password", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	rlog.Logger(r).Info().Int64("userId", user.Id).Msg("User password reset by admin")
+
+	// The response carries the new password.
+	w.Header().Set("Cache-Control", "no-store")
+	response.HandleHtmlResponse(r, w, templates.AdminUserRow(*user, currentUser.Id, password))
+}
+
+func (mux *AdminMux) handleForceSessionPhase(w http.ResponseWriter, r *http.Request) {
+	sessionId, err := strconv.ParseInt(r.PathValue("sessionId"), 10, 64)
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid session ID", http.StatusBadRequest, r, err)
+		return
+	}
+
+	phase := core.SessionPhase(r.FormValue("phase"))
+
+	session, err := mux.Services.SessionService.FastForwardSession(r.Context(), sessionId, phase)
+	if err == core.ErrInvalidFastForwardPhase || err == core.ErrSessionPhaseAlreadyReached {
+		response.HandleErrorResponse(w, err.Error(), http.StatusUnprocessableEntity, r, err)
+		return
+	} else if err == core.ErrSessionNotFound {
+		response.HandleErrorResponse(w, "Session not found", http.StatusNotFound, r, err)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to change session phase", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	creator, err := mux.Services.UserService.GetUserById(r.Context(), session.CreatedBy)
+	if err != nil && err != core.ErrUserNotFound {
+		response.HandleErrorResponse(w, "Failed to get session creator", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	creatorName := ""
+	if creator != nil {
+		creatorName = creator.Username
+	}
+
+	rlog.Logger(r).Info().Int64("sessionId", sessionId).Str("phase", string(phase)).Msg("Session phase forced by admin")
+
+	response.HandleHtmlResponse(r, w, templates.AdminSessionRow(*session, creatorName))
+}
+
+func (mux *AdminMux) handleDeleteSession(w http.ResponseWriter, r *http.Request) {
+	sessionId, err := strconv.ParseInt(r.PathValue("sessionId"), 10, 64)
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid session ID", http.StatusBadRequest, r, err)
+		return
+	}
+
+	err = mux.Services.SessionService.DeleteSession(r.Context(), sessionId)
+	if err == core.ErrSessionNotFound {
+		response.HandleErrorResponse(w, "Session not found", http.StatusNotFound, r, err)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to delete session", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	rlog.Logger(r).Info().Int64("sessionId", sessionId).Msg("Session deleted by admin")
+
+	// An empty response removes the session's row.
+	w.WriteHeader(http.StatusOK)
+}
+
+const adminJobsPageLimit = 100
+
+func (mux *AdminMux) handleJobsPage(w http.ResponseWriter, r *http.Request) {
+	pending, err := mux.Services.Scheduler.GetJobs(r.Context(), []scheduler.JobStatus{scheduler.JobStatusPending, scheduler.JobStatusRunning}, adminJobsPageLimit)
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to get pending jobs", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	failed, err := mux.Services.Scheduler.GetJobs(r.Context(), []scheduler.JobStatus{scheduler.JobStatusFailed}, adminJobsPageLimit)
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to get failed jobs", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	response.HandleHtmlResponse(r, w, templates.AdminJobsPage(pending, failed))
+}
+
+func (mux *AdminMux) usernames(r *http.Request) (map[int64]string, error) {
+	users, err := mux.Services.UserService.GetAllUsers(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	usernames := make(map[int64]string, len(*users))
+	for _, u := range *users {
+		usernames[u.Id] = u.Username
+	}
+	return usernames, nil
+}
+
+func (mux *AdminMux) handleInvitationsPage(w http.ResponseWriter, r *http.Request) {
+	invitations, err := mux.Services.InvitationService.GetAllInvitations(r.Context())
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to get invitations", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	uses, err := mux.Services.InvitationService.GetUses(r.Context(), invitations)
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to get invitation uses", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	usernames, err := mux.usernames(r)
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to get users", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	response.HandleHtmlResponse(r, w, templates.AdminInvitationsPage(templates.AdminInvitationsProps{
+		Invitations: invitations,
+		Uses:        uses,
+		Usernames:   usernames,
+		BaseUrl:     mux.opts.BaseUrl,
+	}))
+}
+
+func (mux *AdminMux) handleLockoutsPage(w http.ResponseWriter, r *http.Request) {
+	blocked, err := mux.Services.LoginLimiter.GetBlocked(r.Context())
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to get lockouts", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	lockouts := make([]templates.AdminLockout, 0, len(blocked))
+	for _, failures := range blocked {
+		lockouts = append(lockouts, templates.AdminLockout{
+			Failures:    failures,
+			IsLockedOut: mux.Services.LoginLimiter.IsLockedOut(failures),
+		})
+	}
+
+	response.HandleHtmlResponse(r, w, templates.AdminLockoutsPage(lockouts))
+}
+
+func (mux *AdminMux) handleUnlockLogin(w http.ResponseWriter, r *http.Request) {
+	kind := loginlimit.KeyKind(r.FormValue("kind"))
+	value := r.FormValue("value")
+
+	err := mux.Services.LoginLimiter.Unlock(r.Context(), kind, value)
+	if err == loginlimit.ErrInvalidKeyKind {
+		response.HandleErrorResponse(w, "Invalid lockout", http.StatusBadRequest, r, err)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to unlock", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	rlog.Logger(r).Info().Str("kind", string(kind)).Str("value", value).Msg("Login unlocked")
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (mux *AdminMux) handleRevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil {
+		response.HandleErrorResponse(w, "Could not get user data", http.StatusUnauthorized, r, err)
+		return
+	}
+
+	invitationId, err := strconv.ParseInt(r.PathValue("invitationId"), 10, 64)
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid invitation ID", http.StatusBadRequest, r, err)
+		return
+	}
+
+	inv, err := mux.Services.InvitationService.RevokeInvitation(r.Context(), user, invitationId)
+	if err == invitation.ErrInvitationNotFound {
+		response.HandleErrorResponse(w, "Invitation not found", http.StatusNotFound, r, err)
+		return
+	} else if err == invitation.ErrInvitationUsedUp {
+		response.HandleErrorResponse(w, "This invitation has already been used up", http.StatusUnprocessableEntity, r, err)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to revoke invitation", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	uses, err := mux.Services.InvitationService.GetUses(r.Context(), []invitation.Invitation{*inv})
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to get invitation uses", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	creatorName := ""
+	if inv.CreatedBy != 0 {
+		creator, err := mux.Services.UserService.GetUserById(r.Context(), inv.CreatedBy)
+		if err != nil {
+			response.HandleErrorResponse(w, "Failed to get user", http.StatusInternalServerError, r, err)
+			return
+		}
+		creatorName = creator.Username
+	}
+
+	rlog.Logger(r).Info().Int64("invitationId", inv.Id).Msg("Invitation revoked")
+
+	response.HandleHtmlResponse(r, w, templates.AdminInvitationRow(*inv, uses[inv.Id], creatorName, mux.opts.BaseUrl))
+}
+
+func (mux *AdminMux) handleRetryJob(w http.ResponseWriter, r *http.Request) {
+	jobId, err := strconv.ParseInt(r.PathValue("jobId"), 10, 64)
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid job ID", http.StatusBadRequest, r, err)
+		return
+	}
+
+	err = mux.Services.Scheduler.RetryJob(r.Context(), jobId)
+	if err == scheduler.ErrJobNotFound {
+		response.HandleErrorResponse(w, "Job not found", http.StatusNotFound, r, err)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to retry job", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	rlog.Logger(r).Info().Int64("jobId", jobId).Msg("Job queued for retry")
+
+	// The job is pending again, so it no longer belongs in the failed list.
+	w.WriteHeader(http.StatusOK)
+}
+
+const adminWebhookDeliveriesLimit = 50
+
+func (mux *AdminMux) handleWebhooksPage(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := mux.Services.WebhookService.GetWebhooks(r.Context())
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to get webhooks", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	response.HandleHtmlResponse(r, w, templates.AdminWebhooksPage(webhooks))
+}
+
+func (mux *AdminMux) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to parse form", http.StatusBadRequest, r, err)
+		return
+	}
+
+	formOpts := templates.AdminWebhookFormOpts{
+		Url:         r.Form.Get("url"),
+		Description: r.Form.Get("description"),
+	}
+	for _, event := range r.Form["events"] {
+		formOpts.Events = append(formOpts.Events, webhook.EventType(event))
+	}
+
+	hook, err := mux.Services.WebhookService.CreateWebhook(r.Context(), formOpts.Url, formOpts.Description, formOpts.Events)
+	if err == webhook.ErrInvalidUrl {
+		formOpts.UrlError = "Enter an absolute http or https URL"
+		response.HandleHtmlResponse(r, w, templates.AdminWebhookForm(formOpts))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	} else if err == webhook.ErrInvalidEventType {
+		response.HandleErrorResponse(w, "Invalid event type", http.StatusUnprocessableEntity, r, err)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to create webhook", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	rlog.Logger(r).Info().Int64("webhookId", hook.Id).Str("url", hook.Url).Msg("Webhook created")
+
+	response.HandleRedirect(w, r, fmt.Sprintf("/app/admin/webhooks/%d", hook.Id))
+}
+
+func (mux *AdminMux) handleWebhookPage(w http.ResponseWriter, r *http.Request) {
+	webhookId, err := strconv.ParseInt(r.PathValue("webhookId"), 10, 64)
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid webhook ID", http.StatusBadRequest, r, err)
+		return
+	}
+
+	hook, err := mux.Services.WebhookService.GetWebhook(r.Context(), webhookId)
+	if err == webhook.ErrWebhookNotFound {
+		response.HandleErrorResponse(w, "Webhook not found", http.StatusNotFound, r, err)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to get webhook", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	deliveries, err := mux.Services.WebhookService.GetDeliveries(r.Context(), webhookId, adminWebhookDeliveriesLimit)
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to get webhook deliveries", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	response.HandleHtmlResponse(r, w, templates.AdminWebhookPage(*hook, deliveries))
+}
+
+func (mux *AdminMux) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookId, err := strconv.ParseInt(r.PathValue("webhookId"), 10, 64)
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid webhook ID", http.StatusBadRequest, r, err)
+		return
+	}
+
+	err = mux.Services.WebhookService.DeleteWebhook(r.Context(), webhookId)
+	if err == webhook.ErrWebhookNotFound {
+		response.HandleErrorResponse(w, "Webhook not found", http.StatusNotFound, r, err)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to delete webhook", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	rlog.Logger(r).Info().Int64("webhookId", webhookId).Msg("Webhook deleted")
+
+	// An empty response removes the webhook's row.
+	w.WriteHeader(http.StatusOK)
+}
+
+func (mux *AdminMux) handleSetWebhookActive(w http.ResponseWriter, r *http.Request) {
+	webhookId, err := strconv.ParseInt(r.PathValue("webhookId"), 10, 64)
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid webhook ID", http.StatusBadRequest, r, err)
+		return
+	}
+
+	isActive, err := strconv.ParseBool(r.FormValue("isActive"))
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid active value", http.StatusBadRequest, r, err)
+		return
+	}
+
+	hook, err := mux.Services.WebhookService.SetWebhookActive(r.Context(), webhookId, isActive)
+	if err == webhook.ErrWebhookNotFound {
+		response.HandleErrorResponse(w, "Webhook not found", http.StatusNotFound, r, err)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to update webhook", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	response.HandleHtmlResponse(r, w, templates.AdminWebhookRow(*hook))
+}
+
+func (mux *AdminMux) handleTestWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookId, err := strconv.ParseInt(r.PathValue("webhookId"), 10, 64)
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid webhook ID", http.StatusBadRequest, r, err)
+		return
+	}
+
+	delivery, err := mux.Services.WebhookService.TestWebhook(r.Context(), webhookId)
+	if err == webhook.ErrWebhookNotFound {
+		response.HandleErrorResponse(w, "Webhook not found", http.StatusNotFound, r, err)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to send test event", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	rlog.Logger(r).Info().Int64("webhookId", webhookId).Str("status", string(delivery.Status)).Msg("Webhook test event sent")
+
+	// The delivery log on the webhook page shows how the test went.
+	response.HandleRedirect(w, r, fmt.Sprintf("/app/admin/webhooks/%d", webhookId))
+}
+
+func (mux *AdminMux) handleRedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookId, err := strconv.ParseInt(r.PathValue("webhookId"), 10, 64)
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid webhook ID", http.StatusBadRequest, r, err)
+		return
+	}
+
+	deliveryId, err := strconv.ParseInt(r.PathValue("deliveryId"), 10, 64)
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid delivery ID", http.StatusBadRequest, r, err)
+		return
+	}
+
+	err = mux.Services.WebhookService.RedeliverDelivery(r.Context(), deliveryId)
+	if err == webhook.ErrDeliveryNotFound {
+		response.HandleErrorResponse(w, "Delivery not found", http.StatusNotFound, r, err)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to redeliver webhook", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	rlog.Logger(r).Info().Int64("deliveryId", deliveryId).Msg("Webhook delivery queued for redelivery")
+
+	response.HandleRedirect(w, r, fmt.Sprintf("/app/admin/webhooks/%d", webhookId))
+}
@@ -0,0 +1,812 @@
+package mux
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/accesstoken"
+	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/server/middleware"
+	"github.com/CaribouBlue/mixtape/internal/server/openapi"
+	"github.com/CaribouBlue/mixtape/internal/server/response"
+	"github.com/CaribouBlue/mixtape/internal/server/utils"
+)
+
+const (
+	apiMaxBodyBytes         = 1 << 20
+	apiMaxSessionNameLength = 100
+	apiMaxSubmissions       = 20
+)
+
+type ApiMux struct {
+	Mux[ApiMuxOpts, ApiMuxServices]
+	spec *openapi.Builder
+	// origin is the app's own origin, the only one cookie-authenticated changes are accepted from.
+	origin string
+}
+
+func (mux *ApiMux) Opts() MuxOpts {
+	return mux.opts.MuxOpts
+}
+
+type ApiMuxOpts struct {
+	MuxOpts
+	// BaseUrl is where the API is served from, advertised in the OpenAPI document.
+	BaseUrl string
+}
+
+type ApiMuxServices struct {
+	MuxServices
+	SessionServiceInitializer MuxServiceInitializer[*ApiMux, *core.SessionService]
+	UserService               *core.UserService
+	CrewService               *core.CrewService
+}
+
+// NewApiMux serves the versioned JSON API. Every route is registered along with its OpenAPI
+// description, which is served from /openapi.json without authentication.
+func NewApiMux(opts ApiMuxOpts, services ApiMuxServices, mw []middleware.Middleware, children []ChildMux) *ApiMux {
+	mux := &ApiMux{
+		Mux: *NewMux(
+			opts,
+			services,
+			children,
+			mw,
+		),
+		spec: openapi.NewBuilder(openapi.Info{
+			Title:       "Mixtape API",
+			Version:     "1.0.0",
+			Description: "Create and play Mixtape sessions. Every error response is a JsonError.",
+		}, response.JsonError{}),
+		origin: apiOrigin(opts.BaseUrl),
+	}
+	mux.spec.AddServer(opts.BaseUrl + opts.PathPrefix)
+	mux.spec.AddSecurityScheme("accessToken", openapi.SecurityScheme{
+		Type:         "http",
+		Scheme:       "bearer",
+		BearerFormat: "Personal access token",
+		Description:  "A personal access token created from the profile page. Each operation needs the scope named in its description.",
+	})
+	mux.spec.AddSecurityScheme("authCookie", openapi.SecurityScheme{
+		Type:        "apiKey",
+		In:          "cookie",
+		Name:        utils.CookieNameAuthorization,
+		Description: "The cookie set by logging in to the app, which isn't limited by scopes. Changes made with it are only accepted from the app's own origin.",
+	})
+
+	mux.BeforeEachRequest = func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The session service is built for each request, so it's kept in the request's context
+			// rather than on the mux, which is shared by concurrent requests.
+			sessionService, err := mux.Services.SessionServiceInitializer(mux, r)
+			if err != nil {
+				response.HandleJsonErrorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to init mux", err)
+				return
+			}
+
+			ctx := utils.SetContextValue(r.Context(), utils.SessionServiceCtxKey, sessionService)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+
+	ids := map[string]any{"sessionId": int64(0), "candidateId": int64(0)}
+
+	mux.route(accesstoken.ScopeRead, openapi.Route{
+		Method:      http.MethodGet,
+		Path:        "/crews",
+		OperationId: "listCrews",
+		Summary:     "List the crews the current user is a member of",
+		Tag:         "crews",
+		Response:    []ApiCrew{},
+	}, mux.handleListCrews)
+	mux.route(accesstoken.ScopeRead, openapi.Route{
+		Method:      http.MethodGet,
+		Path:        "/sessions",
+		OperationId: "listSessions",
+		Summary:     "List the sessions of the current user's crews",
+		Tag:         "sessions",
+		Response:    []ApiSession{},
+	}, mux.handleListSessions)
+	mux.route(accesstoken.ScopeSessions, openapi.Route{
+		Method:      http.MethodPost,
+		Path:        "/sessions",
+		OperationId: "createSession",
+		Summary:     "Create a session for a crew the current user is an admin of",
+		Tag:         "sessions",
+		Request:     ApiCreateSessionRequest{},
+		Response:    ApiSession{},
+		Status:      http.StatusCreated,
+		Errors:      []int{http.StatusBadRequest, http.StatusForbidden, http.StatusUnprocessableEntity},
+	}, mux.handleCreateSession)
+	mux.route(accesstoken.ScopeRead, openapi.Route{
+		Method:      http.MethodGet,
+		Path:        "/sessions/{sessionId}",
+		OperationId: "getSession",
+		Summary:     "Get a session as seen by the current user",
+		Tag:         "sessions",
+		PathParams:  ids,
+		Response:    ApiSessionDetail{},
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	}, mux.handleGetSession)
+	mux.route(accesstoken.ScopeSubmit, openapi.Route{
+		Method:      http.MethodPost,
+		Path:        "/sessions/{sessionId}/players/me",
+		OperationId: "joinSession",
+		Summary:     "Join a session during its submission phase",
+		Tag:         "players",
+		PathParams:  ids,
+		Response:    ApiPlayer{},
+		Status:      http.StatusCreated,
+		Errors:      []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusConflict},
+	}, mux.handleJoinSession)
+	mux.route(accesstoken.ScopeSubmit, openapi.Route{
+		Method:      http.MethodPost,
+		Path:        "/sessions/{sessionId}/players/me/finalize",
+		OperationId: "finalizeSubmissions",
+		Summary:     "Lock in the current user's submissions",
+		Tag:         "players",
+		PathParams:  ids,
+		Response:    ApiPlayer{},
+		Errors:      []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusConflict},
+	}, mux.handleFinalizeSubmissions)
+	mux.route(accesstoken.ScopeRead, openapi.Route{
+		Method:      http.MethodGet,
+		Path:        "/sessions/{sessionId}/tracks",
+		OperationId: "searchTracks",
+		Summary:     "Search for tracks to submit to a session",
+		Tag:         "candidates",
+		PathParams:  ids,
+		QueryParams: map[string]any{"q": ""},
+		Response:    []ApiTrack{},
+		Errors:      []int{http.StatusBadRequest, http.StatusUnprocessableEntity},
+	}, mux.handleSearchTracks)
+	mux.route(accesstoken.ScopeSubmit, openapi.Route{
+		Method:      http.MethodPost,
+		Path:        "/sessions/{sessionId}/candidates",
+		OperationId: "submitCandidate",
+		Summary:     "Submit a track",
+		Tag:         "candidates",
+		PathParams:  ids,
+		Request:     ApiSubmitCandidateRequest{},
+		Response:    ApiCandidate{},
+		Status:      http.StatusCreated,
+		Errors:      []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+	}, mux.handleSubmitCandidate)
+	mux.route(accesstoken.ScopeSubmit, openapi.Route{
+		Method:      http.MethodDelete,
+		Path:        "/sessions/{sessionId}/candidates/{candidateId}",
+		OperationId: "removeCandidate",
+		Summary:     "Take back one of the current user's submissions",
+		Tag:         "candidates",
+		PathParams:  ids,
+		Status:      http.StatusNoContent,
+		Errors:      []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusConflict},
+	}, mux.handleRemoveCandidate)
+	mux.route(accesstoken.ScopeVote, openapi.Route{
+		Method:      http.MethodPost,
+		Path:        "/sessions/{sessionId}/candidates/{candidateId}/vote",
+		OperationId: "voteForCandidate",
+		Summary:     "Vote for a candidate",
+		Tag:         "votes",
+		PathParams:  ids,
+		Response:    ApiCandidate{},
+		Errors:      []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+	}, mux.handleVoteForCandidate)
+	mux.route(accesstoken.ScopeVote, openapi.Route{
+		Method:      http.MethodDelete,
+		Path:        "/sessions/{sessionId}/candidates/{candidateId}/vote",
+		OperationId: "removeVote",
+		Summary:     "Take back a vote",
+		Tag:         "votes",
+		PathParams:  ids,
+		Response:    ApiCandidate{},
+		Errors:      []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusConflict},
+	}, mux.handleRemoveVote)
+
+	mux.Handle("GET /openapi.json", http.HandlerFunc(mux.handleGetOpenApi))
+	mux.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response.HandleJsonErrorResponse(w, r, http.StatusNotFound, "not_found", "No such endpoint", nil)
+	}))
+
+	return mux
+}
+
+// route registers an authenticated handler that access tokens need the scope for, and describes it
+// in the OpenAPI document.
+func (mux *ApiMux) route(scope accesstoken.Scope, route openapi.Route, handler http.HandlerFunc) {
+	route.Description = fmt.Sprintf("Access tokens need the `%s` scope.", scope)
+	route.Errors = append([]int{http.StatusUnauthorized}, route.Errors...)
+	if !slices.Contains(route.Errors, http.StatusForbidden) {
+		route.Errors = append(route.Errors, http.StatusForbidden)
+	}
+	route.Errors = append(route.Errors, http.StatusInternalServerError)
+	mux.spec.Add(route)
+
+	mux.Handle(route.Method+" "+route.Path, middleware.HandlerFunc(handler,
+		middleware.WithEnforcedAuthentication(middleware.WithEnforcedAuthenticationOpts{
+			UnauthenticatedHandler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				response.HandleJsonErrorResponse(w, r, http.StatusUnauthorized, "unauthorized", "Authentication required", nil)
+			}),
+			UserService: mux.Services.UserService,
+		}),
+		middleware.WithSameOriginCookieWrites(mux.origin),
+		middleware.WithRequiredScope(scope),
+	))
+}
+
+// apiOrigin returns the scheme and host of the app's base URL, as browsers send them in the
+// Origin header.
+func apiOrigin(baseUrl string) string {
+	u, err := url.Parse(baseUrl)
+	if err != nil {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// sessionService returns the session service BeforeEachRequest built for the request.
+func (mux *ApiMux) sessionService(r *http.Request) *core.SessionService {
+	sessionService, _ := utils.ContextValue(r.Context(), utils.SessionServiceCtxKey)
+	return sessionService
+}
+
+func (mux *ApiMux) handleGetOpenApi(w http.ResponseWriter, r *http.Request) {
+	response.HandleJsonResponse(w, mux.spec.Document())
+}
+
+type ApiSessionPhase core.SessionPhase
+
+func (ApiSessionPhase) EnumValues() []string {
+	return []string{string(core.SubmissionPhase), string(core.VotePhase), string(core.ResultPhase)}
+}
+
+type ApiCrewRole core.CrewRole
+
+func (ApiCrewRole) EnumValues() []string {
+	return []string{string(core.CrewRoleMember), string(core.CrewRoleAdmin)}
+}
+
+type ApiCrew struct {
+	Id   int64       `json:"id"`
+	Name string      `json:"name"`
+	Role ApiCrewRole `json:"role"`
+}
+
+type ApiSession struct {
+	Id             int64           `json:"id"`
+	Name           string          `json:"name"`
+	CrewId         int64           `json:"crewId"`
+	Phase          ApiSessionPhase `json:"phase"`
+	CreatedBy      int64           `json:"createdBy"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	MaxSubmissions int             `json:"maxSubmissions"`
+	MaxVotes       int             `json:"maxVotes"`
+	StartAt        time.Time       `json:"startAt"`
+	VotingStartAt  time.Time       `json:"votingStartAt"`
+	ResultsStartAt time.Time       `json:"resultsStartAt"`
+	IsJoined       bool            `json:"isJoined"`
+}
+
+func newApiSession(session *core.SessionEntity, isJoined bool) ApiSession {
+	return ApiSession{
+		Id:             session.Id,
+		Name:           session.Name,
+		CrewId:         session.CrewId,
+		Phase:          ApiSessionPhase(session.Phase()),
+		CreatedBy:      session.CreatedBy,
+		CreatedAt:      session.CreatedAt.UTC().Truncate(time.Second),
+		MaxSubmissions: session.MaxSubmissions,
+		MaxVotes:       session.MaxVotes(),
+		StartAt:        session.StartAt.UTC().Truncate(time.Second),
+		VotingStartAt:  session.PhaseStartAt(core.VotePhase).UTC().Truncate(time.Second),
+		ResultsStartAt: session.PhaseStartAt(core.ResultPhase).UTC().Truncate(time.Second),
+		IsJoined:       isJoined,
+	}
+}
+
+// ApiSessionDetail is a session as seen by the current user. Submissions and Ballot are only
+// filled in for players, Results only once the session is over and they've been tallied, until
+// then ResultsPending is set.
+type ApiSessionDetail struct {
+	ApiSession
+	Players        []ApiPlayer    `json:"players"`
+	Me             *ApiPlayer     `json:"me"`
+	Submissions    []ApiCandidate `json:"submissions"`
+	Ballot         []ApiCandidate `json:"ballot"`
+	Results        []ApiCandidate `json:"results"`
+	ResultsPending bool           `json:"resultsPending"`
+}
+
+type ApiPlayer struct {
+	UserId                 int64  `json:"userId"`
+	DisplayName            string `json:"displayName"`
+	IsSubmissionsFinalized bool   `json:"isSubmissionsFinalized"`
+	VoteCount              int    `json:"voteCount"`
+	PlaylistUrl            string `json:"playlistUrl,omitempty"`
+}
+
+func newApiPlayer(player *core.PlayerDto) ApiPlayer {
+	return ApiPlayer{
+		UserId:                 player.PlayerId,
+		DisplayName:            player.DisplayName,
+		IsSubmissionsFinalized: player.IsSubmissionsFinalized,
+		VoteCount:              player.VoteCount,
+		PlaylistUrl:            player.PlaylistUrl,
+	}
+}
+
+type ApiTrack struct {
+	Id       string   `json:"id"`
+	Name     string   `json:"name"`
+	Artists  []string `json:"artists"`
+	Album    string   `json:"album"`
+	Explicit bool     `json:"explicit"`
+	Url      string   `json:"url"`
+}
+
+// ApiCandidate is a submitted track. Who submitted it is only revealed with the results, until
+// then it's only filled in for the current user's own submissions.
+type ApiCandidate struct {
+	Id          int64     `json:"id"`
+	Track       *ApiTrack `json:"track"`
+	NominatorId int64     `json:"nominatorId,omitempty"`
+	Nominator   string    `json:"nominator,omitempty"`
+	IsVoted     bool      `json:"isVoted"`
+	Votes       int       `json:"votes,omitempty"`
+	Place       int       `json:"place,omitempty"`
+}
+
+func newApiTrack(track *core.TrackEntity) *ApiTrack {
+	artists := make([]string, len(track.Artists))
+	for i, artist := range track.Artists {
+		artists[i] = artist.Name
+	}
+	return &ApiTrack{
+		Id:       track.Id,
+		Name:     track.Name,
+		Artists:  artists,
+		Album:    track.Album.Name,
+		Explicit: track.Explicit,
+		Url:      track.Url,
+	}
+}
+
+func newApiCandidate(candidate *core.CandidateDto, userId int64) ApiCandidate {
+	apiCandidate := ApiCandidate{
+		Id:      candidate.Id,
+		IsVoted: candidate.Vote != nil,
+		Place:   candidate.Place,
+	}
+
+	if candidate.Track != nil {
+		apiCandidate.Track = newApiTrack(candidate.Track)
+	}
+
+	if candidate.Nominator != nil {
+		apiCandidate.NominatorId = candidate.Nominator.Id
+		apiCandidate.Nominator = candidate.Nominator.DisplayName
+		apiCandidate.Votes = candidate.Votes
+	} else if candidate.NominatorId == userId {
+		apiCandidate.NominatorId = userId
+	}
+
+	return apiCandidate
+}
+
+func newApiCandidates(candidates *[]core.CandidateDto, userId int64) []ApiCandidate {
+	apiCandidates := make([]ApiCandidate, 0)
+	if candidates == nil {
+		return apiCandidates
+	}
+	for _, candidate := range *candidates {
+		apiCandidates = append(apiCandidates, newApiCandidate(&candidate, userId))
+	}
+	return apiCandidates
+}
+
+type ApiCreateSessionRequest struct {
+	Name           string     `json:"name"`
+	CrewId         int64      `json:"crewId"`
+	MaxSubmissions int        `json:"maxSubmissions,omitempty"`
+	StartAt        *time.Time `json:"startAt,omitempty"`
+}
+
+type ApiSubmitCandidateRequest struct {
+	TrackId string `json:"trackId"`
+}
+
+type apiError struct {
+	status int
+	code   string
+	msg    string
+}
+
+// apiErrors maps the core errors an API client can cause to their responses. Anything else is an
+// internal error.
+var apiErrors = map[error]apiError{
+	core.ErrSessionNotFound:               {http.StatusNotFound, "session_not_found", "Session not found"},
+	core.ErrCandidateNotFound:             {http.StatusNotFound, "candidate_not_found", "Candidate not found"},
+	core.ErrPlayerNotFound:                {http.StatusForbidden, "not_a_player", "You haven't joined this session"},
+	core.ErrUserCannotFinalizeSubmissions: {http.StatusForbidden, "not_a_player", "You haven't joined this session"},
+	core.ErrPlayerAlreadyJoined:           {http.StatusConflict, "already_joined", "You have already joined this session"},
+	core.ErrWrongSessionPhase:             {http.StatusConflict, "wrong_phase", "This isn't allowed in the session's current phase"},
+	core.ErrSubmissionsFinalized:          {http.StatusConflict, "submissions_finalized", "Your submissions have already been finalized"},
+	core.ErrSubmissionsRemaining:          {http.StatusConflict, "submissions_remaining", "You still have submissions left to make"},
+	core.ErrNoSubmissionsLeft:             {http.StatusConflict, "no_submissions_left", "You have no submissions left"},
+	core.ErrDuplicateSubmission:           {http.StatusConflict, "duplicate_submission", "This track was already submitted"},
+	core.ErrNoVotesLeft:                   {http.StatusConflict, "no_votes_left", "You have no votes left"},
+	core.ErrDuplicateVote:                 {http.StatusConflict, "duplicate_vote", "You already voted for this candidate"},
+	core.ErrCannotVoteForOwnCandidate:     {http.StatusUnprocessableEntity, "own_candidate", "You can't vote for your own submission"},
+	core.ErrNotCrewMember:                 {http.StatusForbidden, "not_a_crew_member", "Only members of the session's crew can join it"},
+	core.ErrNotCrewAdmin:                  {http.StatusForbidden, "not_a_crew_admin", "Only crew admins can create sessions for the crew"},
+	core.ErrCrewNotFound:                  {http.StatusUnprocessableEntity, "invalid_crew", "Crew not found"},
+}
+
+func handleApiServiceError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	if apiErr, ok := apiErrors[err]; ok {
+		response.HandleJsonErrorResponse(w, r, apiErr.status, apiErr.code, apiErr.msg, err)
+		return
+	}
+	response.HandleJsonErrorResponse(w, r, http.StatusInternalServerError, "internal_error", msg, err)
+}
+
+var errUnsupportedMediaType = errors.New("request body must be application/json")
+
+// decodeApiRequest reads a JSON request body, rejecting unknown fields so typos don't go
+// unnoticed. Cross-site requests made with the user's cookies are turned away before this by
+// WithSameOriginCookieWrites, whether or not they have a body.
+func decodeApiRequest(w http.ResponseWriter, r *http.Request, v any) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		response.HandleJsonErrorResponse(w, r, http.StatusUnsupportedMediaType, "unsupported_media_type", "Request body must be JSON", errUnsupportedMediaType)
+		return false
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, apiMaxBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		response.HandleJsonErrorResponse(w, r, http.StatusBadRequest, "invalid_json", fmt.Sprintf("Invalid request body: %s", err), err)
+		return false
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		response.HandleJsonErrorResponse(w, r, http.StatusBadRequest, "invalid_json", "Request body must be a single JSON value", err)
+		return false
+	}
+
+	return true
+}
+
+func apiPathId(w http.ResponseWriter, r *http.Request, name string) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	if err != nil {
+		response.HandleJsonErrorResponse(w, r, http.StatusBadRequest, "invalid_id", fmt.Sprintf("Invalid %s", name), err)
+		return 0, false
+	}
+	return id, true
+}
+
+func apiUser(w http.ResponseWriter, r *http.Request) (*core.UserEntity, bool) {
+	user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil || user == nil {
+		response.HandleJsonErrorResponse(w, r, http.StatusUnauthorized, "unauthorized", "Could not get user data", err)
+		return nil, false
+	}
+	return user, true
+}
+
+func (mux *ApiMux) handleListCrews(w http.ResponseWriter, r *http.Request) {
+	user, ok := apiUser(w, r)
+	if !ok {
+		return
+	}
+
+	crews, err := mux.Services.CrewService.GetUserCrews(r.Context(), user.Id)
+	if err != nil {
+		handleApiServiceError(w, r, "Failed to get crews", err)
+		return
+	}
+
+	apiCrews := make([]ApiCrew, len(crews))
+	for i, crew := range crews {
+		apiCrews[i] = ApiCrew{
+			Id:   crew.Id,
+			Name: crew.Name,
+			Role: ApiCrewRole(crew.CurrentMember.Role),
+		}
+	}
+
+	response.HandleJsonResponse(w, apiCrews)
+}
+
+func (mux *ApiMux) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	user, ok := apiUser(w, r)
+	if !ok {
+		return
+	}
+
+	sessions, err := mux.sessionService(r).GetSessionsListForUser(r.Context(), user.Id)
+	if err != nil {
+		handleApiServiceError(w, r, "Failed to get sessions", err)
+		return
+	}
+
+	apiSessions := make([]ApiSession, len(*sessions))
+	for i, session := range *sessions {
+		apiSessions[i] = newApiSession(&session.SessionEntity, session.CurrentPlayer.IsJoinedSession())
+	}
+
+	response.HandleJsonResponse(w, apiSessions)
+}
+
+func (mux *ApiMux) handleCreateSession(w http.ResponseWriter, r *http.Request) {
+	user, ok := apiUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req ApiCreateSessionRequest
+	if !decodeApiRequest(w, r, &req) {
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > apiMaxSessionNameLength {
+		response.HandleJsonErrorResponse(w, r, http.StatusUnprocessableEntity, "invalid_name", fmt.Sprintf("Name must be between 1 and %d characters", apiMaxSessionNameLength), nil)
+		return
+	}
+	if req.MaxSubmissions < 0 || req.MaxSubmissions > apiMaxSubmissions {
+		response.HandleJsonErrorResponse(w, r, http.StatusUnprocessableEntity, "invalid_max_submissions", fmt.Sprintf("maxSubmissions must be between 1 and %d", apiMaxSubmissions), nil)
+		return
+	}
+
+	options := []core.SessionOption{core.WithSessionCrew(req.CrewId)}
+	if req.StartAt != nil {
+		options = append(options, core.WithSessionStartAt(*req.StartAt))
+	}
+	session := core.NewSessionEntity(req.Name, user.Id, options...)
+	if req.MaxSubmissions != 0 {
+		session.MaxSubmissions = req.MaxSubmissions
+	}
+
+	session, err := mux.sessionService(r).CreateSession(r.Context(), session)
+	if err != nil {
+		handleApiServiceError(w, r, "Failed to create session", err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("%s/sessions/%d", mux.opts.PathPrefix, session.Id))
+	response.HandleJsonStatusResponse(w, http.StatusCreated, newApiSession(session, true))
+}
+
+func (mux *ApiMux) handleGetSession(w http.ResponseWriter, r *http.Request) {
+	user, ok := apiUser(w, r)
+	if !ok {
+		return
+	}
+
+	sessionId, ok := apiPathId(w, r, "sessionId")
+	if !ok {
+		return
+	}
+
+	if err := mux.sessionService(r).CheckSessionAccess(r.Context(), sessionId, user); err != nil {
+		handleApiServiceError(w, r, "Failed to get session", err)
+		return
+	}
+
+	session, err := mux.sessionService(r).GetSessionView(r.Context(), sessionId, user.Id)
+	if err != nil {
+		handleApiServiceError(w, r, "Failed to get session", err)
+		return
+	}
+
+	detail := ApiSessionDetail{
+		ApiSession:     newApiSession(&session.SessionEntity, session.CurrentPlayer.IsJoinedSession()),
+		Players:        make([]ApiPlayer, 0),
+		Submissions:    newApiCandidates(session.SubmittedCandidates, user.Id),
+		Ballot:         newApiCandidates(session.BallotCandidates, user.Id),
+		Results:        newApiCandidates(session.Results, user.Id),
+		ResultsPending: session.ResultsPending,
+	}
+	for _, player := range *session.Players {
+		apiPlayer := newApiPlayer(&player)
+		detail.Players = append(detail.Players, apiPlayer)
+
+		if player.PlayerId == user.Id {
+			apiPlayer.PlaylistUrl = session.CurrentPlayer.PlaylistUrl
+			detail.Me = &apiPlayer
+		}
+	}
+
+	response.HandleJsonResponse(w, detail)
+}
+
+func (mux *ApiMux) handleJoinSession(w http.ResponseWriter, r *http.Request) {
+	user, ok := apiUser(w, r)
+	if !ok {
+		return
+	}
+
+	sessionId, ok := apiPathId(w, r, "sessionId")
+	if !ok {
+		return
+	}
+
+	player, err := mux.sessionService(r).JoinSession(r.Context(), sessionId, user.Id)
+	if err != nil {
+		handleApiServiceError(w, r, "Failed to join session", err)
+		return
+	}
+	player.DisplayName = user.DisplayName
+
+	response.HandleJsonStatusResponse(w, http.StatusCreated, newApiPlayer(player))
+}
+
+func (mux *ApiMux) handleFinalizeSubmissions(w http.ResponseWriter, r *http.Request) {
+	user, ok := apiUser(w, r)
+	if !ok {
+		return
+	}
+
+	sessionId, ok := apiPathId(w, r, "sessionId")
+	if !ok {
+		return
+	}
+
+	err := mux.sessionService(r).FinalizePlayerSubmissions(r.Context(), sessionId, user.Id)
+	if err != nil {
+		handleApiServiceError(w, r, "Failed to finalize submissions", err)
+		return
+	}
+
+	response.HandleJsonResponse(w, ApiPlayer{
+		UserId:                 user.Id,
+		DisplayName:            user.DisplayName,
+		IsSubmissionsFinalized: true,
+	})
+}
+
+func (mux *ApiMux) handleSearchTracks(w http.ResponseWriter, r *http.Request) {
+	user, ok := apiUser(w, r)
+	if !ok {
+		return
+	}
+
+	sessionId, ok := apiPathId(w, r, "sessionId")
+	if !ok {
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		response.HandleJsonErrorResponse(w, r, http.StatusUnprocessableEntity, "invalid_query", "q is required", nil)
+		return
+	}
+
+	candidates, err := mux.sessionService(r).SearchCandidateSubmissions(r.Context(), sessionId, user.Id, query)
+	if err != nil {
+		handleApiServiceError(w, r, "Failed to search tracks", err)
+		return
+	}
+
+	tracks := make([]ApiTrack, 0, len(*candidates))
+	for _, candidate := range *candidates {
+		tracks = append(tracks, *newApiTrack(candidate.Track))
+	}
+
+	response.HandleJsonResponse(w, tracks)
+}
+
+func (mux *ApiMux) handleSubmitCandidate(w http.ResponseWriter, r *http.Request) {
+	user, ok := apiUser(w, r)
+	if !ok {
+		return
+	}
+
+	sessionId, ok := apiPathId(w, r, "sessionId")
+	if !ok {
+		return
+	}
+
+	var req ApiSubmitCandidateRequest
+	if !decodeApiRequest(w, r, &req) {
+		return
+	}
+
+	if strings.TrimSpace(req.TrackId) == "" {
+		response.HandleJsonErrorResponse(w, r, http.StatusUnprocessableEntity, "invalid_track_id", "trackId is required", nil)
+		return
+	}
+
+	candidate, err := mux.sessionService(r).SubmitCandidate(r.Context(), sessionId, user.Id, req.TrackId)
+	if err != nil {
+		handleApiServiceError(w, r, "Failed to submit candidate", err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("%s/sessions/%d/candidates/%d", mux.opts.PathPrefix, sessionId, candidate.Id))
+	response.HandleJsonStatusResponse(w, http.StatusCreated, newApiCandidate(candidate, user.Id))
+}
+
+func (mux *ApiMux) handleRemoveCandidate(w http.ResponseWriter, r *http.Request) {
+	user, ok := apiUser(w, r)
+	if !ok {
+		return
+	}
+
+	sessionId, ok := apiPathId(w, r, "sessionId")
+	if !ok {
+		return
+	}
+
+	candidateId, ok := apiPathId(w, r, "candidateId")
+	if !ok {
+		return
+	}
+
+	err := mux.sessionService(r).RemoveCandidate(r.Context(), sessionId, user.Id, candidateId)
+	if err != nil {
+		handleApiServiceError(w, r, "Failed to remove candidate", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (mux *ApiMux) handleVoteForCandidate(w http.ResponseWriter, r *http.Request) {
+	user, ok := apiUser(w, r)
+	if !ok {
+		return
+	}
+
+	sessionId, ok := apiPathId(w, r, "sessionId")
+	if !ok {
+		return
+	}
+
+	candidateId, ok := apiPathId(w, r, "candidateId")
+	if !ok {
+		return
+	}
+
+	candidate, err := mux.sessionService(r).VoteForCandidate(r.Context(), sessionId, user.Id, candidateId)
+	if err != nil {
+		handleApiServiceError(w, r, "Failed to vote for candidate", err)
+		return
+	}
+
+	response.HandleJsonResponse(w, newApiCandidate(candidate, user.Id))
+}
+
+func (mux *ApiMux) handleRemoveVote(w http.ResponseWriter, r *http.Request) {
+	user, ok := apiUser(w, r)
+	if !ok {
+		return
+	}
+
+	sessionId, ok := apiPathId(w, r, "sessionId")
+	if !ok {
+		return
+	}
+
+	candidateId, ok := apiPathId(w, r, "candidateId")
+	if !ok {
+		return
+	}
+
+	candidate, err := mux.sessionService(r).RemoveVoteForCandidate(r.Context(), sessionId, user.Id, candidateId)
+	if err != nil {
+		handleApiServiceError(w, r, "Failed to remove vote", err)
+		return
+	}
+
+	response.HandleJsonResponse(w, newApiCandidate(candidate, user.Id))
+}
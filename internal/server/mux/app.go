@@ -3,6 +3,7 @@ package mux
 import (
 	"net/http"
 
+	"github.com/CaribouBlue/mixtape/internal/core"
 	"github.com/CaribouBlue/mixtape/internal/log/rlog"
 	"github.com/CaribouBlue/mixtape/internal/server/middleware"
 	"github.com/CaribouBlue/mixtape/internal/server/response"
@@ -24,6 +25,7 @@ type AppMuxOpts struct {
 
 type AppMuxServices struct {
 	MuxServices
+	CrewService *core.CrewService
 }
 
 func NewAppMux(opts AppMuxOpts, services AppMuxServices, middleware []middleware.Middleware, children []ChildMux) *AppMux {
@@ -45,7 +47,13 @@ func NewAppMux(opts AppMuxOpts, services AppMuxServices, middleware []middleware
 			return
 		}
 
-		response.HandleHtmlResponse(r, w, templates.Home(*user))
+		crews, err := mux.Services.CrewService.GetManagedCrews(r.Context(), user)
+		if err != nil {
+			response.HandleErrorResponse(w, "Failed to get crews", http.StatusInternalServerError, r, err)
+			return
+		}
+
+		response.HandleHtmlResponse(r, w, templates.Home(*user, len(crews) > 0))
 	}))
 
 	return mux
@@ -2,9 +2,19 @@ package mux
 
 import (
 	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/CaribouBlue/mixtape/internal/account"
 	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/invitation"
+	"github.com/CaribouBlue/mixtape/internal/log/rlog"
+	"github.com/CaribouBlue/mixtape/internal/loginlimit"
+	"github.com/CaribouBlue/mixtape/internal/loginsession"
+	"github.com/CaribouBlue/mixtape/internal/notification"
 	"github.com/CaribouBlue/mixtape/internal/server/middleware"
 	"github.com/CaribouBlue/mixtape/internal/server/response"
 	"github.com/CaribouBlue/mixtape/internal/server/utils"
@@ -26,7 +36,11 @@ type AuthMuxOpts struct {
 
 type AuthMuxServices struct {
 	MuxServices
-	UserService *core.UserService
+	UserService         *core.UserService
+	AccountService      *account.AccountService
+	InvitationService   *invitation.InvitationService
+	LoginSessionService *loginsession.LoginSessionService
+	LoginLimiter        *loginlimit.Limiter
 }
 
 func NewAuthMux(opts AuthMuxOpts, services AuthMuxServices, middleware []middleware.Middleware, children []ChildMux) *AuthMux {
@@ -45,9 +59,27 @@ func NewAuthMux(opts AuthMuxOpts, services AuthMuxServices, middleware []middlew
 	mux.Handle("GET /user/sign-up", http.HandlerFunc(mux.handleUserSignUpPage))
 	mux.Handle("POST /user/sign-up", http.HandlerFunc(mux.handleUserSignUp))
 
+	mux.Handle("GET /user/forgot-password", http.HandlerFunc(mux.handleForgotPasswordPage))
+	mux.Handle("POST /user/forgot-password", http.HandlerFunc(mux.handleForgotPassword))
+	mux.Handle("GET /user/reset-password", http.HandlerFunc(mux.handleResetPasswordPage))
+	mux.Handle("POST /user/reset-password", http.HandlerFunc(mux.handleResetPassword))
+
+	mux.Handle("GET /user/magic-link", http.HandlerFunc(mux.handleMagicLinkPage))
+	mux.Handle("POST /user/magic-link", http.HandlerFunc(mux.handleMagicLinkRequest))
+	mux.Handle("GET /user/magic-link/confirm", http.HandlerFunc(mux.handleMagicLinkConfirmPage))
+	mux.Handle("POST /user/magic-link/confirm", http.HandlerFunc(mux.handleMagicLinkLogin))
+
+	mux.Handle("GET /user/email", http.HandlerFunc(mux.handleUserEmailPage))
+	mux.Handle("POST /user/email", http.HandlerFunc(mux.handleUserEmailSubmit))
+	mux.Handle("GET /email/verify", http.HandlerFunc(mux.handleEmailVerify))
+
 	mux.Handle("/login", http.HandlerFunc(mux.handleLogin))
 	mux.Handle("/logout", http.HandlerFunc(mux.handleLogout))
 
+	mux.Handle("GET /email/unsubscribe", http.HandlerFunc(mux.handleEmailPreferencesPage))
+	mux.Handle("POST /email/unsubscribe", http.HandlerFunc(mux.handleEmailUnsubscribe))
+	mux.Handle("POST /email/subscribe", http.HandlerFunc(mux.handleEmailSubscribe))
+
 	mux.Handle("/spotify", http.HandlerFunc(mux.handleSpotifyAuth))
 	mux.Handle("/spotify/redirect", http.HandlerFunc(mux.handleSpotifyAuthRedirect))
 
@@ -59,26 +91,70 @@ func (mux *AuthMux) handleUserLoginPage(w http.ResponseWriter, r *http.Request)
 }
 
 func (mux *AuthMux) handleUserSignUpPage(w http.ResponseWriter, r *http.Request) {
-	response.HandleHtmlResponse(r, w, templates.UserSignUpPage())
+	opts := templates.UserSignUpFormOpts{}
+
+	// Invitation links carry the code, which is checked up front so people find out before
+	// filling in the form.
+	if code := r.URL.Query().Get("code"); code != "" {
+		opts.InvitationCode = invitation.FormatCode(code)
+
+		inv, err := mux.Services.InvitationService.CheckCode(r.Context(), code)
+		if err == invitation.ErrInvalidCode {
+			opts.InvitationCodeError = "This invitation is invalid, expired or has been used up"
+		} else if err != nil {
+			response.HandleErrorResponse(w, "Failed to check invitation", http.StatusInternalServerError, r, err)
+			return
+		} else {
+			opts.InvitedAs = inv.DisplayName
+		}
+	}
+
+	response.HandleHtmlResponse(r, w, templates.UserSignUpPage(opts))
 }
 
 func (mux *AuthMux) handleUserSignUp(w http.ResponseWriter, r *http.Request) {
+	// Sign up is limited by IP address only, failures are wrong invitation codes rather than
+	// passwords.
+	ip := utils.ClientIp(r)
+	if !mux.checkLoginLimit(w, r, ip, "") {
+		return
+	}
+
 	username := r.FormValue("username")
 	password := r.FormValue("password")
 	confirmPassword := r.FormValue("confirm-password")
-	accessCode := r.FormValue("access-code")
+	invitationCode := r.FormValue("invitation-code")
+	email := r.FormValue("email")
+
+	userSignUpFormOpts := templates.UserSignUpFormOpts{
+		Username:        username,
+		Password:        password,
+		ConfirmPassword: confirmPassword,
+		InvitationCode:  invitationCode,
+		Email:           email,
+	}
 
-	_, err := mux.Services.UserService.SignUpNewUser(username, password, confirmPassword, accessCode)
-	if err != nil {
-		userSignUpFormOpts := templates.UserSignUpFormOpts{
-			Username:        username,
-			Password:        password,
-			ConfirmPassword: confirmPassword,
-			AccessCode:      accessCode,
+	if email != "" {
+		_, err := mux.Services.UserService.GetUserByEmail(r.Context(), email)
+		if err == nil || err == core.ErrInvalidEmail {
+			userSignUpFormOpts.EmailError = "Invalid email"
+			if err == nil {
+				userSignUpFormOpts.EmailError = "Email already in use"
+			}
+			response.HandleHtmlResponse(r, w, templates.UserSignUpForm(userSignUpFormOpts))
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		} else if err != core.ErrUserNotFound {
+			response.HandleErrorResponse(w, "Failed to sign up user", http.StatusInternalServerError, r, err)
+			return
 		}
+	}
 
-		if err == core.ErrIncorrectAccessCode {
-			userSignUpFormOpts.AccessCodeError = "Invalid access code"
+	user, err := mux.Services.InvitationService.SignUp(r.Context(), invitationCode, username, password, confirmPassword)
+	if err != nil {
+		if err == invitation.ErrInvalidCode {
+			mux.recordLoginFailure(r, ip, "", "invalid_invitation_code")
+			userSignUpFormOpts.InvitationCodeError = "Invalid, expired or used up invitation code"
 			response.HandleHtmlResponse(r, w, templates.UserSignUpForm(userSignUpFormOpts))
 			w.WriteHeader(http.StatusUnprocessableEntity)
 			return
@@ -98,15 +174,211 @@ func (mux *AuthMux) handleUserSignUp(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if email != "" {
+		// The account exists at this point, so a failed verification email shouldn't fail sign up.
+		// The address can be verified again from the email page.
+		if err := mux.Services.AccountService.RequestEmailVerification(r.Context(), user, email); err != nil {
+			rlog.Logger(r).Error().Err(err).Int64("userId", user.Id).Msg("Failed to request email verification")
+		}
+	}
+
 	w.Header().Add("HX-Redirect", mux.opts.PathPrefix+"/user/login")
 	w.WriteHeader(http.StatusCreated)
 }
 
+func (mux *AuthMux) handleForgotPasswordPage(w http.ResponseWriter, r *http.Request) {
+	response.HandleHtmlResponse(r, w, templates.UserForgotPasswordPage())
+}
+
+func (mux *AuthMux) handleForgotPassword(w http.ResponseWriter, r *http.Request) {
+	usernameOrEmail := r.FormValue("username-or-email")
+	if usernameOrEmail == "" {
+		response.HandleErrorResponse(w, "Enter a username or email", http.StatusUnprocessableEntity, r, nil)
+		return
+	}
+
+	err := mux.Services.AccountService.RequestPasswordReset(r.Context(), usernameOrEmail, utils.ClientIp(r))
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to send password reset email", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	response.HandleHtmlResponse(r, w, templates.UserForgotPasswordForm(true))
+}
+
+func (mux *AuthMux) handleResetPasswordPage(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if err := mux.Services.AccountService.CheckPasswordResetToken(token); err != nil {
+		response.HandleHtmlResponse(r, w, templates.UserLinkResultPage("Reset Password", "This password reset link is invalid or has expired. Request a new one from the login page."))
+		return
+	}
+
+	response.HandleHtmlResponse(r, w, templates.UserResetPasswordPage(token))
+}
+
+func (mux *AuthMux) handleResetPassword(w http.ResponseWriter, r *http.Request) {
+	token := r.FormValue("token")
+	password := r.FormValue("password")
+	confirmPassword := r.FormValue("confirm-password")
+
+	err := mux.Services.AccountService.ResetPassword(r.Context(), token, password, confirmPassword)
+	if err == core.ErrPasswordsDoNotMatch {
+		response.HandleHtmlResponse(r, w, templates.UserResetPasswordForm(templates.UserResetPasswordFormOpts{
+			Token:                token,
+			ConfirmPasswordError: "Passwords do not match",
+		}))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	} else if err == account.ErrInvalidToken {
+		response.HandleErrorResponse(w, "This password reset link is invalid or has expired", http.StatusUnprocessableEntity, r, err)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to reset password", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	response.HandleRedirect(w, r, mux.opts.PathPrefix+"/user/login")
+}
+
+func (mux *AuthMux) handleMagicLinkPage(w http.ResponseWriter, r *http.Request) {
+	response.HandleHtmlResponse(r, w, templates.UserMagicLinkPage())
+}
+
+func (mux *AuthMux) handleMagicLinkRequest(w http.ResponseWriter, r *http.Request) {
+	email := r.FormValue("email")
+	if email == "" {
+		response.HandleErrorResponse(w, "Enter an email", http.StatusUnprocessableEntity, r, nil)
+		return
+	}
+
+	err := mux.Services.AccountService.RequestMagicLink(r.Context(), email, utils.ClientIp(r))
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to send login link", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	response.HandleHtmlResponse(r, w, templates.UserMagicLinkForm(true))
+}
+
+func (mux *AuthMux) handleMagicLinkConfirmPage(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+
+	user, err := mux.Services.AccountService.CheckMagicLink(r.Context(), token)
+	if err == account.ErrInvalidToken {
+		response.HandleHtmlResponse(r, w, templates.UserLinkResultPage("Login Link", "This login link is invalid or has expired. Request a new one from the login page."))
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to check login link", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	response.HandleHtmlResponse(r, w, templates.UserMagicLinkConfirmPage(token, user.DisplayName))
+}
+
+func (mux *AuthMux) handleMagicLinkLogin(w http.ResponseWriter, r *http.Request) {
+	token := r.FormValue("token")
+
+	user, err := mux.Services.AccountService.LoginWithMagicLink(r.Context(), token)
+	if err == account.ErrInvalidToken {
+		response.HandleErrorResponse(w, "This login link is invalid, has expired or was already used", http.StatusUnprocessableEntity, r, err)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to log in user", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	err = mux.logIn(w, r, user)
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to log in user", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	// Continue through /login so users who haven't connected Spotify are sent to do so.
+	response.HandleRedirect(w, r, mux.opts.PathPrefix+"/login")
+}
+
+func (mux *AuthMux) handleUserEmailPage(w http.ResponseWriter, r *http.Request) {
+	u, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil || u.Id == 0 {
+		response.HandleRedirect(w, r, mux.opts.PathPrefix+"/user/login")
+		return
+	}
+
+	response.HandleHtmlResponse(r, w, templates.UserEmailPage(templates.UserEmailFormOpts{
+		Email:           u.Email,
+		IsEmailVerified: u.IsEmailVerified,
+	}))
+}
+
+func (mux *AuthMux) handleUserEmailSubmit(w http.ResponseWriter, r *http.Request) {
+	u, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil || u.Id == 0 {
+		response.HandleErrorResponse(w, "Log in to change your email", http.StatusUnauthorized, r, err)
+		return
+	}
+
+	email := r.FormValue("email")
+	formOpts := templates.UserEmailFormOpts{
+		Email:           email,
+		IsEmailVerified: u.IsEmailVerified && email == u.Email,
+	}
+
+	err = mux.Services.AccountService.RequestEmailVerification(r.Context(), u, email)
+	if err == core.ErrInvalidEmail || err == core.ErrEmailAlreadyInUse {
+		formOpts.EmailError = "Invalid email"
+		if err == core.ErrEmailAlreadyInUse {
+			formOpts.EmailError = "Email already in use"
+		}
+		response.HandleHtmlResponse(r, w, templates.UserEmailForm(formOpts))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to send verification email", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	formOpts.IsEmailVerified = false
+	formOpts.SentTo = email
+	response.HandleHtmlResponse(r, w, templates.UserEmailForm(formOpts))
+}
+
+func (mux *AuthMux) handleEmailVerify(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+
+	user, err := mux.Services.AccountService.VerifyEmail(r.Context(), token)
+	if err == account.ErrInvalidToken {
+		response.HandleHtmlResponse(r, w, templates.UserLinkResultPage("Verify Email", "This verification link is invalid, has expired or was already used."))
+		return
+	} else if err == core.ErrEmailAlreadyInUse {
+		response.HandleHtmlResponse(r, w, templates.UserLinkResultPage("Verify Email", "This email has already been verified by another account."))
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to verify email", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	response.HandleHtmlResponse(r, w, templates.UserLinkResultPage("Email Verified", "Mixtape emails will now go to "+user.Email+"."))
+}
+
 func (mux *AuthMux) handleUserLoginSubmit(w http.ResponseWriter, r *http.Request) {
 	username := r.FormValue("username")
 	password := r.FormValue("password")
-	u, err := mux.Services.UserService.LoginUser(username, password)
+
+	ip := utils.ClientIp(r)
+	normalizedUsername := mux.Services.UserService.NormalizeUsername(username)
+	if !mux.checkLoginLimit(w, r, ip, normalizedUsername) {
+		return
+	}
+
+	u, err := mux.Services.UserService.LoginUser(r.Context(), username, password)
 	if err == core.ErrUserNotFound || err == core.ErrIncorrectPassword {
+		// Unknown usernames count too, so lockouts don't tell which accounts exist.
+		reason := "incorrect_password"
+		if err == core.ErrUserNotFound {
+			reason = "unknown_username"
+		}
+		mux.recordLoginFailure(r, ip, normalizedUsername, reason)
+
 		response.HandleErrorResponse(w, "Invalid login", http.StatusUnprocessableEntity, r, err)
 		return
 	} else if err != nil {
@@ -114,9 +386,13 @@ func (mux *AuthMux) handleUserLoginSubmit(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	err = utils.SetAuthCookie(w, u)
+	if err := mux.Services.LoginLimiter.RecordSuccess(r.Context(), normalizedUsername); err != nil {
+		rlog.Logger(r).Error().Err(err).Msg("Failed to clear failed logins")
+	}
+
+	err = mux.logIn(w, r, u)
 	if err != nil {
-		response.HandleErrorResponse(w, "Failed to set auth cookie", http.StatusInternalServerError, r, err)
+		response.HandleErrorResponse(w, "Failed to log in user", http.StatusInternalServerError, r, err)
 		return
 	}
 
@@ -145,7 +421,7 @@ func (mux *AuthMux) handleLogin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if u.IsAuthenticatedWithSpotify() {
-		_, err := spotify.Reauthenticate(u.SpotifyToken)
+		_, err := spotify.Reauthenticate(r.Context(), u.SpotifyToken)
 		if err != nil {
 			response.HandleErrorResponse(w, "Failed to login", http.StatusInternalServerError, r, err)
 			return
@@ -159,11 +435,91 @@ func (mux *AuthMux) handleLogin(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// checkLoginLimit turns the request away when attempts from the IP address or for the username
+// are blocked after too many failures. It reports whether the request can go ahead.
+func (mux *AuthMux) checkLoginLimit(w http.ResponseWriter, r *http.Request, ip string, username string) bool {
+	retryAt, err := mux.Services.LoginLimiter.Check(r.Context(), ip, username)
+	if err != loginlimit.ErrTooManyAttempts && err != loginlimit.ErrLockedOut {
+		if err != nil {
+			response.HandleErrorResponse(w, "Failed to check login attempts", http.StatusInternalServerError, r, err)
+			return false
+		}
+		return true
+	}
+
+	retryAfter := max(time.Until(retryAt), time.Second)
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+
+	msg := fmt.Sprintf("Too many failed attempts, try again in %s", retryAfterLabel(retryAfter))
+	if err == loginlimit.ErrLockedOut {
+		msg = fmt.Sprintf("Too many failed attempts, logging in is locked for %s. An admin can unlock it sooner.", retryAfterLabel(retryAfter))
+	}
+
+	rlog.Logger(r).Warn().Str("ip", ip).Str("username", username).Time("retryAt", retryAt).Msg("Login attempt blocked")
+	response.HandleErrorResponse(w, msg, http.StatusTooManyRequests, r, err)
+	return false
+}
+
+func retryAfterLabel(d time.Duration) string {
+	count, unit := int(math.Ceil(d.Minutes())), "minute"
+	if d < time.Minute {
+		count, unit = int(math.Ceil(d.Seconds())), "second"
+	}
+	if count == 1 {
+		return fmt.Sprintf("1 %s", unit)
+	}
+	return fmt.Sprintf("%d %ss", count, unit)
+}
+
+// recordLoginFailure counts a failed attempt towards the login limits and keeps an audit log of it.
+func (mux *AuthMux) recordLoginFailure(r *http.Request, ip string, username string, reason string) {
+	failures, err := mux.Services.LoginLimiter.RecordFailure(r.Context(), ip, username)
+	if err != nil {
+		rlog.Logger(r).Error().Err(err).Msg("Failed to record failed login")
+		return
+	}
+
+	rlog.Logger(r).Warn().Str("ip", ip).Str("username", username).Str("reason", reason).Msg("Failed login attempt")
+
+	now := core.Now()
+	for _, f := range failures {
+		if f.IsBlocked(now) {
+			rlog.Logger(r).Warn().
+				Str("kind", string(f.Kind)).
+				Str("value", f.Value).
+				Int("failures", f.Count).
+				Bool("lockedOut", mux.Services.LoginLimiter.IsLockedOut(f)).
+				Time("blockedUntil", f.BlockedUntil).
+				Msg("Login attempts blocked")
+		}
+	}
+}
+
+// logIn starts a login session for the user in this browser.
+func (mux *AuthMux) logIn(w http.ResponseWriter, r *http.Request, u *core.UserEntity) error {
+	session, err := mux.Services.LoginSessionService.StartSession(r.Context(), u.Id, r.UserAgent(), utils.ClientIp(r))
+	if err != nil {
+		return err
+	}
+
+	rlog.Logger(r).Info().Int64("userId", u.Id).Int64("loginSessionId", session.Id).Msg("User logged in")
+
+	return utils.SetAuthCookie(w, session)
+}
+
 func (mux *AuthMux) handleLogout(w http.ResponseWriter, r *http.Request) {
-	// cookie, _ := r.Cookie(utils.CookieAuthorization)
-	// println("cookie:", cookie, cookie.Expires.String(), cookie.MaxAge)
+	// The session is revoked, not just forgotten by the browser, so a copy of the cookie stops
+	// working too.
+	session, err := utils.ContextValue(r.Context(), utils.LoginSessionCtxKey)
+	if err == nil && session != nil {
+		_, err := mux.Services.LoginSessionService.RevokeSession(r.Context(), session.UserId, session.Id)
+		if err != nil {
+			response.HandleErrorResponse(w, "Failed to logout", http.StatusInternalServerError, r, err)
+			return
+		}
+	}
 
-	err := utils.DeleteCookie(w, r, utils.CookieNameAuthorization)
+	err = utils.DeleteCookie(w, r, utils.CookieNameAuthorization)
 	if err != nil {
 		response.HandleErrorResponse(w, "Failed to logout", http.StatusInternalServerError, r, err)
 		return
@@ -195,7 +551,7 @@ func (mux *AuthMux) handleSpotifyAuthRedirect(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	u, err = mux.Services.UserService.GetUserById(u.Id)
+	u, err = mux.Services.UserService.GetUserById(r.Context(), u.Id)
 	if err != nil {
 		response.HandleErrorResponse(w, "Failed to get user", http.StatusInternalServerError, r, err)
 		return
@@ -219,14 +575,14 @@ func (mux *AuthMux) handleSpotifyAuthRedirect(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	token, err := spotify.Authenticate(code)
+	token, err := spotify.Authenticate(r.Context(), code)
 	if err != nil {
 		response.HandleErrorResponse(w, "Failed to get new access token", http.StatusBadRequest, r, err)
 		return
 	}
 
 	if u.SpotifyEmail == "" {
-		profile, err := spotify.GetCurrentUserProfile()
+		profile, err := spotify.GetCurrentUserProfile(r.Context())
 		if err != nil {
 			response.HandleErrorResponse(w, "Failed to get Spotify profile", http.StatusInternalServerError, r, err)
 			return
@@ -236,7 +592,7 @@ func (mux *AuthMux) handleSpotifyAuthRedirect(w http.ResponseWriter, r *http.Req
 	}
 
 	u.SpotifyToken = token.RefreshToken
-	_, err = mux.Services.UserService.AuthenticateSpotify(u.Id, u.SpotifyToken, u.SpotifyEmail)
+	_, err = mux.Services.UserService.AuthenticateSpotify(r.Context(), u.Id, u.SpotifyToken, u.SpotifyEmail)
 	if err != nil {
 		response.HandleErrorResponse(w, "Failed to authenticate Spotify", http.StatusInternalServerError, r, err)
 		return
@@ -244,3 +600,49 @@ func (mux *AuthMux) handleSpotifyAuthRedirect(w http.ResponseWriter, r *http.Req
 
 	response.HandleRedirect(w, r, mux.opts.PathPrefix+"/login")
 }
+
+func (mux *AuthMux) handleEmailPreferencesPage(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	userId, err := notification.ParseUnsubscribeToken(token)
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid unsubscribe link", http.StatusBadRequest, r, err)
+		return
+	}
+
+	user, err := mux.Services.UserService.GetUserById(r.Context(), userId)
+	if err == core.ErrUserNotFound {
+		response.HandleErrorResponse(w, "Invalid unsubscribe link", http.StatusBadRequest, r, err)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to get user", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	response.HandleHtmlResponse(r, w, templates.EmailPreferencesPage(token, user.EmailOptOut))
+}
+
+func (mux *AuthMux) handleEmailUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	mux.setEmailOptOut(w, r, true)
+}
+
+func (mux *AuthMux) handleEmailSubscribe(w http.ResponseWriter, r *http.Request) {
+	mux.setEmailOptOut(w, r, false)
+}
+
+func (mux *AuthMux) setEmailOptOut(w http.ResponseWriter, r *http.Request, optOut bool) {
+	// Mail clients that support one-click unsubscribe post the token in the query string.
+	token := r.FormValue("token")
+	userId, err := notification.ParseUnsubscribeToken(token)
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid unsubscribe link", http.StatusUnprocessableEntity, r, err)
+		return
+	}
+
+	err = mux.Services.UserService.SetEmailOptOut(r.Context(), userId, optOut)
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to update email preferences", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	response.HandleHtmlResponse(r, w, templates.EmailPreferencesForm(token, optOut))
+}
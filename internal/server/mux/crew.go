@@ -0,0 +1,233 @@
+package mux
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/log/rlog"
+	"github.com/CaribouBlue/mixtape/internal/server/middleware"
+	"github.com/CaribouBlue/mixtape/internal/server/response"
+	"github.com/CaribouBlue/mixtape/internal/server/utils"
+	"github.com/CaribouBlue/mixtape/internal/templates"
+)
+
+type CrewMux struct {
+	Mux[CrewMuxOpts, CrewMuxServices]
+}
+
+func (mux *CrewMux) Opts() MuxOpts {
+	return mux.opts.MuxOpts
+}
+
+type CrewMuxOpts struct {
+	MuxOpts
+}
+
+type CrewMuxServices struct {
+	MuxServices
+	CrewService *core.CrewService
+}
+
+func NewCrewMux(opts CrewMuxOpts, services CrewMuxServices, middleware []middleware.Middleware, children []ChildMux) *CrewMux {
+	mux := &CrewMux{
+		*NewMux(
+			opts,
+			services,
+			children,
+			middleware,
+		),
+	}
+
+	mux.Handle("GET /{$}", http.HandlerFunc(mux.handleCrewsPage))
+	mux.Handle("POST /{$}", http.HandlerFunc(mux.handleCreateCrew))
+
+	mux.Handle("GET /{crewId}", http.HandlerFunc(mux.handleCrewPage))
+
+	mux.Handle("POST /{crewId}/members", http.HandlerFunc(mux.handleAddMember))
+	mux.Handle("POST /{crewId}/members/{userId}/role", http.HandlerFunc(mux.handleSetMemberRole))
+	mux.Handle("DELETE /{crewId}/members/{userId}", http.HandlerFunc(mux.handleRemoveMember))
+
+	return mux
+}
+
+// crewErrorMessages are the crew errors caused by what the user asked for, shown to them as is.
+var crewErrorMessages = map[error]string{
+	core.ErrCrewsNotAllowed:       "Only admins can create crews",
+	core.ErrInvalidCrewName:       fmt.Sprintf("Crew names must be between 1 and %d characters", core.MaxCrewNameLength),
+	core.ErrCrewNameAlreadyExists: "There's already a crew with this name",
+	core.ErrInvalidCrewRole:       "Invalid role",
+	core.ErrNotCrewAdmin:          "Only crew admins can change the crew's members",
+	core.ErrCrewMemberExists:      "This user is already a member",
+	core.ErrLastCrewAdmin:         "A crew needs at least one admin",
+	core.ErrUserNotFound:          "User not found",
+}
+
+func handleCrewServiceError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	if err == core.ErrCrewNotFound || err == core.ErrCrewMemberNotFound {
+		response.HandleErrorResponse(w, "Not found", http.StatusNotFound, r, err)
+	} else if errMsg, ok := crewErrorMessages[err]; ok {
+		response.HandleErrorResponse(w, errMsg, http.StatusUnprocessableEntity, r, err)
+	} else {
+		response.HandleErrorResponse(w, msg, http.StatusInternalServerError, r, err)
+	}
+}
+
+func (mux *CrewMux) handleCrewsPage(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil {
+		response.HandleErrorResponse(w, "Could not get user data", http.StatusUnauthorized, r, err)
+		return
+	}
+
+	crews, err := mux.Services.CrewService.GetVisibleCrews(r.Context(), user)
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to get crews", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	response.HandleHtmlResponse(r, w, templates.CrewsPage(*user, crews))
+}
+
+func (mux *CrewMux) handleCreateCrew(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil {
+		response.HandleErrorResponse(w, "Could not get user data", http.StatusUnauthorized, r, err)
+		return
+	}
+
+	crew, err := mux.Services.CrewService.CreateCrew(r.Context(), user, r.FormValue("name"))
+	if err != nil {
+		handleCrewServiceError(w, r, "Failed to create crew", err)
+		return
+	}
+
+	rlog.Logger(r).Info().Int64("crewId", crew.Id).Msg("Crew created")
+
+	response.HandleRedirect(w, r, fmt.Sprintf("/app/crew/%d", crew.Id))
+}
+
+func (mux *CrewMux) handleCrewPage(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil {
+		response.HandleErrorResponse(w, "Could not get user data", http.StatusUnauthorized, r, err)
+		return
+	}
+
+	crewId, err := strconv.ParseInt(r.PathValue("crewId"), 10, 64)
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid crew ID", http.StatusBadRequest, r, err)
+		return
+	}
+
+	crew, err := mux.Services.CrewService.GetCrewView(r.Context(), crewId, user)
+	if err != nil {
+		handleCrewServiceError(w, r, "Failed to get crew", err)
+		return
+	}
+
+	response.HandleHtmlResponse(r, w, templates.CrewPage(*user, *crew))
+}
+
+// renderCrewMembers responds with the crew's member list after a change to it.
+func (mux *CrewMux) renderCrewMembers(w http.ResponseWriter, r *http.Request, user *core.UserEntity, crewId int64) {
+	crew, err := mux.Services.CrewService.GetCrewView(r.Context(), crewId, user)
+	if err != nil {
+		handleCrewServiceError(w, r, "Failed to get crew", err)
+		return
+	}
+
+	response.HandleHtmlResponse(r, w, templates.CrewMembers(*user, *crew))
+}
+
+func (mux *CrewMux) handleAddMember(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil {
+		response.HandleErrorResponse(w, "Could not get user data", http.StatusUnauthorized, r, err)
+		return
+	}
+
+	crewId, err := strconv.ParseInt(r.PathValue("crewId"), 10, 64)
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid crew ID", http.StatusBadRequest, r, err)
+		return
+	}
+
+	member, err := mux.Services.CrewService.AddMember(r.Context(), user, crewId, r.FormValue("username"))
+	if err != nil {
+		handleCrewServiceError(w, r, "Failed to add member", err)
+		return
+	}
+
+	rlog.Logger(r).Info().Int64("crewId", crewId).Int64("userId", member.UserId).Msg("Crew member added")
+
+	mux.renderCrewMembers(w, r, user, crewId)
+}
+
+func (mux *CrewMux) handleSetMemberRole(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil {
+		response.HandleErrorResponse(w, "Could not get user data", http.StatusUnauthorized, r, err)
+		return
+	}
+
+	crewId, err := strconv.ParseInt(r.PathValue("crewId"), 10, 64)
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid crew ID", http.StatusBadRequest, r, err)
+		return
+	}
+
+	userId, err := strconv.ParseInt(r.PathValue("userId"), 10, 64)
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid user ID", http.StatusBadRequest, r, err)
+		return
+	}
+
+	role := core.CrewRole(r.FormValue("role"))
+	err = mux.Services.CrewService.SetMemberRole(r.Context(), user, crewId, userId, role)
+	if err != nil {
+		handleCrewServiceError(w, r, "Failed to change role", err)
+		return
+	}
+
+	rlog.Logger(r).Info().Int64("crewId", crewId).Int64("userId", userId).Str("role", string(role)).Msg("Crew member role changed")
+
+	mux.renderCrewMembers(w, r, user, crewId)
+}
+
+func (mux *CrewMux) handleRemoveMember(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil {
+		response.HandleErrorResponse(w, "Could not get user data", http.StatusUnauthorized, r, err)
+		return
+	}
+
+	crewId, err := strconv.ParseInt(r.PathValue("crewId"), 10, 64)
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid crew ID", http.StatusBadRequest, r, err)
+		return
+	}
+
+	userId, err := strconv.ParseInt(r.PathValue("userId"), 10, 64)
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid user ID", http.StatusBadRequest, r, err)
+		return
+	}
+
+	err = mux.Services.CrewService.RemoveMember(r.Context(), user, crewId, userId)
+	if err != nil {
+		handleCrewServiceError(w, r, "Failed to remove member", err)
+		return
+	}
+
+	rlog.Logger(r).Info().Int64("crewId", crewId).Int64("userId", userId).Msg("Crew member removed")
+
+	// Members who left can't see the crew anymore unless they're global admins.
+	if userId == user.Id && !user.IsAdmin {
+		response.HandleRedirect(w, r, "/app/crew/")
+		return
+	}
+
+	mux.renderCrewMembers(w, r, user, crewId)
+}
@@ -1,12 +1,20 @@
 package mux
 
 import (
-	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/CaribouBlue/mixtape/internal/accesstoken"
+	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/invitation"
 	"github.com/CaribouBlue/mixtape/internal/log/rlog"
+	"github.com/CaribouBlue/mixtape/internal/loginsession"
 	"github.com/CaribouBlue/mixtape/internal/server/middleware"
+	"github.com/CaribouBlue/mixtape/internal/server/response"
 	"github.com/CaribouBlue/mixtape/internal/server/utils"
+	"github.com/CaribouBlue/mixtape/internal/templates"
 )
 
 type ProfileMux struct {
@@ -19,10 +27,17 @@ func (mux *ProfileMux) Opts() MuxOpts {
 
 type ProfileMuxOpts struct {
 	MuxOpts
+	// BaseUrl is the app's public URL, used to build invitation links.
+	BaseUrl string
 }
 
 type ProfileMuxServices struct {
 	MuxServices
+	AccessTokenService  *accesstoken.AccessTokenService
+	UserService         *core.UserService
+	SessionService      *core.SessionService
+	InvitationService   *invitation.InvitationService
+	LoginSessionService *loginsession.LoginSessionService
 }
 
 func NewProfileMux(opts ProfileMuxOpts, services ProfileMuxServices, middleware []middleware.Middleware, children []ChildMux) *ProfileMux {
@@ -37,6 +52,20 @@ func NewProfileMux(opts ProfileMuxOpts, services ProfileMuxServices, middleware
 
 	mux.Handle("GET /", http.HandlerFunc(mux.handleProfilePage))
 
+	mux.Handle("POST /display-name", http.HandlerFunc(mux.handleSetDisplayName))
+	mux.Handle("POST /password", http.HandlerFunc(mux.handleChangePassword))
+	mux.Handle("POST /spotify/unlink", http.HandlerFunc(mux.handleUnlinkSpotify))
+
+	mux.Handle("POST /login-sessions/revoke", http.HandlerFunc(mux.handleRevokeAllLoginSessions))
+	mux.Handle("POST /login-sessions/revoke-others", http.HandlerFunc(mux.handleRevokeOtherLoginSessions))
+	mux.Handle("POST /login-sessions/{sessionId}/revoke", http.HandlerFunc(mux.handleRevokeLoginSession))
+
+	mux.Handle("POST /tokens", http.HandlerFunc(mux.handleCreateAccessToken))
+	mux.Handle("POST /tokens/{tokenId}/revoke", http.HandlerFunc(mux.handleRevokeAccessToken))
+
+	mux.Handle("POST /invitations", http.HandlerFunc(mux.handleCreateInvitation))
+	mux.Handle("POST /invitations/{invitationId}/revoke", http.HandlerFunc(mux.handleRevokeInvitation))
+
 	return mux
 }
 
@@ -48,9 +77,433 @@ func (mux *ProfileMux) handleProfilePage(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(user); err != nil {
-		http.Error(w, "Failed to encode data", http.StatusInternalServerError)
+	tokens, err := mux.Services.AccessTokenService.GetUserTokens(r.Context(), user.Id)
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to get access tokens", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	sessions, err := mux.Services.SessionService.GetPlayedSessions(r.Context(), user.Id)
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to get sessions", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	loginSessions, err := mux.loginSessionsProps(r, user)
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to get devices", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	props := templates.ProfilePageProps{
+		User:          *user,
+		Tokens:        tokens,
+		Sessions:      *sessions,
+		LoginSessions: *loginSessions,
+	}
+
+	if mux.Services.InvitationService.CanInvite(user) {
+		invitations, err := mux.invitationsProps(r, user, 0)
+		if err != nil {
+			response.HandleErrorResponse(w, "Failed to get invitations", http.StatusInternalServerError, r, err)
+			return
+		}
+		props.Invitations = invitations
+	}
+
+	response.HandleHtmlResponse(r, w, templates.ProfilePage(props))
+}
+
+// invitationsProps loads the invitations the user created. newInvitationId highlights an
+// invitation that was just created.
+func (mux *ProfileMux) invitationsProps(r *http.Request, user *core.UserEntity, newInvitationId int64) (*templates.ProfileInvitationsProps, error) {
+	invitations, err := mux.Services.InvitationService.GetUserInvitations(r.Context(), user.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	uses, err := mux.Services.InvitationService.GetUses(r.Context(), invitations)
+	if err != nil {
+		return nil, err
+	}
+
+	return &templates.ProfileInvitationsProps{
+		User:            *user,
+		Invitations:     invitations,
+		Uses:            uses,
+		BaseUrl:         mux.opts.BaseUrl,
+		NewInvitationId: newInvitationId,
+	}, nil
+}
+
+var invitationErrorMessages = map[error]string{
+	invitation.ErrInvitationsNotAllowed: "Only admins can create invitations",
+	invitation.ErrInvalidDisplayName:    fmt.Sprintf("Display names can be at most %d characters", core.MaxDisplayNameLength),
+	invitation.ErrInvalidMaxUses:        "Invalid number of uses",
+	invitation.ErrInvalidExpiry:         "Invalid expiry",
+	invitation.ErrTooManyInvitations:    "You have too many open invitations, revoke one first",
+	invitation.ErrInvitationUsedUp:      "This invitation has already been used up",
+}
+
+func (mux *ProfileMux) handleCreateInvitation(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil {
+		response.HandleErrorResponse(w, "Could not get user data", http.StatusUnauthorized, r, err)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to parse form", http.StatusBadRequest, r, err)
+		return
+	}
+
+	expiresInDays, err := strconv.Atoi(r.Form.Get("expiresInDays"))
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid expiry", http.StatusBadRequest, r, err)
+		return
+	}
+
+	// Only admins are offered a choice, everyone else creates single-use invitations.
+	maxUses := 1
+	if r.Form.Has("maxUses") {
+		maxUses, err = strconv.Atoi(r.Form.Get("maxUses"))
+		if err != nil {
+			response.HandleErrorResponse(w, "Invalid number of uses", http.StatusBadRequest, r, err)
+			return
+		}
+	}
+
+	inv, err := mux.Services.InvitationService.CreateInvitation(r.Context(), user, r.Form.Get("displayName"), maxUses, time.Duration(expiresInDays)*24*time.Hour)
+	if msg, ok := invitationErrorMessages[err]; ok {
+		response.HandleErrorResponse(w, msg, http.StatusUnprocessableEntity, r, err)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to create invitation", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	rlog.Logger(r).Info().Int64("invitationId", inv.Id).Int("maxUses", inv.MaxUses).Msg("Invitation created")
+
+	props, err := mux.invitationsProps(r, user, inv.Id)
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to get invitations", http.StatusInternalServerError, r, err)
+		return
 	}
+
+	response.HandleHtmlResponse(r, w, templates.ProfileInvitations(*props))
+}
+
+func (mux *ProfileMux) handleRevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil {
+		response.HandleErrorResponse(w, "Could not get user data", http.StatusUnauthorized, r, err)
+		return
+	}
+
+	invitationId, err := strconv.ParseInt(r.PathValue("invitationId"), 10, 64)
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid invitation ID", http.StatusBadRequest, r, err)
+		return
+	}
+
+	inv, err := mux.Services.InvitationService.RevokeInvitation(r.Context(), user, invitationId)
+	if err == invitation.ErrInvitationNotFound {
+		response.HandleErrorResponse(w, "Invitation not found", http.StatusNotFound, r, err)
+		return
+	} else if msg, ok := invitationErrorMessages[err]; ok {
+		response.HandleErrorResponse(w, msg, http.StatusUnprocessableEntity, r, err)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to revoke invitation", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	rlog.Logger(r).Info().Int64("invitationId", inv.Id).Msg("Invitation revoked")
+
+	props, err := mux.invitationsProps(r, user, 0)
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to get invitations", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	response.HandleHtmlResponse(r, w, templates.ProfileInvitations(*props))
+}
+
+func (mux *ProfileMux) handleSetDisplayName(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil {
+		response.HandleErrorResponse(w, "Could not get user data", http.StatusUnauthorized, r, err)
+		return
+	}
+
+	formOpts := templates.ProfileDisplayNameFormOpts{
+		DisplayName: r.FormValue("displayName"),
+	}
+
+	displayName, err := mux.Services.UserService.SetDisplayName(r.Context(), user.Id, formOpts.DisplayName)
+	if err == core.ErrInvalidDisplayName {
+		formOpts.DisplayNameError = fmt.Sprintf("Display names must be between 1 and %d characters", core.MaxDisplayNameLength)
+		response.HandleHtmlResponse(r, w, templates.ProfileDisplayNameForm(formOpts))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to update display name", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	formOpts.DisplayName = displayName
+	formOpts.IsSaved = true
+	response.HandleHtmlResponse(r, w, templates.ProfileDisplayNameForm(formOpts))
+}
+
+func (mux *ProfileMux) handleChangePassword(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil {
+		response.HandleErrorResponse(w, "Could not get user data", http.StatusUnauthorized, r, err)
+		return
+	}
+
+	formOpts := templates.ProfilePasswordFormOpts{}
+
+	err = mux.Services.UserService.ChangePassword(r.Context(), user.Id, r.FormValue("current-password"), r.FormValue("password"), r.FormValue("confirm-password"))
+	if err == core.ErrIncorrectPassword {
+		formOpts.CurrentPasswordError = "Incorrect password"
+		response.HandleHtmlResponse(r, w, templates.ProfilePasswordForm(formOpts))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	} else if err == core.ErrPasswordsDoNotMatch {
+		formOpts.ConfirmPasswordError = "Passwords do not match"
+		response.HandleHtmlResponse(r, w, templates.ProfilePasswordForm(formOpts))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to change password", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	rlog.Logger(r).Info().Msg("Password changed")
+
+	// Changing the password logged the user out everywhere, this browser included, so it gets a
+	// new login to stay logged in.
+	if currentLoginSessionId(r) != 0 {
+		session, err := mux.Services.LoginSessionService.StartSession(r.Context(), user.Id, r.UserAgent(), utils.ClientIp(r))
+		if err != nil {
+			response.HandleErrorResponse(w, "Failed to log in again", http.StatusInternalServerError, r, err)
+			return
+		}
+
+		if err := utils.SetAuthCookie(w, session); err != nil {
+			response.HandleErrorResponse(w, "Failed to log in again", http.StatusInternalServerError, r, err)
+			return
+		}
+	}
+
+	formOpts.IsSaved = true
+	response.HandleHtmlResponse(r, w, templates.ProfilePasswordForm(formOpts))
+}
+
+func (mux *ProfileMux) handleUnlinkSpotify(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil {
+		response.HandleErrorResponse(w, "Could not get user data", http.StatusUnauthorized, r, err)
+		return
+	}
+
+	_, err = mux.Services.UserService.UnlinkSpotify(r.Context(), user.Id)
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to disconnect Spotify", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	rlog.Logger(r).Info().Msg("Spotify account unlinked")
+
+	// Playing needs a Spotify account, so the login flow asks for a new one.
+	response.HandleRedirect(w, r, "/auth/login")
+}
+
+var accessTokenErrorMessages = map[error]string{
+	accesstoken.ErrInvalidName:          "Token names must be between 1 and 100 characters",
+	accesstoken.ErrNoScopes:             "Pick at least one scope",
+	accesstoken.ErrInvalidScope:         "Invalid scope",
+	accesstoken.ErrAdminScopeNotAllowed: "Only admins can create tokens with the admin scope",
+	accesstoken.ErrInvalidExpiry:        "Invalid expiry",
+	accesstoken.ErrTooManyTokens:        "You have too many active tokens, revoke one first",
+}
+
+func (mux *ProfileMux) handleCreateAccessToken(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil {
+		response.HandleErrorResponse(w, "Could not get user data", http.StatusUnauthorized, r, err)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to parse form", http.StatusBadRequest, r, err)
+		return
+	}
+
+	expiresInDays, err := strconv.Atoi(r.Form.Get("expiresInDays"))
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid expiry", http.StatusBadRequest, r, err)
+		return
+	}
+
+	scopes := make([]accesstoken.Scope, 0)
+	for _, scope := range r.Form["scopes"] {
+		scopes = append(scopes, accesstoken.Scope(scope))
+	}
+
+	token, secret, err := mux.Services.AccessTokenService.CreateToken(r.Context(), user, r.Form.Get("name"), scopes, time.Duration(expiresInDays)*24*time.Hour)
+	if msg, ok := accessTokenErrorMessages[err]; ok {
+		response.HandleErrorResponse(w, msg, http.StatusUnprocessableEntity, r, err)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to create access token", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	rlog.Logger(r).Info().Int64("tokenId", token.Id).Msg("Access token created")
+
+	tokens, err := mux.Services.AccessTokenService.GetUserTokens(r.Context(), user.Id)
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to get access tokens", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	// The response carries the token's secret.
+	w.Header().Set("Cache-Control", "no-store")
+	response.HandleHtmlResponse(r, w, templates.ProfileAccessTokens(*user, tokens, secret))
+}
+
+func (mux *ProfileMux) handleRevokeAccessToken(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil {
+		response.HandleErrorResponse(w, "Could not get user data", http.StatusUnauthorized, r, err)
+		return
+	}
+
+	tokenId, err := strconv.ParseInt(r.PathValue("tokenId"), 10, 64)
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid token ID", http.StatusBadRequest, r, err)
+		return
+	}
+
+	token, err := mux.Services.AccessTokenService.RevokeToken(r.Context(), user.Id, tokenId)
+	if err == accesstoken.ErrTokenNotFound {
+		response.HandleErrorResponse(w, "Access token not found", http.StatusNotFound, r, err)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to revoke access token", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	rlog.Logger(r).Info().Int64("tokenId", token.Id).Msg("Access token revoked")
+
+	response.HandleHtmlResponse(r, w, templates.ProfileAccessTokenRow(*token))
+}
+
+// currentLoginSessionId returns the ID of the login session the request was made with, or 0 when
+// there's none, e.g. for requests made with an access token.
+func currentLoginSessionId(r *http.Request) int64 {
+	session, err := utils.ContextValue(r.Context(), utils.LoginSessionCtxKey)
+	if err != nil || session == nil {
+		return 0
+	}
+	return session.Id
+}
+
+func (mux *ProfileMux) loginSessionsProps(r *http.Request, user *core.UserEntity) (*templates.ProfileLoginSessionsProps, error) {
+	sessions, err := mux.Services.LoginSessionService.GetUserSessions(r.Context(), user.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &templates.ProfileLoginSessionsProps{
+		Sessions:         sessions,
+		CurrentSessionId: currentLoginSessionId(r),
+	}, nil
+}
+
+func (mux *ProfileMux) handleRevokeLoginSession(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil {
+		response.HandleErrorResponse(w, "Could not get user data", http.StatusUnauthorized, r, err)
+		return
+	}
+
+	sessionId, err := strconv.ParseInt(r.PathValue("sessionId"), 10, 64)
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid device ID", http.StatusBadRequest, r, err)
+		return
+	}
+
+	session, err := mux.Services.LoginSessionService.RevokeSession(r.Context(), user.Id, sessionId)
+	if err == loginsession.ErrLoginSessionNotFound {
+		response.HandleErrorResponse(w, "Device not found", http.StatusNotFound, r, err)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to log out device", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	rlog.Logger(r).Info().Int64("loginSessionId", session.Id).Msg("Login session revoked")
+
+	if session.Id == currentLoginSessionId(r) {
+		utils.DeleteCookie(w, r, utils.CookieNameAuthorization)
+		response.HandleRedirect(w, r, "/auth/user/login")
+		return
+	}
+
+	props, err := mux.loginSessionsProps(r, user)
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to get devices", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	response.HandleHtmlResponse(r, w, templates.ProfileLoginSessions(*props))
+}
+
+func (mux *ProfileMux) handleRevokeOtherLoginSessions(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil {
+		response.HandleErrorResponse(w, "Could not get user data", http.StatusUnauthorized, r, err)
+		return
+	}
+
+	err = mux.Services.LoginSessionService.RevokeUserSessions(r.Context(), user.Id, currentLoginSessionId(r))
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to log out other devices", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	rlog.Logger(r).Info().Msg("Other login sessions revoked")
+
+	props, err := mux.loginSessionsProps(r, user)
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to get devices", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	response.HandleHtmlResponse(r, w, templates.ProfileLoginSessions(*props))
+}
+
+func (mux *ProfileMux) handleRevokeAllLoginSessions(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil {
+		response.HandleErrorResponse(w, "Could not get user data", http.StatusUnauthorized, r, err)
+		return
+	}
+
+	err = mux.Services.LoginSessionService.RevokeUserSessions(r.Context(), user.Id, 0)
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to log out everywhere", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	rlog.Logger(r).Info().Msg("All login sessions revoked")
+
+	utils.DeleteCookie(w, r, utils.CookieNameAuthorization)
+	response.HandleRedirect(w, r, "/auth/user/login")
 }
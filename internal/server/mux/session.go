@@ -1,12 +1,14 @@
 package mux
 
 import (
-	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 
+	"github.com/CaribouBlue/mixtape/internal/config"
 	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/live"
+	"github.com/CaribouBlue/mixtape/internal/log/rlog"
 	"github.com/CaribouBlue/mixtape/internal/server/middleware"
 	"github.com/CaribouBlue/mixtape/internal/server/response"
 	"github.com/CaribouBlue/mixtape/internal/server/utils"
@@ -29,24 +31,9 @@ type SessionMuxOpts struct {
 type SessionMuxServices struct {
 	MuxServices
 	SessionServiceInitializer MuxServiceInitializer[*SessionMux, *core.SessionService]
-	sessionService            *core.SessionService
-	MusicServiceInitializer   MuxServiceInitializer[*SessionMux, *core.MusicService]
-	musicService              *core.MusicService
 	UserService               *core.UserService
-}
-
-func (services *SessionMuxServices) SessionService() (*core.SessionService, error) {
-	if services.sessionService == nil {
-		return nil, errors.New("session service not initialized")
-	}
-	return services.sessionService, nil
-}
-
-func (services *SessionMuxServices) MusicService() (*core.MusicService, error) {
-	if services.musicService == nil {
-		return nil, errors.New("music service not initialized")
-	}
-	return services.musicService, nil
+	CrewService               *core.CrewService
+	Broadcaster               *live.Broadcaster
 }
 
 func NewSessionMux(opts SessionMuxOpts, services SessionMuxServices, mw []middleware.Middleware, children []ChildMux) *SessionMux {
@@ -61,21 +48,16 @@ func NewSessionMux(opts SessionMuxOpts, services SessionMuxServices, mw []middle
 
 	mux.BeforeEachRequest = func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			var err error
-
-			mux.Services.musicService, err = mux.Services.MusicServiceInitializer(mux, r)
+			// The session service is built for each request, so it's kept in the request's context
+			// rather than on the mux, which is shared by concurrent requests.
+			sessionService, err := mux.Services.SessionServiceInitializer(mux, r)
 			if err != nil {
 				response.HandleErrorResponse(w, "Failed to init mux", http.StatusInternalServerError, r, err)
 				return
 			}
 
-			mux.Services.sessionService, err = mux.Services.SessionServiceInitializer(mux, r)
-			if err != nil {
-				response.HandleErrorResponse(w, "Failed to init mux", http.StatusInternalServerError, r, err)
-				return
-			}
-
-			next.ServeHTTP(w, r)
+			ctx := utils.SetContextValue(r.Context(), utils.SessionServiceCtxKey, sessionService)
+			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 
@@ -86,9 +68,17 @@ func NewSessionMux(opts SessionMuxOpts, services SessionMuxServices, mw []middle
 
 	mux.Handle("GET /{sessionId}", http.HandlerFunc(mux.handlePageSession))
 
+	mux.Handle("GET /{sessionId}/live", http.HandlerFunc(mux.handleLiveSession))
+	mux.Handle("GET /{sessionId}/players", http.HandlerFunc(mux.handleGetPlayers))
 	mux.Handle("GET /{sessionId}/phase-duration", http.HandlerFunc(mux.handleGetPhaseDuration))
 	mux.Handle("GET /{sessionId}/submission-search", http.HandlerFunc(mux.handleSearchSubmissions))
 
+	mux.Handle("GET /{sessionId}/dev-tools", http.HandlerFunc(mux.handleGetDevTools))
+	mux.Handle("POST /{sessionId}/fast-forward", http.HandlerFunc(mux.handleFastForwardSession))
+
+	mux.Handle("GET /{sessionId}/results/admin", http.HandlerFunc(mux.handleGetResultsAdmin))
+	mux.Handle("POST /{sessionId}/results/recompute", http.HandlerFunc(mux.handleRecomputeResults))
+
 	mux.Handle("POST /{sessionId}/player/me", http.HandlerFunc(mux.handleJoinSession))
 	mux.Handle("POST /{sessionId}/player/me/finalize-submissions", http.HandlerFunc(mux.handleFinalizeSubmissions))
 	mux.Handle("POST /{sessionId}/player/me/playlist", http.HandlerFunc(mux.handleCreatePlayerPlaylist))
@@ -101,6 +91,12 @@ func NewSessionMux(opts SessionMuxOpts, services SessionMuxServices, mw []middle
 	return mux
 }
 
+// sessionService returns the session service BeforeEachRequest built for the request.
+func (mux *SessionMux) sessionService(r *http.Request) *core.SessionService {
+	sessionService, _ := utils.ContextValue(r.Context(), utils.SessionServiceCtxKey)
+	return sessionService
+}
+
 func (mux *SessionMux) handlePageSessions(w http.ResponseWriter, r *http.Request) {
 	user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
 	if err != nil {
@@ -108,7 +104,7 @@ func (mux *SessionMux) handlePageSessions(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	sessions, err := mux.Services.sessionService.GetSessionsListForUser(user.Id)
+	sessions, err := mux.sessionService(r).GetSessionsListForUser(r.Context(), user.Id)
 	if err != nil {
 		response.HandleErrorResponse(w, "Failed to get sessions", http.StatusInternalServerError, r, err)
 		return
@@ -133,8 +129,20 @@ func (mux *SessionMux) handleCreateSession(w http.ResponseWriter, r *http.Reques
 
 	name := r.Form.Get("name")
 
-	session, err := mux.Services.sessionService.CreateSession(core.NewSessionEntity(name, user.Id))
+	crewId, err := strconv.ParseInt(r.Form.Get("crewId"), 10, 64)
 	if err != nil {
+		response.HandleErrorResponse(w, "Pick a crew for the session", http.StatusUnprocessableEntity, r, err)
+		return
+	}
+
+	session, err := mux.sessionService(r).CreateSession(r.Context(), core.NewSessionEntity(name, user.Id, core.WithSessionCrew(crewId)))
+	if err == core.ErrNotCrewAdmin {
+		response.HandleErrorResponse(w, "Only crew admins can make sessions for a crew", http.StatusForbidden, r, err)
+		return
+	} else if err == core.ErrCrewNotFound {
+		response.HandleErrorResponse(w, "Crew not found", http.StatusUnprocessableEntity, r, err)
+		return
+	} else if err != nil {
 		response.HandleErrorResponse(w, "Failed to create session", http.StatusInternalServerError, r, err)
 		return
 	}
@@ -149,12 +157,16 @@ func (mux *SessionMux) handlePageSessionMaker(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	if !user.IsAdmin {
-		response.HandleErrorResponse(w, "Forbidden", http.StatusForbidden, r, err)
+	crews, err := mux.Services.CrewService.GetManagedCrews(r.Context(), user)
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to get crews", http.StatusInternalServerError, r, err)
+		return
+	} else if len(crews) == 0 {
+		response.HandleErrorResponse(w, "Forbidden", http.StatusForbidden, r, nil)
 		return
 	}
 
-	response.HandleHtmlResponse(r, w, templates.SessionMakerPage(*user))
+	response.HandleHtmlResponse(r, w, templates.SessionMakerPage(*user, crews))
 }
 
 func (mux *SessionMux) handlePageSession(w http.ResponseWriter, r *http.Request) {
@@ -170,8 +182,20 @@ func (mux *SessionMux) handlePageSession(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	sessionView, err := mux.Services.sessionService.GetSessionView(sessionId, user.Id)
-	if err != nil {
+	err = mux.sessionService(r).CheckSessionAccess(r.Context(), sessionId, user)
+	if err == core.ErrSessionNotFound {
+		response.HandleErrorResponse(w, "Session not found", http.StatusNotFound, r, err)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to get session", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	sessionView, err := mux.sessionService(r).GetSessionView(r.Context(), sessionId, user.Id)
+	if err == core.ErrSessionNotFound {
+		response.HandleErrorResponse(w, "Session not found", http.StatusNotFound, r, err)
+		return
+	} else if err != nil {
 		response.HandleErrorResponse(w, "Failed to get session view", http.StatusInternalServerError, r, err)
 		return
 	}
@@ -180,7 +204,87 @@ func (mux *SessionMux) handlePageSession(w http.ResponseWriter, r *http.Request)
 	response.HandleHtmlResponse(r, w, component)
 }
 
+func (mux *SessionMux) handleLiveSession(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil {
+		response.HandleErrorResponse(w, "Could not get user data", http.StatusUnauthorized, r, err)
+		return
+	}
+
+	sessionId, err := strconv.ParseInt(r.PathValue("sessionId"), 10, 64)
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid session ID", http.StatusBadRequest, r, err)
+		return
+	}
+
+	err = mux.sessionService(r).CheckSessionAccess(r.Context(), sessionId, user)
+	if err == core.ErrSessionNotFound {
+		response.HandleErrorResponse(w, "Session not found", http.StatusNotFound, r, err)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to get session", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	conn, err := mux.Services.Broadcaster.Connect(sessionId)
+	if err == live.ErrTooManyConnections || err == live.ErrBroadcasterClosed {
+		w.Header().Set("Retry-After", "30")
+		response.HandleErrorResponse(w, "Live updates unavailable", http.StatusServiceUnavailable, r, err)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to connect to live updates", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	if err := conn.Serve(w, r); err != nil {
+		rlog.Logger(r).Debug().Err(err).Int64("sessionId", sessionId).Msg("Live session stream ended")
+	}
+}
+
+func (mux *SessionMux) handleGetPlayers(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil {
+		response.HandleErrorResponse(w, "Could not get user data", http.StatusUnauthorized, r, err)
+		return
+	}
+
+	sessionId, err := strconv.ParseInt(r.PathValue("sessionId"), 10, 64)
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid session ID", http.StatusBadRequest, r, err)
+		return
+	}
+
+	err = mux.sessionService(r).CheckSessionAccess(r.Context(), sessionId, user)
+	if err == core.ErrSessionNotFound {
+		response.HandleErrorResponse(w, "Session not found", http.StatusNotFound, r, err)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to get session", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	session, err := mux.sessionService(r).GetSessionData(r.Context(), sessionId)
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to get session", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	players, err := mux.sessionService(r).GetSessionPlayers(r.Context(), session)
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to get players", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	response.HandleHtmlResponse(r, w, templates.SessionPlayers(*session, *players, user.Id))
+}
+
 func (mux *SessionMux) handleSearchSubmissions(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil {
+		response.HandleErrorResponse(w, "Could not get user data", http.StatusUnauthorized, r, err)
+		return
+	}
+
 	sessionId, err := strconv.ParseInt(r.PathValue("sessionId"), 10, 64)
 	if err != nil {
 		response.HandleErrorResponse(w, "Invalid session ID", http.StatusBadRequest, r, err)
@@ -190,8 +294,11 @@ func (mux *SessionMux) handleSearchSubmissions(w http.ResponseWriter, r *http.Re
 	r.ParseForm()
 	query := r.Form.Get("query")
 
-	submissions, err := mux.Services.sessionService.SearchCandidateSubmissions(sessionId, query)
-	if err != nil {
+	submissions, err := mux.sessionService(r).SearchCandidateSubmissions(r.Context(), sessionId, user.Id, query)
+	if err == core.ErrSessionNotFound {
+		response.HandleErrorResponse(w, "Session not found", http.StatusNotFound, r, err)
+		return
+	} else if err != nil {
 		response.HandleErrorResponse(w, "Failed to search tracks", http.StatusInternalServerError, r, err)
 		return
 	}
@@ -215,8 +322,11 @@ func (mux *SessionMux) handleSubmitCandidate(w http.ResponseWriter, r *http.Requ
 	r.ParseForm()
 	trackId := r.Form.Get("trackId")
 
-	submission, err := mux.Services.sessionService.SubmitCandidate(sessionId, user.Id, trackId)
-	if err == core.ErrNoSubmissionsLeft {
+	submission, err := mux.sessionService(r).SubmitCandidate(r.Context(), sessionId, user.Id, trackId)
+	if err == core.ErrSessionNotFound {
+		response.HandleErrorResponse(w, "Session not found", http.StatusNotFound, r, err)
+		return
+	} else if err == core.ErrNoSubmissionsLeft {
 		response.HandleErrorResponse(w, "No submissions left", http.StatusUnprocessableEntity, r, err)
 		return
 	} else if err == core.ErrDuplicateSubmission {
@@ -227,7 +337,7 @@ func (mux *SessionMux) handleSubmitCandidate(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	session, err := mux.Services.sessionService.GetSessionView(sessionId, user.Id)
+	session, err := mux.sessionService(r).GetSessionView(r.Context(), sessionId, user.Id)
 	if err != nil {
 		response.HandleErrorResponse(w, "Failed to get session view", http.StatusInternalServerError, r, err)
 		return
@@ -250,13 +360,19 @@ func (mux *SessionMux) handleJoinSession(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	_, err = mux.Services.sessionService.JoinSession(sessionId, user.Id)
-	if err != nil {
+	_, err = mux.sessionService(r).JoinSession(r.Context(), sessionId, user.Id)
+	if err == core.ErrSessionNotFound {
+		response.HandleErrorResponse(w, "Session not found", http.StatusNotFound, r, err)
+		return
+	} else if err == core.ErrNotCrewMember {
+		response.HandleErrorResponse(w, "Only members of the session's crew can join it", http.StatusForbidden, r, err)
+		return
+	} else if err != nil {
 		response.HandleErrorResponse(w, "Failed to join session", http.StatusInternalServerError, r, err)
 		return
 	}
 
-	sessionView, err := mux.Services.sessionService.GetSessionView(sessionId, user.Id)
+	sessionView, err := mux.sessionService(r).GetSessionView(r.Context(), sessionId, user.Id)
 	if err != nil {
 		response.HandleErrorResponse(w, "Failed to get session view", http.StatusInternalServerError, r, err)
 		return
@@ -278,13 +394,16 @@ func (mux *SessionMux) handleFinalizeSubmissions(w http.ResponseWriter, r *http.
 		return
 	}
 
-	err = mux.Services.sessionService.FinalizePlayerSubmissions(sessionId, user.Id)
-	if err != nil {
+	err = mux.sessionService(r).FinalizePlayerSubmissions(r.Context(), sessionId, user.Id)
+	if err == core.ErrSessionNotFound {
+		response.HandleErrorResponse(w, "Session not found", http.StatusNotFound, r, err)
+		return
+	} else if err != nil {
 		response.HandleErrorResponse(w, "Failed to finalize submissions", http.StatusInternalServerError, r, err)
 		return
 	}
 
-	sessionView, err := mux.Services.sessionService.GetSessionView(sessionId, user.Id)
+	sessionView, err := mux.sessionService(r).GetSessionView(r.Context(), sessionId, user.Id)
 	if err != nil {
 		response.HandleErrorResponse(w, "Failed to get session view", http.StatusInternalServerError, r, err)
 		return
@@ -306,8 +425,14 @@ func (mux *SessionMux) handleCreatePlayerPlaylist(w http.ResponseWriter, r *http
 		return
 	}
 
-	player, err := mux.Services.sessionService.CreatePlayerPlaylist(sessionId, user.Id)
-	if err != nil {
+	player, err := mux.sessionService(r).CreatePlayerPlaylist(r.Context(), sessionId, user.Id)
+	if err == core.ErrSessionNotFound {
+		response.HandleErrorResponse(w, "Session not found", http.StatusNotFound, r, err)
+		return
+	} else if err == core.ErrPlayerNotFound {
+		response.HandleErrorResponse(w, "Join the session to get its playlist", http.StatusForbidden, r, err)
+		return
+	} else if err != nil {
 		response.HandleErrorResponse(w, "Failed to create player playlist", http.StatusInternalServerError, r, err)
 		return
 	}
@@ -316,13 +441,28 @@ func (mux *SessionMux) handleCreatePlayerPlaylist(w http.ResponseWriter, r *http
 }
 
 func (mux *SessionMux) handleGetPhaseDuration(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil {
+		response.HandleErrorResponse(w, "Could not get user data", http.StatusUnauthorized, r, err)
+		return
+	}
+
 	sessionId, err := strconv.ParseInt(r.PathValue("sessionId"), 10, 64)
 	if err != nil {
 		response.HandleErrorResponse(w, "Invalid session ID", http.StatusBadRequest, r, err)
 		return
 	}
 
-	session, err := mux.Services.sessionService.GetSessionData(sessionId)
+	err = mux.sessionService(r).CheckSessionAccess(r.Context(), sessionId, user)
+	if err == core.ErrSessionNotFound {
+		response.HandleErrorResponse(w, "Session not found", http.StatusNotFound, r, err)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to get session", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	session, err := mux.sessionService(r).GetSessionData(r.Context(), sessionId)
 	if err != nil {
 		response.HandleErrorResponse(w, "Failed to get session", http.StatusInternalServerError, r, err)
 		return
@@ -350,13 +490,16 @@ func (mux *SessionMux) handleRemoveCandidate(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	err = mux.Services.sessionService.RemoveCandidate(sessionId, user.Id, candidateId)
-	if err != nil {
+	err = mux.sessionService(r).RemoveCandidate(r.Context(), sessionId, user.Id, candidateId)
+	if err == core.ErrSessionNotFound {
+		response.HandleErrorResponse(w, "Session not found", http.StatusNotFound, r, err)
+		return
+	} else if err != nil {
 		response.HandleErrorResponse(w, "Failed to delete submission", http.StatusInternalServerError, r, err)
 		return
 	}
 
-	session, err := mux.Services.sessionService.GetSessionView(sessionId, user.Id)
+	session, err := mux.sessionService(r).GetSessionView(r.Context(), sessionId, user.Id)
 	if err != nil {
 		response.HandleErrorResponse(w, "Failed to get session view", http.StatusInternalServerError, r, err)
 		return
@@ -386,8 +529,11 @@ func (mux *SessionMux) handleCreateCandidateVote(w http.ResponseWriter, r *http.
 		return
 	}
 
-	candidate, err := mux.Services.sessionService.VoteForCandidate(sessionId, user.Id, candidateId)
-	if err == core.ErrNoVotesLeft {
+	candidate, err := mux.sessionService(r).VoteForCandidate(r.Context(), sessionId, user.Id, candidateId)
+	if err == core.ErrSessionNotFound {
+		response.HandleErrorResponse(w, "Session not found", http.StatusNotFound, r, err)
+		return
+	} else if err == core.ErrNoVotesLeft {
 		w.Header().Add("HX-Reswap", "innerHTML")
 		response.HandleErrorResponse(w, "No votes left", http.StatusUnprocessableEntity, r, err)
 		return
@@ -419,8 +565,11 @@ func (mux *SessionMux) handleDeleteCandidateVote(w http.ResponseWriter, r *http.
 		return
 	}
 
-	candidate, err := mux.Services.sessionService.RemoveVoteForCandidate(sessionId, user.Id, candidateId)
-	if err != nil {
+	candidate, err := mux.sessionService(r).RemoveVoteForCandidate(r.Context(), sessionId, user.Id, candidateId)
+	if err == core.ErrSessionNotFound {
+		response.HandleErrorResponse(w, "Session not found", http.StatusNotFound, r, err)
+		return
+	} else if err != nil {
 		response.HandleErrorResponse(w, "Failed to remove vote", http.StatusInternalServerError, r, err)
 		return
 	}
@@ -428,3 +577,152 @@ func (mux *SessionMux) handleDeleteCandidateVote(w http.ResponseWriter, r *http.
 	w.Header().Add("HX-Trigger", serverUtils.EventDeleteVote)
 	templates.CandidateBallot(*candidate, true).Render(r.Context(), w)
 }
+
+func isDevToolsEnabled(user *core.UserEntity) bool {
+	return user.IsAdmin && config.GetConfigValue(config.ConfEnv) == config.EnvDevelopment
+}
+
+func (mux *SessionMux) handleGetDevTools(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil {
+		response.HandleErrorResponse(w, "Could not get user data", http.StatusUnauthorized, r, err)
+		return
+	}
+
+	if !isDevToolsEnabled(user) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	sessionId, err := strconv.ParseInt(r.PathValue("sessionId"), 10, 64)
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid session ID", http.StatusBadRequest, r, err)
+		return
+	}
+
+	session, err := mux.sessionService(r).GetSessionData(r.Context(), sessionId)
+	if err != nil {
+		response.HandleErrorResponse(w, "Failed to get session", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	response.HandleHtmlResponse(r, w, templates.SessionDevTools(*session))
+}
+
+func (mux *SessionMux) handleFastForwardSession(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil {
+		response.HandleErrorResponse(w, "Could not get user data", http.StatusUnauthorized, r, err)
+		return
+	}
+
+	if !isDevToolsEnabled(user) {
+		response.HandleErrorResponse(w, "Forbidden", http.StatusForbidden, r, err)
+		return
+	}
+
+	sessionId, err := strconv.ParseInt(r.PathValue("sessionId"), 10, 64)
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid session ID", http.StatusBadRequest, r, err)
+		return
+	}
+
+	r.ParseForm()
+	phase := core.SessionPhase(r.Form.Get("phase"))
+
+	_, err = mux.sessionService(r).FastForwardSession(r.Context(), sessionId, phase)
+	if err == core.ErrInvalidFastForwardPhase || err == core.ErrSessionPhaseAlreadyReached {
+		response.HandleErrorResponse(w, err.Error(), http.StatusUnprocessableEntity, r, err)
+		return
+	} else if err == core.ErrSessionNotFound {
+		response.HandleErrorResponse(w, "Session not found", http.StatusNotFound, r, err)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to fast-forward session", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	response.HandleRedirect(w, r, fmt.Sprintf("/app/session/%d", sessionId))
+}
+
+// checkSessionCrewAdmin checks that the user can see the session and is an admin of its crew.
+func (mux *SessionMux) checkSessionCrewAdmin(r *http.Request, sessionId int64, user *core.UserEntity) error {
+	if err := mux.sessionService(r).CheckSessionAccess(r.Context(), sessionId, user); err != nil {
+		return err
+	}
+
+	session, err := mux.sessionService(r).GetSessionData(r.Context(), sessionId)
+	if err != nil {
+		return err
+	}
+
+	return mux.Services.CrewService.CheckCrewAdmin(r.Context(), session.CrewId, user)
+}
+
+func (mux *SessionMux) handleGetResultsAdmin(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil {
+		response.HandleErrorResponse(w, "Could not get user data", http.StatusUnauthorized, r, err)
+		return
+	}
+
+	sessionId, err := strconv.ParseInt(r.PathValue("sessionId"), 10, 64)
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid session ID", http.StatusBadRequest, r, err)
+		return
+	}
+
+	// The actions are only shown to the admins of the session's crew.
+	err = mux.checkSessionCrewAdmin(r, sessionId, user)
+	if err == core.ErrNotCrewAdmin || err == core.ErrSessionNotFound {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to get session", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	response.HandleHtmlResponse(r, w, templates.ResultsAdminActions(sessionId))
+}
+
+func (mux *SessionMux) handleRecomputeResults(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+	if err != nil {
+		response.HandleErrorResponse(w, "Could not get user data", http.StatusUnauthorized, r, err)
+		return
+	}
+
+	sessionId, err := strconv.ParseInt(r.PathValue("sessionId"), 10, 64)
+	if err != nil {
+		response.HandleErrorResponse(w, "Invalid session ID", http.StatusBadRequest, r, err)
+		return
+	}
+
+	err = mux.checkSessionCrewAdmin(r, sessionId, user)
+	if err == core.ErrSessionNotFound {
+		response.HandleErrorResponse(w, "Session not found", http.StatusNotFound, r, err)
+		return
+	} else if err == core.ErrNotCrewAdmin {
+		response.HandleErrorResponse(w, "Only crew admins can recompute results", http.StatusForbidden, r, err)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to get session", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	_, err = mux.sessionService(r).RecomputeSessionResults(r.Context(), sessionId)
+	if err == core.ErrSessionNotInResultPhase {
+		response.HandleErrorResponse(w, err.Error(), http.StatusUnprocessableEntity, r, err)
+		return
+	} else if err == core.ErrSessionNotFound {
+		response.HandleErrorResponse(w, "Session not found", http.StatusNotFound, r, err)
+		return
+	} else if err != nil {
+		response.HandleErrorResponse(w, "Failed to recompute results", http.StatusInternalServerError, r, err)
+		return
+	}
+
+	rlog.Logger(r).Info().Int64("sessionId", sessionId).Msg("Session results recomputed")
+
+	response.HandleRedirect(w, r, fmt.Sprintf("/app/session/%d", sessionId))
+}
@@ -16,13 +16,38 @@ func HandleErrorResponse(w http.ResponseWriter, msg string, statusCode int, r *h
 }
 
 func HandleJsonResponse(w http.ResponseWriter, data interface{}) {
+	HandleJsonStatusResponse(w, http.StatusOK, data)
+}
+
+func HandleJsonStatusResponse(w http.ResponseWriter, statusCode int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(statusCode)
 	if err := json.NewEncoder(w).Encode(data); err != nil {
 		http.Error(w, "Failed to encode data", http.StatusInternalServerError)
 	}
 }
 
+// JsonError is the body of every JSON error response. Code is a stable identifier clients can
+// branch on, Message is meant for people.
+type JsonError struct {
+	Error JsonErrorDetail `json:"error"`
+}
+
+type JsonErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func HandleJsonErrorResponse(w http.ResponseWriter, r *http.Request, statusCode int, code string, msg string, err error) {
+	if statusCode >= http.StatusInternalServerError {
+		rlog.Logger(r).Error().Err(err).Str("code", code).Msg(msg)
+	} else {
+		rlog.Logger(r).Warn().Err(err).Str("code", code).Msg(msg)
+	}
+
+	HandleJsonStatusResponse(w, statusCode, JsonError{Error: JsonErrorDetail{Code: code, Message: msg}})
+}
+
 func HandleHtmlResponse(r *http.Request, w http.ResponseWriter, component templ.Component) {
 	w.Header().Set("Content-Type", "text/html")
 	w.WriteHeader(http.StatusOK)
@@ -1,41 +1,186 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"path/filepath"
 
+	"github.com/CaribouBlue/mixtape/internal/accesstoken"
+	"github.com/CaribouBlue/mixtape/internal/account"
 	"github.com/CaribouBlue/mixtape/internal/config"
 	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/featureflag"
+	"github.com/CaribouBlue/mixtape/internal/invitation"
+	"github.com/CaribouBlue/mixtape/internal/live"
+	mlog "github.com/CaribouBlue/mixtape/internal/log"
+	"github.com/CaribouBlue/mixtape/internal/loginlimit"
+	"github.com/CaribouBlue/mixtape/internal/loginsession"
 	"github.com/CaribouBlue/mixtape/internal/mail"
+	"github.com/CaribouBlue/mixtape/internal/notification"
+	"github.com/CaribouBlue/mixtape/internal/scheduler"
 	"github.com/CaribouBlue/mixtape/internal/server/middleware"
 	"github.com/CaribouBlue/mixtape/internal/server/mux"
 	"github.com/CaribouBlue/mixtape/internal/server/response"
 	"github.com/CaribouBlue/mixtape/internal/server/utils"
 	"github.com/CaribouBlue/mixtape/internal/spotify"
 	"github.com/CaribouBlue/mixtape/internal/storage"
+	"github.com/CaribouBlue/mixtape/internal/webhook"
 )
 
-func NewServer() *http.Server {
+// Server is the HTTP server along with the background work that shuts down with it.
+type Server struct {
+	*http.Server
+	scheduler *scheduler.Scheduler
+	events    *core.EventBus
+	live      *live.Broadcaster
+	db        *storage.SqliteStore
+}
+
+// Shutdown stops accepting requests, waits for in-flight requests, jobs and event subscribers, then
+// closes the database.
+func (s *Server) Shutdown(ctx context.Context) error {
+	// Live streams never finish on their own, so they're closed before waiting on requests.
+	s.live.Close()
+	err := s.Server.Shutdown(ctx)
+
+	if schedulerErr := s.scheduler.Shutdown(ctx); schedulerErr != nil {
+		err = errors.Join(err, schedulerErr)
+	}
+
+	if eventsErr := s.events.Wait(ctx); eventsErr != nil {
+		err = errors.Join(err, eventsErr)
+	}
+
+	if dbErr := s.db.Close(); dbErr != nil {
+		err = errors.Join(err, dbErr)
+	}
+
+	return err
+}
+
+func NewServer() *Server {
 	// Initialize DB
 	dbPath := config.GetConfigValue(config.ConfDbPath)
-	db, err := storage.NewSqliteDb(dbPath)
+	db, err := storage.NewSqliteDb(dbPath,
+		storage.WithJournalMode(config.GetConfigValue(config.ConfSqliteJournalMode)),
+		storage.WithBusyTimeout(config.GetConfigDuration(config.ConfSqliteBusyTimeout)),
+		storage.WithForeignKeys(config.GetConfigBool(config.ConfSqliteForeignKeys)),
+		storage.WithSynchronous(config.GetConfigValue(config.ConfSqliteSynchronous)),
+		storage.WithMaxReaderConns(config.GetConfigInt(config.ConfSqliteMaxReaderConns)),
+		storage.WithQueryTimeout(config.GetConfigDuration(config.ConfSqliteQueryTimeout)),
+	)
 	if err != nil {
 		log.Fatal("Error creating SQLite DB:", err)
 	}
 
+	spotify.SetRequestTimeout(config.GetConfigDuration(config.ConfSpotifyRequestTimeout))
+
 	// Initialize Mailer
-	mailer, err := mail.NewGmailMailer(
-		config.GetConfigValue(config.ConfGmailUsername),
-		config.GetConfigValue(config.ConfGmailPassword),
-	)
+	mailer, err := newMailer()
 	if err != nil {
-		log.Fatal("Error creating Gmail mailer:", err)
+		log.Fatal("Error creating mailer:", err)
 	}
 
 	// Initialize services
-	userService := core.NewUserService(db)
-	_ = mail.NewMailService(mailer)
+	events := core.NewEventBus(
+		core.WithAsyncErrorHandler(func(event core.Event, err error) {
+			mlog.Logger().Error().Err(err).Str("event", string(event.EventName())).Msg("Event subscriber failed")
+		}),
+	)
+	events.SubscribeAsync(core.AllEvents, func(ctx context.Context, event core.Event) error {
+		mlog.Logger().Debug().Str("event", string(event.EventName())).Msg("Domain event published")
+		return nil
+	})
+
+	userService := core.NewUserService(db, core.WithEventBus(events))
+	mailService := mail.NewMailService(mailer, db,
+		mail.WithMaxAttempts(config.GetConfigInt(config.ConfMailMaxAttempts)),
+	)
+
+	webhookService := webhook.NewWebhookService(db,
+		webhook.WithBaseUrl(config.GetConfigValue(config.ConfAppBaseUrl)),
+		webhook.WithMaxAttempts(config.GetConfigInt(config.ConfWebhookMaxAttempts)),
+		webhook.WithRequestTimeout(config.GetConfigDuration(config.ConfWebhookRequestTimeout)),
+	)
+	webhookService.Subscribe(events)
+
+	broadcaster := live.NewBroadcaster(db,
+		live.WithMaxConnections(config.GetConfigInt(config.ConfLiveMaxConnections)),
+		live.WithMaxConnectionsPerSession(config.GetConfigInt(config.ConfLiveMaxSessionConnections)),
+		live.WithHeartbeatInterval(config.GetConfigDuration(config.ConfLiveHeartbeatInterval)),
+		live.WithPollInterval(config.GetConfigDuration(config.ConfLivePollInterval)),
+	)
+
+	accessTokenService := accesstoken.NewAccessTokenService(db,
+		accesstoken.WithMaxTokensPerUser(config.GetConfigInt(config.ConfAccessTokenMaxPerUser)),
+	)
+
+	loginSessionService := loginsession.NewLoginSessionService(db,
+		loginsession.WithIdleTimeout(config.GetConfigDuration(config.ConfLoginSessionIdleTimeout)),
+	)
+	loginSessionService.Subscribe(events)
+
+	var loginLimitRepository loginlimit.Repository = db
+	if config.GetConfigValue(config.ConfLoginLimiterStore) == "memory" {
+		loginLimitRepository = loginlimit.NewMemoryRepository()
+	}
+	loginLimiter := loginlimit.NewLimiter(loginLimitRepository,
+		loginlimit.WithFreeAttempts(config.GetConfigInt(config.ConfLoginFreeAttempts)),
+		loginlimit.WithLockoutThreshold(config.GetConfigInt(config.ConfLoginLockoutThreshold)),
+		loginlimit.WithLockoutDuration(config.GetConfigDuration(config.ConfLoginLockoutDuration)),
+	)
+
+	accountService := account.NewAccountService(
+		account.AccountServiceOpts{
+			BaseUrl:                 config.GetConfigValue(config.ConfAppBaseUrl),
+			EmailVerificationTTL:    config.GetConfigDuration(config.ConfEmailVerificationTTL),
+			PasswordResetTTL:        config.GetConfigDuration(config.ConfPasswordResetTTL),
+			MagicLinkTTL:            config.GetConfigDuration(config.ConfMagicLinkTTL),
+			MagicLinkRateLimit:      config.GetConfigInt(config.ConfMagicLinkRateLimit),
+			MagicLinkRateWindow:     config.GetConfigDuration(config.ConfMagicLinkRateWindow),
+			PasswordResetRateLimit:  config.GetConfigInt(config.ConfPasswordResetRateLimit),
+			PasswordResetRateWindow: config.GetConfigDuration(config.ConfPasswordResetRateWindow),
+			IpRateLimit:             config.GetConfigInt(config.ConfAccountEmailIpRateLimit),
+		},
+		db,
+		db,
+		userService,
+		mailService,
+	)
+
+	jobScheduler := scheduler.NewScheduler(db,
+		scheduler.WithPollInterval(config.GetConfigDuration(config.ConfSchedulerPollInterval)),
+		scheduler.WithLeaseDuration(config.GetConfigDuration(config.ConfSchedulerLeaseDuration)),
+		scheduler.WithConcurrency(config.GetConfigInt(config.ConfSchedulerConcurrency)),
+	)
+
+	notifier := notification.NewNotifier(
+		notification.NotifierOpts{
+			BaseUrl:          config.GetConfigValue(config.ConfAppBaseUrl),
+			MaxDelay:         config.GetConfigDuration(config.ConfNotificationMaxDelay),
+			ReminderLeadTime: config.GetConfigDuration(config.ConfReminderLeadTime),
+			FreezeRetryDelay: config.GetConfigDuration(config.ConfResultsFreezeRetryDelay),
+		},
+		db,
+		db,
+		db,
+		userService,
+		mailService,
+		events,
+		newUserMusicService,
+	)
+
+	// Admin and profile pages don't touch Spotify, so this session service doesn't act for a user.
+	crewService := core.NewCrewService(db, db, userService)
+	appSessionService := core.NewSessionService(db, userService, crewService, core.NewMusicService(spotify.NewDefaultClient()), core.WithEventBus(events), core.WithTransactor(db))
+
+	invitationService := invitation.NewInvitationService(db, db, userService,
+		invitation.WithUserQuota(config.GetConfigInt(config.ConfInvitationUserQuota)),
+		invitation.WithUserMaxTTL(config.GetConfigDuration(config.ConfInvitationUserMaxTTL)),
+	)
 
 	// Initialize server
 	host := config.GetConfigValue(config.ConfHost)
@@ -52,7 +197,8 @@ func NewServer() *http.Server {
 		},
 		[]middleware.Middleware{
 			middleware.WithUser(middleware.WithUserOpts{
-				UserService: userService,
+				UserService:         userService,
+				LoginSessionService: loginSessionService,
 			}),
 			middleware.WithRequestMetadata(),
 			middleware.WithRequestLogging(),
@@ -79,7 +225,11 @@ func NewServer() *http.Server {
 					LoginSuccessPath: "/app/home",
 				},
 				mux.AuthMuxServices{
-					UserService: userService,
+					UserService:         userService,
+					AccountService:      accountService,
+					InvitationService:   invitationService,
+					LoginSessionService: loginSessionService,
+					LoginLimiter:        loginLimiter,
 				},
 				[]middleware.Middleware{
 					middleware.WithSpotifyClient(),
@@ -89,13 +239,47 @@ func NewServer() *http.Server {
 				},
 				[]mux.ChildMux{},
 			),
+			mux.NewApiMux(
+				mux.ApiMuxOpts{
+					MuxOpts: mux.MuxOpts{
+						PathPrefix: "/api/v1",
+					},
+					BaseUrl: config.GetConfigValue(config.ConfAppBaseUrl),
+				},
+				mux.ApiMuxServices{
+					SessionServiceInitializer: func(mux *mux.ApiMux, r *http.Request) (*core.SessionService, error) {
+						user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+						if err != nil {
+							return nil, err
+						}
+
+						musicService, err := newUserMusicService(r.Context(), user)
+						if err != nil {
+							return nil, err
+						}
+
+						return core.NewSessionService(db, mux.Services.UserService, crewService, musicService, core.WithEventBus(events), core.WithTransactor(db)), nil
+					},
+					UserService: userService,
+					CrewService: crewService,
+				},
+				[]middleware.Middleware{
+					middleware.WithAccessToken(middleware.WithAccessTokenOpts{
+						AccessTokenService: accessTokenService,
+						UserService:        userService,
+					}),
+				},
+				[]mux.ChildMux{},
+			),
 			mux.NewAppMux(
 				mux.AppMuxOpts{
 					MuxOpts: mux.MuxOpts{
 						PathPrefix: "/app",
 					},
 				},
-				mux.AppMuxServices{},
+				mux.AppMuxServices{
+					CrewService: crewService,
+				},
 				[]middleware.Middleware{
 					middleware.WithEnforcedAuthentication(middleware.WithEnforcedAuthenticationOpts{
 						UnauthenticatedRedirectPath: "/auth/login",
@@ -115,40 +299,75 @@ func NewServer() *http.Server {
 						},
 						mux.SessionMuxServices{
 							SessionServiceInitializer: func(mux *mux.SessionMux, r *http.Request) (*core.SessionService, error) {
-								musicService, err := mux.Services.MusicService()
+								user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
 								if err != nil {
 									return nil, err
 								}
 
-								return core.NewSessionService(db, mux.Services.UserService, musicService), nil
-							},
-							MusicServiceInitializer: func(mux *mux.SessionMux, r *http.Request) (*core.MusicService, error) {
-								user, err := utils.ContextValue(r.Context(), utils.UserCtxKey)
+								musicService, err := newUserMusicService(r.Context(), user)
 								if err != nil {
 									return nil, err
 								}
 
-								spotifyClient := spotify.NewDefaultClient()
-
-								// TODO: handle invalid token
-								if user.SpotifyToken != "" {
-									spotifyClient.Reauthenticate(user.SpotifyToken)
-								}
-
-								return core.NewMusicService(spotifyClient), nil
+								return core.NewSessionService(db, mux.Services.UserService, crewService, musicService, core.WithEventBus(events), core.WithTransactor(db)), nil
 							},
 							UserService: userService,
+							CrewService: crewService,
+							Broadcaster: broadcaster,
 						},
 						[]middleware.Middleware{},
 						[]mux.ChildMux{},
 					),
+					mux.NewCrewMux(
+						mux.CrewMuxOpts{
+							MuxOpts: mux.MuxOpts{
+								PathPrefix: "/crew",
+							},
+						},
+						mux.CrewMuxServices{
+							CrewService: crewService,
+						},
+						[]middleware.Middleware{},
+						[]mux.ChildMux{},
+					),
+					mux.NewAdminMux(
+						mux.AdminMuxOpts{
+							MuxOpts: mux.MuxOpts{
+								PathPrefix: "/admin",
+							},
+							BaseUrl: config.GetConfigValue(config.ConfAppBaseUrl),
+						},
+						mux.AdminMuxServices{
+							Scheduler:          jobScheduler,
+							WebhookService:     webhookService,
+							UserService:        userService,
+							SessionService:     appSessionService,
+							FeatureFlagService: featureflag.NewFeatureFlagService(featureflag.NewJsonFeatureFlagRepository()),
+							RecentErrors:       mlog.DefaultRecentErrors(),
+							InvitationService:  invitationService,
+							LoginLimiter:       loginLimiter,
+						},
+						[]middleware.Middleware{
+							middleware.WithEnforcedAdmin(middleware.WithEnforcedAdminOpts{
+								ForbiddenRedirectPath: "/app/home",
+							}),
+						},
+						[]mux.ChildMux{},
+					),
 					mux.NewProfileMux(
 						mux.ProfileMuxOpts{
 							MuxOpts: mux.MuxOpts{
 								PathPrefix: "/profile",
 							},
+							BaseUrl: config.GetConfigValue(config.ConfAppBaseUrl),
+						},
+						mux.ProfileMuxServices{
+							AccessTokenService:  accessTokenService,
+							UserService:         userService,
+							SessionService:      appSessionService,
+							InvitationService:   invitationService,
+							LoginSessionService: loginSessionService,
 						},
-						mux.ProfileMuxServices{},
 						[]middleware.Middleware{},
 						[]mux.ChildMux{},
 					),
@@ -162,5 +381,76 @@ func NewServer() *http.Server {
 		Handler: rootMuxHandler,
 	}
 
-	return server
+	err = notifier.RegisterJobs(context.Background(), jobScheduler, config.GetConfigDuration(config.ConfNotificationInterval))
+	if err != nil {
+		log.Fatal("Error scheduling notification jobs:", err)
+	}
+	err = mailService.RegisterJobs(context.Background(), jobScheduler, config.GetConfigDuration(config.ConfMailOutboxInterval))
+	if err != nil {
+		log.Fatal("Error scheduling mail jobs:", err)
+	}
+	err = webhookService.RegisterJobs(context.Background(), jobScheduler, config.GetConfigDuration(config.ConfWebhookDeliveryInterval))
+	if err != nil {
+		log.Fatal("Error scheduling webhook jobs:", err)
+	}
+	jobScheduler.Start()
+	broadcaster.Start()
+
+	return &Server{
+		Server:    server,
+		scheduler: jobScheduler,
+		events:    events,
+		live:      broadcaster,
+		db:        db,
+	}
+}
+
+func newUserMusicService(ctx context.Context, user *core.UserEntity) (*core.MusicService, error) {
+	spotifyClient := spotify.NewDefaultClient()
+
+	// TODO: handle invalid token
+	if user.SpotifyToken != "" {
+		spotifyClient.Reauthenticate(ctx, user.SpotifyToken)
+	}
+
+	return core.NewMusicService(spotifyClient), nil
+}
+
+func newMailer() (mail.Mailer, error) {
+	from := config.GetConfigValue(config.ConfMailFrom)
+
+	switch config.GetConfigValue(config.ConfMailer) {
+	case config.MailerSmtp:
+		return mail.NewSmtpMailer(mail.SmtpOpts{
+			Host:      config.GetConfigValue(config.ConfSmtpHost),
+			Port:      config.GetConfigInt(config.ConfSmtpPort),
+			Username:  config.GetConfigValue(config.ConfSmtpUsername),
+			Password:  config.GetConfigValue(config.ConfSmtpPassword),
+			From:      from,
+			TLSPolicy: mail.TLSPolicy(config.GetConfigValue(config.ConfSmtpTLSPolicy)),
+		})
+	case config.MailerGmail:
+		return mail.NewGmailMailer(
+			config.GetConfigValue(config.ConfGmailUsername),
+			config.GetConfigValue(config.ConfGmailPassword),
+		)
+	case config.MailerFile:
+		dir := config.GetConfigValue(config.ConfMailFileDir)
+		if dir == "" {
+			dir = filepath.Join(config.GetConfigValue(config.ConfAppDataPath), "mail")
+		}
+		return mail.NewFileMailer(dir, from)
+	case config.MailerNoop:
+		return mail.NewNoopMailer(), nil
+	}
+
+	if config.GetConfigValue(config.ConfGmailUsername) != "" {
+		return mail.NewGmailMailer(
+			config.GetConfigValue(config.ConfGmailUsername),
+			config.GetConfigValue(config.ConfGmailPassword),
+		)
+	}
+
+	mlog.Logger().Warn().Msg("No mailer configured, emails will be discarded")
+	return mail.NewNoopMailer(), nil
 }
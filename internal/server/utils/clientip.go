@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+
+	"github.com/CaribouBlue/mixtape/internal/config"
+)
+
+// trustedProxies are the reverse proxies, configured by TRUSTED_PROXIES, whose forwarded headers
+// are believed. The setting is validated when config is loaded.
+var trustedProxies = sync.OnceValue(func() []netip.Prefix {
+	return parseTrustedProxies(config.GetConfigValue(config.ConfTrustedProxies))
+})
+
+func parseTrustedProxies(value string) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0)
+	for _, proxy := range strings.Split(value, ",") {
+		proxy = strings.TrimSpace(proxy)
+		if prefix, err := netip.ParsePrefix(proxy); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+		} else if addr, err := netip.ParseAddr(proxy); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+		}
+	}
+	return prefixes
+}
+
+func isTrustedProxy(proxies []netip.Prefix, ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range proxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIp returns the IP address the request came from. Behind a trusted proxy that's taken from
+// X-Forwarded-For, the last address in it that isn't another trusted proxy, or else X-Real-IP.
+// Anyone else could put anything in those headers, so they're ignored for direct requests.
+func ClientIp(r *http.Request) string {
+	return clientIp(r, trustedProxies())
+}
+
+func clientIp(r *http.Request, proxies []netip.Prefix) string {
+	peer, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		peer = r.RemoteAddr
+	}
+	if !isTrustedProxy(proxies, peer) {
+		return peer
+	}
+
+	// Proxies append the address they got the request from, so the client is the first one from
+	// the right that wasn't added by a trusted proxy.
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	client := ""
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := strings.TrimSpace(forwarded[i])
+		if ip == "" {
+			continue
+		}
+		client = ip
+		if !isTrustedProxy(proxies, ip) {
+			break
+		}
+	}
+	if client != "" {
+		return client
+	}
+
+	if realIp := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIp != "" {
+		return realIp
+	}
+	return peer
+}
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"net/http"
 
+	"github.com/CaribouBlue/mixtape/internal/accesstoken"
 	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/loginsession"
 	"github.com/CaribouBlue/mixtape/internal/spotify"
 	"github.com/google/uuid"
 )
@@ -22,6 +24,13 @@ var (
 	UserCtxKey            = ContextKey[*core.UserEntity]{"user"}
 	SpotifyClientCtxKey   = ContextKey[*spotify.Client]{"spotify_client"}
 	RequestMetaDataCtxKey = ContextKey[*RequestMetadata]{"request_meta_data"}
+	// AccessTokenCtxKey holds the token a request was authenticated with, if any.
+	AccessTokenCtxKey = ContextKey[*accesstoken.AccessToken]{"access_token"}
+	// LoginSessionCtxKey holds the login session of a request authenticated by the auth cookie.
+	LoginSessionCtxKey = ContextKey[*loginsession.LoginSession]{"login_session"}
+	// SessionServiceCtxKey holds the session service built for a request, which depends on the
+	// user's Spotify client.
+	SessionServiceCtxKey = ContextKey[*core.SessionService]{"session_service"}
 )
 
 func ContextValue[T interface{}](ctx context.Context, key ContextKey[T]) (T, error) {
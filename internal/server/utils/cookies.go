@@ -7,7 +7,7 @@ import (
 	"time"
 
 	"github.com/CaribouBlue/mixtape/internal/config"
-	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/loginsession"
 	jwt "github.com/golang-jwt/jwt/v5"
 )
 
@@ -35,15 +35,18 @@ func CookieFactory(name CookieName, value string, maxAge int) *http.Cookie {
 	}
 }
 
-func SetAuthCookie(w http.ResponseWriter, u *core.UserEntity) error {
-	expirationDuration := time.Hour * 24
+// SetAuthCookie logs the browser in to the login session. The cookie carries the session's key as
+// its token ID (jti) and lasts as long as the session, so it's set again whenever the session is
+// extended.
+func SetAuthCookie(w http.ResponseWriter, session *loginsession.LoginSession) error {
 	secretKey := config.GetConfigValue(config.ConfJwtSecret)
 
 	// Create a new token object, specifying signing method and the claims
 	// you would like it to contain.
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userId":  u.Id,
-		"expires": time.Now().Add(expirationDuration).Unix(),
+		"userId": session.UserId,
+		"jti":    session.Key,
+		"exp":    session.ExpiresAt.Unix(),
 	})
 
 	// Sign and get the complete encoded token as a string using the secret
@@ -52,12 +55,20 @@ func SetAuthCookie(w http.ResponseWriter, u *core.UserEntity) error {
 		return err
 	}
 
-	http.SetCookie(w, CookieFactory(CookieNameAuthorization, tokenString, int(expirationDuration.Seconds())))
+	maxAge := int(time.Until(session.ExpiresAt).Seconds())
+	http.SetCookie(w, CookieFactory(CookieNameAuthorization, tokenString, maxAge))
 
 	return nil
 }
 
-func ParseAuthCookie(w http.ResponseWriter, r *http.Request) (*core.UserEntity, error) {
+// AuthCookieClaims are what the auth cookie says about who's logged in. They still have to be
+// checked against the login session, which may have been revoked.
+type AuthCookieClaims struct {
+	UserId     int64
+	SessionKey string
+}
+
+func ParseAuthCookie(w http.ResponseWriter, r *http.Request) (*AuthCookieClaims, error) {
 	secretKey := config.GetConfigValue(config.ConfJwtSecret)
 
 	cookie, err := r.Cookie(CookieNameAuthorization)
@@ -73,24 +84,33 @@ func ParseAuthCookie(w http.ResponseWriter, r *http.Request) (*core.UserEntity,
 
 		// hmacSampleSecret is a []byte containing your secret, e.g. []byte("my_secret_key")
 		return []byte(secretKey), nil
-	})
+	}, jwt.WithExpirationRequired())
 
-	if err != nil {
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		DeleteCookie(w, r, CookieNameAuthorization)
+		return nil, ErrTokenExpired
+	} else if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok {
-		expires := int64(claims["expires"].(float64))
-		if time.Now().Unix() > expires {
-			DeleteCookie(w, r, CookieNameAuthorization)
-			return nil, ErrTokenExpired
-		}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
 
-		userId := int64(claims["userId"].(float64))
-		return &core.UserEntity{Id: userId}, nil
-	} else {
+	// Cookies from before login sessions have no token ID and can't be revoked, so they're
+	// turned away.
+	userId, ok := claims["userId"].(float64)
+	sessionKey, _ := claims["jti"].(string)
+	if !ok || sessionKey == "" {
+		DeleteCookie(w, r, CookieNameAuthorization)
 		return nil, ErrInvalidToken
 	}
+
+	return &AuthCookieClaims{
+		UserId:     int64(userId),
+		SessionKey: sessionKey,
+	}, nil
 }
 
 func DeleteCookie(w http.ResponseWriter, r *http.Request, cookieName string) error {
@@ -1,6 +1,7 @@
 package spotify
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -64,7 +65,7 @@ func (token *AccessToken) IsExpired() bool {
 	return time.Now().After(token.CreatedAt.Add(time.Duration(token.ExpiresIn) * time.Second))
 }
 
-func GetAccessToken(opts AccessTokenRequestOptions) (*AccessToken, error) {
+func GetAccessToken(ctx context.Context, opts AccessTokenRequestOptions) (*AccessToken, error) {
 	response := &AccessToken{
 		RefreshToken: opts.RefreshToken,
 		CreatedAt:    time.Now(),
@@ -78,15 +79,14 @@ func GetAccessToken(opts AccessTokenRequestOptions) (*AccessToken, error) {
 
 	authHeader := "Basic " + base64.StdEncoding.EncodeToString([]byte(opts.ClientId+":"+opts.ClientSecret))
 
-	req, err := http.NewRequest("POST", "https://accounts.spotify.com/api/token", strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://accounts.spotify.com/api/token", strings.NewReader(data.Encode()))
 	if err != nil {
 		return response, err
 	}
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Add("Authorization", authHeader)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := do(req)
 	if err != nil {
 		return response, err
 	}
@@ -1,6 +1,7 @@
 package spotify
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/CaribouBlue/mixtape/internal/config"
@@ -47,8 +48,8 @@ func (s *Client) GetUserAuthUrl() (string, error) {
 	})
 }
 
-func (s *Client) Authenticate(code string) (AccessToken, error) {
-	newAccessToken, err := GetAccessToken(AccessTokenRequestOptions{
+func (s *Client) Authenticate(ctx context.Context, code string) (AccessToken, error) {
+	newAccessToken, err := GetAccessToken(ctx, AccessTokenRequestOptions{
 		Code:         code,
 		ClientId:     s.ClientId,
 		ClientSecret: s.ClientSecret,
@@ -59,11 +60,11 @@ func (s *Client) Authenticate(code string) (AccessToken, error) {
 		return AccessToken{}, err
 	}
 	s.accessToken = *newAccessToken
-	return s.GetValidAccessToken()
+	return s.GetValidAccessToken(ctx)
 }
 
-func (s *Client) refreshAccessToken() error {
-	newAccessToken, err := GetAccessToken(AccessTokenRequestOptions{
+func (s *Client) refreshAccessToken(ctx context.Context) error {
+	newAccessToken, err := GetAccessToken(ctx, AccessTokenRequestOptions{
 		RefreshToken: s.accessToken.RefreshToken,
 		ClientId:     s.ClientId,
 		ClientSecret: s.ClientSecret,
@@ -76,19 +77,19 @@ func (s *Client) refreshAccessToken() error {
 	return nil
 }
 
-func (s *Client) Reauthenticate(refreshToken string) (AccessToken, error) {
+func (s *Client) Reauthenticate(ctx context.Context, refreshToken string) (AccessToken, error) {
 	s.accessToken.RefreshToken = refreshToken
-	err := s.refreshAccessToken()
+	err := s.refreshAccessToken(ctx)
 	if err != nil {
 		return AccessToken{}, err
 	}
 
-	return s.GetValidAccessToken()
+	return s.GetValidAccessToken(ctx)
 }
 
-func (s *Client) GetValidAccessToken() (AccessToken, error) {
+func (s *Client) GetValidAccessToken(ctx context.Context) (AccessToken, error) {
 	if s.accessToken.IsExpired() {
-		err := s.refreshAccessToken()
+		err := s.refreshAccessToken(ctx)
 		if err != nil {
 			return s.accessToken, err
 		}
@@ -97,39 +98,39 @@ func (s *Client) GetValidAccessToken() (AccessToken, error) {
 	return s.accessToken, nil
 }
 
-func (s *Client) AuthenticateUser(user *core.UserEntity) error {
-	_, err := s.Reauthenticate(user.SpotifyToken)
+func (s *Client) AuthenticateUser(ctx context.Context, user *core.UserEntity) error {
+	_, err := s.Reauthenticate(ctx, user.SpotifyToken)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (s *Client) NewRequest(opts SpotifyRequestOptions) (*http.Request, error) {
-	accessToken, err := s.GetValidAccessToken()
+func (s *Client) NewRequest(ctx context.Context, opts SpotifyRequestOptions) (*http.Request, error) {
+	accessToken, err := s.GetValidAccessToken(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return newRequest(SpotifyRequestOptions{
+	return newRequest(ctx, SpotifyRequestOptions{
 		accessToken: accessToken,
 	})
 }
 
-func (s *Client) GetCurrentUserProfile() (*UserProfile, error) {
-	accessToken, err := s.GetValidAccessToken()
+func (s *Client) GetCurrentUserProfile(ctx context.Context) (*UserProfile, error) {
+	accessToken, err := s.GetValidAccessToken(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return getCurrentUserProfile(GetCurrentUserProfileRequestOptions{
+	return getCurrentUserProfile(ctx, GetCurrentUserProfileRequestOptions{
 		accessToken: accessToken,
 	})
 }
 
-func (s *Client) CurrentUser() *UserProfile {
+func (s *Client) CurrentUser(ctx context.Context) *UserProfile {
 	if s.currentUser == nil {
-		userProfile, err := s.GetCurrentUserProfile()
+		userProfile, err := s.GetCurrentUserProfile(ctx)
 		if err == nil {
 			s.currentUser = userProfile
 		}
@@ -137,13 +138,13 @@ func (s *Client) CurrentUser() *UserProfile {
 	return s.currentUser
 }
 
-func (s *Client) SearchTracks(query string) ([]core.TrackEntity, error) {
-	accessToken, err := s.GetValidAccessToken()
+func (s *Client) SearchTracks(ctx context.Context, query string) ([]core.TrackEntity, error) {
+	accessToken, err := s.GetValidAccessToken(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	results, err := getSearchResult(GetSearchResultRequestOptions{
+	results, err := getSearchResult(ctx, GetSearchResultRequestOptions{
 		accessToken: accessToken,
 		query:       query,
 		itemTypes:   []ItemType{TrackItemType},
@@ -168,13 +169,13 @@ func (s *Client) SearchTracks(query string) ([]core.TrackEntity, error) {
 	return tracks, nil
 }
 
-func (s *Client) GetTrackById(id string) (*core.TrackEntity, error) {
-	accessToken, err := s.GetValidAccessToken()
+func (s *Client) GetTrackById(ctx context.Context, id string) (*core.TrackEntity, error) {
+	accessToken, err := s.GetValidAccessToken(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	track, err := getTrack(GetTrackRequestOptions{
+	track, err := getTrack(ctx, GetTrackRequestOptions{
 		accessToken: accessToken,
 		id:          id,
 	})
@@ -194,15 +195,15 @@ func (s *Client) GetTrackById(id string) (*core.TrackEntity, error) {
 	}, nil
 }
 
-func (s *Client) CreatePlaylist(name string, trackIds []string) (*core.PlaylistEntity, error) {
-	accessToken, err := s.GetValidAccessToken()
+func (s *Client) CreatePlaylist(ctx context.Context, name string, trackIds []string) (*core.PlaylistEntity, error) {
+	accessToken, err := s.GetValidAccessToken(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	playlist, err := createPlaylist(CreatePlaylistRequestOptions{
+	playlist, err := createPlaylist(ctx, CreatePlaylistRequestOptions{
 		accessToken: accessToken,
-		userId:      s.CurrentUser().Id,
+		userId:      s.CurrentUser(ctx).Id,
 		name:        name,
 	})
 	if err != nil {
@@ -219,13 +220,13 @@ func (s *Client) CreatePlaylist(name string, trackIds []string) (*core.PlaylistE
 		return "spotify:track:" + id
 	})
 
-	err = addItemsToPlaylist(AddItemsToPlaylistRequestOptions{
+	err = addItemsToPlaylist(ctx, AddItemsToPlaylistRequestOptions{
 		accessToken: accessToken,
 		playlistId:  playlistEntity.Id,
 		uris:        uris,
 	})
 	if err != nil {
-		rollbackErr := unfollowPlaylist(UnfollowPlaylistRequestOptions{
+		rollbackErr := unfollowPlaylist(ctx, UnfollowPlaylistRequestOptions{
 			accessToken: accessToken,
 			playlistId:  playlistEntity.Id,
 		})
@@ -239,13 +240,13 @@ func (s *Client) CreatePlaylist(name string, trackIds []string) (*core.PlaylistE
 	return playlistEntity, nil
 }
 
-func (s *Client) GetPlaylistById(playlistId string) (*core.PlaylistEntity, error) {
-	accessToken, err := s.GetValidAccessToken()
+func (s *Client) GetPlaylistById(ctx context.Context, playlistId string) (*core.PlaylistEntity, error) {
+	accessToken, err := s.GetValidAccessToken(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	playlist, err := getPlaylist(GetPlaylistRequestOptions{
+	playlist, err := getPlaylist(ctx, GetPlaylistRequestOptions{
 		accessToken: accessToken,
 		playlistId:  playlistId,
 	})
@@ -2,6 +2,7 @@ package spotify
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -151,7 +152,7 @@ type CreatePlaylistRequestOptions struct {
 	collaborative bool
 }
 
-func createPlaylist(opts CreatePlaylistRequestOptions) (*Playlist, error) {
+func createPlaylist(ctx context.Context, opts CreatePlaylistRequestOptions) (*Playlist, error) {
 	var playlist *Playlist = &Playlist{}
 
 	if opts.userId == "" {
@@ -173,7 +174,7 @@ func createPlaylist(opts CreatePlaylistRequestOptions) (*Playlist, error) {
 	}
 	body := bytes.NewReader(jsonData)
 
-	req, err := newRequest(SpotifyRequestOptions{
+	req, err := newRequest(ctx, SpotifyRequestOptions{
 		method:      "POST",
 		path:        fmt.Sprintf("/users/%s/playlists", opts.userId),
 		accessToken: opts.accessToken,
@@ -183,8 +184,7 @@ func createPlaylist(opts CreatePlaylistRequestOptions) (*Playlist, error) {
 		return playlist, err
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := do(req)
 	if err != nil {
 		return playlist, err
 	}
@@ -209,7 +209,7 @@ type AddItemsToPlaylistRequestOptions struct {
 	position    int
 }
 
-func addItemsToPlaylist(opts AddItemsToPlaylistRequestOptions) error {
+func addItemsToPlaylist(ctx context.Context, opts AddItemsToPlaylistRequestOptions) error {
 	if opts.playlistId == "" {
 		return errors.New("playlist ID is required")
 	}
@@ -232,7 +232,7 @@ func addItemsToPlaylist(opts AddItemsToPlaylistRequestOptions) error {
 	}
 	body := bytes.NewReader(jsonData)
 
-	req, err := newRequest(SpotifyRequestOptions{
+	req, err := newRequest(ctx, SpotifyRequestOptions{
 		method:      "POST",
 		path:        fmt.Sprintf("/playlists/%s/tracks", opts.playlistId),
 		accessToken: opts.accessToken,
@@ -242,8 +242,7 @@ func addItemsToPlaylist(opts AddItemsToPlaylistRequestOptions) error {
 		return err
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := do(req)
 	if err != nil {
 		return err
 	}
@@ -260,14 +259,14 @@ type GetPlaylistRequestOptions struct {
 	playlistId  string
 }
 
-func getPlaylist(opts GetPlaylistRequestOptions) (*Playlist, error) {
+func getPlaylist(ctx context.Context, opts GetPlaylistRequestOptions) (*Playlist, error) {
 	var playlist Playlist
 
 	if opts.playlistId == "" {
 		return &playlist, errors.New("playlist ID is required")
 	}
 
-	req, err := newRequest(SpotifyRequestOptions{
+	req, err := newRequest(ctx, SpotifyRequestOptions{
 		method:      "GET",
 		path:        fmt.Sprintf("/playlists/%s", opts.playlistId),
 		accessToken: opts.accessToken,
@@ -277,8 +276,7 @@ func getPlaylist(opts GetPlaylistRequestOptions) (*Playlist, error) {
 		return &playlist, err
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := do(req)
 	if err != nil {
 		return &playlist, err
 	}
@@ -301,12 +299,12 @@ type UnfollowPlaylistRequestOptions struct {
 	playlistId  string
 }
 
-func unfollowPlaylist(opts UnfollowPlaylistRequestOptions) error {
+func unfollowPlaylist(ctx context.Context, opts UnfollowPlaylistRequestOptions) error {
 	if opts.playlistId == "" {
 		return errors.New("playlist ID is required")
 	}
 
-	req, err := newRequest(SpotifyRequestOptions{
+	req, err := newRequest(ctx, SpotifyRequestOptions{
 		method:      "DELETE",
 		path:        fmt.Sprintf("/playlists/%s/followers", opts.playlistId),
 		accessToken: opts.accessToken,
@@ -315,8 +313,7 @@ func unfollowPlaylist(opts UnfollowPlaylistRequestOptions) error {
 		return err
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := do(req)
 	if err != nil {
 		return err
 	}
@@ -1,11 +1,24 @@
 package spotify
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/log"
 )
 
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// SetRequestTimeout bounds every Spotify API call, including reading the response body.
+func SetRequestTimeout(timeout time.Duration) {
+	httpClient = &http.Client{Timeout: timeout}
+}
+
 type SpotifyRequestOptions struct {
 	accessToken AccessToken
 	method      string
@@ -13,9 +26,9 @@ type SpotifyRequestOptions struct {
 	body        io.Reader
 }
 
-func newRequest(opts SpotifyRequestOptions) (*http.Request, error) {
+func newRequest(ctx context.Context, opts SpotifyRequestOptions) (*http.Request, error) {
 	url := fmt.Sprintf("https://api.spotify.com/v1%s", opts.path)
-	req, err := http.NewRequest(opts.method, url, opts.body)
+	req, err := http.NewRequestWithContext(ctx, opts.method, url, opts.body)
 	if err != nil {
 		return nil, err
 	}
@@ -24,3 +37,15 @@ func newRequest(opts SpotifyRequestOptions) (*http.Request, error) {
 
 	return req, nil
 }
+
+func do(req *http.Request) (*http.Response, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		if ctxErr := req.Context().Err(); errors.Is(ctxErr, context.Canceled) {
+			log.Logger().Info().Str("method", req.Method).Str("path", req.URL.Path).Msg("Spotify request canceled")
+		} else if ctxErr != nil || os.IsTimeout(err) {
+			log.Logger().Warn().Err(err).Str("method", req.Method).Str("path", req.URL.Path).Msg("Spotify request timed out")
+		}
+	}
+	return resp, err
+}
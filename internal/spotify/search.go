@@ -1,6 +1,7 @@
 package spotify
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -357,7 +358,7 @@ type SearchResult struct {
 	} `json:"audiobooks"`
 }
 
-func getSearchResult(opts GetSearchResultRequestOptions) (*SearchResult, error) {
+func getSearchResult(ctx context.Context, opts GetSearchResultRequestOptions) (*SearchResult, error) {
 	var searchRequest SearchResult
 
 	params := netUrl.Values{}
@@ -391,7 +392,7 @@ func getSearchResult(opts GetSearchResultRequestOptions) (*SearchResult, error)
 		params.Add("include_external", string(opts.includeExternal))
 	}
 
-	req, err := newRequest(SpotifyRequestOptions{
+	req, err := newRequest(ctx, SpotifyRequestOptions{
 		method:      "GET",
 		path:        "/search?" + params.Encode(),
 		accessToken: opts.accessToken,
@@ -400,8 +401,7 @@ func getSearchResult(opts GetSearchResultRequestOptions) (*SearchResult, error)
 		return &searchRequest, err
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := do(req)
 	if err != nil {
 		return &searchRequest, err
 	}
@@ -1,6 +1,7 @@
 package spotify
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -89,7 +90,7 @@ type Track struct {
 	IsLocal     bool   `json:"is_local"`
 }
 
-func getTrack(opts GetTrackRequestOptions) (*Track, error) {
+func getTrack(ctx context.Context, opts GetTrackRequestOptions) (*Track, error) {
 	var track Track
 
 	if opts.id == "" {
@@ -102,7 +103,7 @@ func getTrack(opts GetTrackRequestOptions) (*Track, error) {
 		params.Add("market", opts.market)
 	}
 
-	req, err := newRequest(SpotifyRequestOptions{
+	req, err := newRequest(ctx, SpotifyRequestOptions{
 		method:      "GET",
 		path:        fmt.Sprintf("/tracks/%s?", opts.id) + params.Encode(),
 		accessToken: opts.accessToken,
@@ -111,8 +112,7 @@ func getTrack(opts GetTrackRequestOptions) (*Track, error) {
 		return &track, err
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := do(req)
 	if err != nil {
 		return &track, err
 	}
@@ -1,8 +1,8 @@
 package spotify
 
 import (
+	"context"
 	"encoding/json"
-	"net/http"
 )
 
 type GetCurrentUserProfileRequestOptions struct {
@@ -36,10 +36,10 @@ type UserProfile struct {
 	Uri     string `json:"uri"`
 }
 
-func getCurrentUserProfile(opts GetCurrentUserProfileRequestOptions) (*UserProfile, error) {
+func getCurrentUserProfile(ctx context.Context, opts GetCurrentUserProfileRequestOptions) (*UserProfile, error) {
 	var profile UserProfile
 
-	req, err := newRequest(SpotifyRequestOptions{
+	req, err := newRequest(ctx, SpotifyRequestOptions{
 		method:      "GET",
 		path:        "/me",
 		accessToken: opts.accessToken,
@@ -49,8 +49,7 @@ func getCurrentUserProfile(opts GetCurrentUserProfileRequestOptions) (*UserProfi
 		return &profile, err
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := do(req)
 	if err != nil {
 		return &profile, err
 	}
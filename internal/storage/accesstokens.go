@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/accesstoken"
+)
+
+// ------------------------------------------------------------
+// | Access Token Repository Methods
+// ------------------------------------------------------------
+
+const accessTokenColumns = "id, user_id, name, prefix, hash, scopes, expires_at, last_used_at, revoked_at, created_at"
+
+func nullTime(t sql.NullInt64) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	v := time.Unix(t.Int64, 0)
+	return &v
+}
+
+func scanAccessToken(row rowScanner) (*accesstoken.AccessToken, error) {
+	token := &accesstoken.AccessToken{}
+	var scopes string
+	var createdAt int64
+	var expiresAt, lastUsedAt, revokedAt sql.NullInt64
+	err := row.Scan(&token.Id, &token.UserId, &token.Name, &token.Prefix, &token.Hash, &scopes, &expiresAt, &lastUsedAt, &revokedAt, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	token.Scopes = make([]accesstoken.Scope, 0)
+	if scopes != "" {
+		for _, scope := range strings.Split(scopes, ",") {
+			token.Scopes = append(token.Scopes, accesstoken.Scope(scope))
+		}
+	}
+	token.ExpiresAt = nullTime(expiresAt)
+	token.LastUsedAt = nullTime(lastUsedAt)
+	token.RevokedAt = nullTime(revokedAt)
+	token.CreatedAt = time.Unix(createdAt, 0)
+
+	return token, nil
+}
+
+func (store *SqliteStore) CreateAccessToken(ctx context.Context, token *accesstoken.AccessToken) (*accesstoken.AccessToken, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	scopes := make([]string, len(token.Scopes))
+	for i, scope := range token.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	var expiresAt sql.NullInt64
+	if token.ExpiresAt != nil {
+		expiresAt = sql.NullInt64{Int64: token.ExpiresAt.Unix(), Valid: true}
+	}
+
+	query := "INSERT INTO " + TableNameAccessTokens + " (user_id, name, prefix, hash, scopes, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?) " +
+		"RETURNING " + accessTokenColumns
+	stmt, err := store.writeStmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanAccessToken(stmt.QueryRowContext(ctx, token.UserId, token.Name, token.Prefix, token.Hash, strings.Join(scopes, ","), expiresAt, token.CreatedAt.Unix()))
+}
+
+func (store *SqliteStore) GetAccessToken(ctx context.Context, tokenId int64) (*accesstoken.AccessToken, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + accessTokenColumns + " FROM " + TableNameAccessTokens + " WHERE id = ?"
+	token, err := scanAccessToken(store.queryRow(ctx, query, tokenId))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return token, err
+}
+
+func (store *SqliteStore) GetAccessTokenByHash(ctx context.Context, hash string) (*accesstoken.AccessToken, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + accessTokenColumns + " FROM " + TableNameAccessTokens + " WHERE hash = ?"
+	token, err := scanAccessToken(store.queryRow(ctx, query, hash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return token, err
+}
+
+func (store *SqliteStore) GetUserAccessTokens(ctx context.Context, userId int64) ([]accesstoken.AccessToken, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + accessTokenColumns + " FROM " + TableNameAccessTokens + " WHERE user_id = ? ORDER BY id DESC"
+	rows, err := store.query(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := make([]accesstoken.AccessToken, 0)
+	for rows.Next() {
+		token, err := scanAccessToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func (store *SqliteStore) CountActiveUserAccessTokens(ctx context.Context, userId int64, now time.Time) (int, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	var count int
+	query := "SELECT COUNT(*) FROM " + TableNameAccessTokens + " WHERE user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)"
+	err := store.queryRow(ctx, query, userId, now.Unix()).Scan(&count)
+	return count, err
+}
+
+func (store *SqliteStore) RevokeAccessToken(ctx context.Context, tokenId int64, now time.Time) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE " + TableNameAccessTokens + " SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL"
+	_, err := store.exec(ctx, query, now.Unix(), tokenId)
+	return err
+}
+
+func (store *SqliteStore) SetAccessTokenLastUsed(ctx context.Context, tokenId int64, now time.Time) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE " + TableNameAccessTokens + " SET last_used_at = ? WHERE id = ?"
+	_, err := store.exec(ctx, query, now.Unix(), tokenId)
+	return err
+}
@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+)
+
+// ------------------------------------------------------------
+// | Crew Repository Methods
+// ------------------------------------------------------------
+
+const (
+	crewColumns       = "id, name, created_by, created_at"
+	crewMemberColumns = "crew_id, user_id, role, joined_at"
+)
+
+func scanCrew(row rowScanner) (*core.CrewEntity, error) {
+	crew := &core.CrewEntity{}
+	var createdBy sql.NullInt64
+	var createdAt int64
+	err := row.Scan(&crew.Id, &crew.Name, &createdBy, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// The creator is cleared when their account is deleted.
+	crew.CreatedBy = createdBy.Int64
+	crew.CreatedAt = time.Unix(createdAt, 0)
+
+	return crew, nil
+}
+
+func scanCrewMember(row rowScanner) (*core.CrewMemberEntity, error) {
+	member := &core.CrewMemberEntity{}
+	var role string
+	var joinedAt int64
+	err := row.Scan(&member.CrewId, &member.UserId, &role, &joinedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	member.Role = core.CrewRole(role)
+	member.JoinedAt = time.Unix(joinedAt, 0)
+
+	return member, nil
+}
+
+func (store *SqliteStore) scanCrewMembers(ctx context.Context, query string, args ...any) ([]core.CrewMemberEntity, error) {
+	rows, err := store.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := make([]core.CrewMemberEntity, 0)
+	for rows.Next() {
+		member, err := scanCrewMember(rows)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, *member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+func (store *SqliteStore) CreateCrew(ctx context.Context, crew *core.CrewEntity) (*core.CrewEntity, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "INSERT INTO " + TableNameCrews + " (name, created_by, created_at) VALUES (?, ?, ?) RETURNING " + crewColumns
+	stmt, err := store.writeStmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanCrew(stmt.QueryRowContext(ctx, crew.Name, crew.CreatedBy, crew.CreatedAt.Unix()))
+}
+
+func (store *SqliteStore) GetCrewById(ctx context.Context, id int64) (*core.CrewEntity, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + crewColumns + " FROM " + TableNameCrews + " WHERE id = ?"
+	crew, err := scanCrew(store.queryRow(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return crew, err
+}
+
+func (store *SqliteStore) GetCrewByName(ctx context.Context, name string) (*core.CrewEntity, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + crewColumns + " FROM " + TableNameCrews + " WHERE name = ? COLLATE NOCASE"
+	crew, err := scanCrew(store.queryRow(ctx, query, name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return crew, err
+}
+
+func (store *SqliteStore) GetAllCrews(ctx context.Context) ([]core.CrewEntity, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := store.query(ctx, "SELECT "+crewColumns+" FROM "+TableNameCrews+" ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	crews := make([]core.CrewEntity, 0)
+	for rows.Next() {
+		crew, err := scanCrew(rows)
+		if err != nil {
+			return nil, err
+		}
+		crews = append(crews, *crew)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return crews, nil
+}
+
+func (store *SqliteStore) AddCrewMember(ctx context.Context, member *core.CrewMemberEntity) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "INSERT INTO " + TableNameCrewMembers + " (crew_id, user_id, role, joined_at) VALUES (?, ?, ?, ?)"
+	_, err := store.exec(ctx, query, member.CrewId, member.UserId, string(member.Role), member.JoinedAt.Unix())
+	return err
+}
+
+func (store *SqliteStore) GetCrewMember(ctx context.Context, crewId, userId int64) (*core.CrewMemberEntity, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + crewMemberColumns + " FROM " + TableNameCrewMembers + " WHERE crew_id = ? AND user_id = ?"
+	member, err := scanCrewMember(store.queryRow(ctx, query, crewId, userId))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return member, err
+}
+
+func (store *SqliteStore) GetCrewMembers(ctx context.Context, crewId int64) ([]core.CrewMemberEntity, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + crewMemberColumns + " FROM " + TableNameCrewMembers + " WHERE crew_id = ? ORDER BY joined_at, user_id"
+	return store.scanCrewMembers(ctx, query, crewId)
+}
+
+func (store *SqliteStore) GetUserCrewMemberships(ctx context.Context, userId int64) ([]core.CrewMemberEntity, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + crewMemberColumns + " FROM " + TableNameCrewMembers + " WHERE user_id = ? ORDER BY crew_id"
+	return store.scanCrewMembers(ctx, query, userId)
+}
+
+func (store *SqliteStore) UpdateCrewMemberRole(ctx context.Context, crewId, userId int64, role core.CrewRole) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE " + TableNameCrewMembers + " SET role = ? WHERE crew_id = ? AND user_id = ?"
+	_, err := store.exec(ctx, query, string(role), crewId, userId)
+	return err
+}
+
+func (store *SqliteStore) DeleteCrewMember(ctx context.Context, crewId, userId int64) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "DELETE FROM " + TableNameCrewMembers + " WHERE crew_id = ? AND user_id = ?"
+	_, err := store.exec(ctx, query, crewId, userId)
+	return err
+}
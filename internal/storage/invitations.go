@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/invitation"
+)
+
+// ------------------------------------------------------------
+// | Invitation Repository Methods
+// ------------------------------------------------------------
+
+const invitationColumns = "id, code, created_by, display_name, max_uses, uses, expires_at, revoked_at, created_at"
+
+func scanInvitation(row rowScanner) (*invitation.Invitation, error) {
+	inv := &invitation.Invitation{}
+	var createdAt int64
+	var createdBy, expiresAt, revokedAt sql.NullInt64
+	err := row.Scan(&inv.Id, &inv.Code, &createdBy, &inv.DisplayName, &inv.MaxUses, &inv.Uses, &expiresAt, &revokedAt, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	inv.CreatedBy = createdBy.Int64
+	inv.ExpiresAt = nullTime(expiresAt)
+	inv.RevokedAt = nullTime(revokedAt)
+	inv.CreatedAt = time.Unix(createdAt, 0)
+
+	return inv, nil
+}
+
+func (store *SqliteStore) scanInvitations(ctx context.Context, query string, args ...any) ([]invitation.Invitation, error) {
+	rows, err := store.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := make([]invitation.Invitation, 0)
+	for rows.Next() {
+		inv, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, *inv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return invitations, nil
+}
+
+func (store *SqliteStore) CreateInvitation(ctx context.Context, inv *invitation.Invitation) (*invitation.Invitation, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	var expiresAt sql.NullInt64
+	if inv.ExpiresAt != nil {
+		expiresAt = sql.NullInt64{Int64: inv.ExpiresAt.Unix(), Valid: true}
+	}
+
+	query := "INSERT INTO " + TableNameInvitations + " (code, created_by, display_name, max_uses, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?) " +
+		"RETURNING " + invitationColumns
+	stmt, err := store.writeStmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanInvitation(stmt.QueryRowContext(ctx, inv.Code, inv.CreatedBy, inv.DisplayName, inv.MaxUses, expiresAt, inv.CreatedAt.Unix()))
+}
+
+func (store *SqliteStore) GetInvitation(ctx context.Context, invitationId int64) (*invitation.Invitation, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + invitationColumns + " FROM " + TableNameInvitations + " WHERE id = ?"
+	inv, err := scanInvitation(store.queryRow(ctx, query, invitationId))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return inv, err
+}
+
+func (store *SqliteStore) GetInvitationByCode(ctx context.Context, code string) (*invitation.Invitation, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + invitationColumns + " FROM " + TableNameInvitations + " WHERE code = ?"
+	inv, err := scanInvitation(store.queryRow(ctx, query, code))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return inv, err
+}
+
+func (store *SqliteStore) GetInvitations(ctx context.Context) ([]invitation.Invitation, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + invitationColumns + " FROM " + TableNameInvitations + " ORDER BY id DESC"
+	return store.scanInvitations(ctx, query)
+}
+
+func (store *SqliteStore) GetUserInvitations(ctx context.Context, userId int64) ([]invitation.Invitation, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + invitationColumns + " FROM " + TableNameInvitations + " WHERE created_by = ? ORDER BY id DESC"
+	return store.scanInvitations(ctx, query, userId)
+}
+
+func (store *SqliteStore) CountActiveUserInvitations(ctx context.Context, userId int64, now time.Time) (int, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	var count int
+	query := "SELECT COUNT(*) FROM " + TableNameInvitations + " WHERE created_by = ? AND revoked_at IS NULL AND uses < max_uses AND (expires_at IS NULL OR expires_at > ?)"
+	err := store.queryRow(ctx, query, userId, now.Unix()).Scan(&count)
+	return count, err
+}
+
+func (store *SqliteStore) RevokeInvitation(ctx context.Context, invitationId int64, now time.Time) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE " + TableNameInvitations + " SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL"
+	_, err := store.exec(ctx, query, now.Unix(), invitationId)
+	return err
+}
+
+func (store *SqliteStore) UseInvitation(ctx context.Context, invitationId int64, userId int64, now time.Time) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	return store.InTransaction(ctx, func(ctx context.Context) error {
+		// The use is counted in the same statement that checks for uses left, so concurrent sign
+		// ups can't use an invitation more often than allowed.
+		query := "UPDATE " + TableNameInvitations + " SET uses = uses + 1 WHERE id = ? AND revoked_at IS NULL AND uses < max_uses AND (expires_at IS NULL OR expires_at > ?)"
+		result, err := store.exec(ctx, query, invitationId, now.Unix())
+		if err != nil {
+			return err
+		}
+
+		updated, err := result.RowsAffected()
+		if err != nil {
+			return err
+		} else if updated == 0 {
+			return invitation.ErrInvalidCode
+		}
+
+		query = "INSERT INTO " + TableNameInvitationUses + " (invitation_id, user_id, used_at) VALUES (?, ?, ?)"
+		_, err = store.exec(ctx, query, invitationId, userId, now.Unix())
+		return err
+	})
+}
+
+func (store *SqliteStore) GetInvitationUses(ctx context.Context, invitationIds []int64) ([]invitation.Use, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	uses := make([]invitation.Use, 0)
+	if len(invitationIds) == 0 {
+		return uses, nil
+	}
+
+	args := make([]any, len(invitationIds))
+	for i, id := range invitationIds {
+		args[i] = id
+	}
+
+	query := "SELECT iu.invitation_id, iu.user_id, u.username, iu.used_at FROM " + TableNameInvitationUses + " iu " +
+		"JOIN " + TableNameUsers + " u ON u.id = iu.user_id " +
+		"WHERE iu.invitation_id IN (?" + strings.Repeat(", ?", len(invitationIds)-1) + ") ORDER BY iu.used_at, iu.user_id"
+	rows, err := store.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var use invitation.Use
+		var usedAt int64
+		if err := rows.Scan(&use.InvitationId, &use.UserId, &use.Username, &usedAt); err != nil {
+			return nil, err
+		}
+		use.UsedAt = time.Unix(usedAt, 0)
+		uses = append(uses, use)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return uses, nil
+}
@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/scheduler"
+)
+
+// ------------------------------------------------------------
+// | Job Repository Methods
+// ------------------------------------------------------------
+
+const jobColumns = "id, name, payload, status, run_at, attempts, max_attempts, interval, last_error, locked_until, unique_key, created_at, updated_at"
+
+func scanJob(row rowScanner) (*scheduler.Job, error) {
+	job := &scheduler.Job{}
+	var runAt, lockedUntil, createdAt, updatedAt int64
+	var lastError, uniqueKey sql.NullString
+	err := row.Scan(&job.Id, &job.Name, &job.Payload, &job.Status, &runAt, &job.Attempts, &job.MaxAttempts, &job.Interval, &lastError, &lockedUntil, &uniqueKey, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	job.RunAt = time.Unix(runAt, 0)
+	job.LockedUntil = time.Unix(lockedUntil, 0)
+	job.CreatedAt = time.Unix(createdAt, 0)
+	job.UpdatedAt = time.Unix(updatedAt, 0)
+	job.LastError = lastError.String
+	job.UniqueKey = uniqueKey.String
+
+	return job, nil
+}
+
+func (store *SqliteStore) CreateJob(ctx context.Context, job *scheduler.Job) (*scheduler.Job, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	now := core.Now().Unix()
+	uniqueKey := sql.NullString{String: job.UniqueKey, Valid: job.UniqueKey != ""}
+
+	query := "INSERT INTO " + TableNameJobs + " (name, payload, status, run_at, attempts, max_attempts, interval, locked_until, unique_key, created_at, updated_at) VALUES (?, ?, ?, ?, 0, ?, ?, 0, ?, ?, ?) " +
+		"ON CONFLICT (unique_key) DO UPDATE SET interval = excluded.interval, max_attempts = excluded.max_attempts, updated_at = excluded.updated_at " +
+		"RETURNING " + jobColumns
+	stmt, err := store.writeStmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanJob(stmt.QueryRowContext(ctx, job.Name, job.Payload, job.Status, job.RunAt.Unix(), job.MaxAttempts, job.Interval, uniqueKey, now, now))
+}
+
+func (store *SqliteStore) ClaimDueJobs(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) ([]scheduler.Job, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE " + TableNameJobs + " SET status = ?, attempts = attempts + 1, locked_until = ?, updated_at = ? " +
+		"WHERE id IN (SELECT id FROM " + TableNameJobs + " WHERE (status = ? AND run_at <= ?) OR (status = ? AND locked_until <= ?) ORDER BY run_at LIMIT ?) " +
+		"RETURNING " + jobColumns
+	stmt, err := store.writeStmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := stmt.QueryContext(ctx,
+		scheduler.JobStatusRunning, leaseUntil.Unix(), now.Unix(),
+		scheduler.JobStatusPending, now.Unix(),
+		scheduler.JobStatusRunning, now.Unix(),
+		limit,
+	)
+	if err != nil {
+		logCanceled(ctx, query, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := make([]scheduler.Job, 0)
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+func (store *SqliteStore) CompleteJob(ctx context.Context, jobId int64, nextRunAt *time.Time) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	now := core.Now().Unix()
+	if nextRunAt != nil {
+		query := "UPDATE " + TableNameJobs + " SET status = ?, run_at = ?, attempts = 0, locked_until = 0, last_error = NULL, updated_at = ? WHERE id = ?"
+		_, err := store.exec(ctx, query, scheduler.JobStatusPending, nextRunAt.Unix(), now, jobId)
+		return err
+	}
+
+	query := "UPDATE " + TableNameJobs + " SET status = ?, locked_until = 0, last_error = NULL, updated_at = ? WHERE id = ?"
+	_, err := store.exec(ctx, query, scheduler.JobStatusSucceeded, now, jobId)
+	return err
+}
+
+func (store *SqliteStore) FailJob(ctx context.Context, jobId int64, lastError string, retryAt *time.Time, resetAttempts bool) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	now := core.Now().Unix()
+	if retryAt != nil {
+		query := "UPDATE " + TableNameJobs + " SET status = ?, run_at = ?, attempts = CASE WHEN ? THEN 0 ELSE attempts END, locked_until = 0, last_error = ?, updated_at = ? WHERE id = ?"
+		_, err := store.exec(ctx, query, scheduler.JobStatusPending, retryAt.Unix(), resetAttempts, lastError, now, jobId)
+		return err
+	}
+
+	query := "UPDATE " + TableNameJobs + " SET status = ?, locked_until = 0, last_error = ?, updated_at = ? WHERE id = ?"
+	_, err := store.exec(ctx, query, scheduler.JobStatusFailed, lastError, now, jobId)
+	return err
+}
+
+func (store *SqliteStore) RetryJob(ctx context.Context, jobId int64) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	now := core.Now().Unix()
+	query := "UPDATE " + TableNameJobs + " SET status = ?, run_at = ?, attempts = 0, locked_until = 0, updated_at = ? WHERE id = ? AND status != ?"
+	result, err := store.exec(ctx, query, scheduler.JobStatusPending, now, now, jobId, scheduler.JobStatusRunning)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	} else if rowsAffected == 0 {
+		return scheduler.ErrJobNotFound
+	}
+
+	return nil
+}
+
+func (store *SqliteStore) GetJobs(ctx context.Context, statuses []scheduler.JobStatus, limit int) ([]scheduler.Job, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	args := make([]any, 0, len(statuses)+1)
+	placeholders := make([]string, len(statuses))
+	for i, status := range statuses {
+		placeholders[i] = "?"
+		args = append(args, status)
+	}
+	args = append(args, limit)
+
+	query := "SELECT " + jobColumns + " FROM " + TableNameJobs + " WHERE status IN (" + strings.Join(placeholders, ", ") + ") ORDER BY run_at LIMIT ?"
+	rows, err := store.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := make([]scheduler.Job, 0)
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"strings"
+
+	"github.com/CaribouBlue/mixtape/internal/live"
+)
+
+// ------------------------------------------------------------
+// | Live Repository Methods
+// ------------------------------------------------------------
+
+func (store *SqliteStore) GetSessionsActivity(ctx context.Context, sessionIds []int64) (map[int64]live.Activity, error) {
+	activity := make(map[int64]live.Activity, len(sessionIds))
+	if len(sessionIds) == 0 {
+		return activity, nil
+	}
+
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(sessionIds)), ", ")
+	args := make([]any, 0, 2*len(sessionIds))
+	for _, sessionId := range sessionIds {
+		args = append(args, sessionId)
+	}
+	args = append(args, args...)
+
+	query := "SELECT session_id, SUM(players), SUM(finalized), SUM(votes) FROM (" +
+		"SELECT session_id, COUNT(*) AS players, SUM(is_submissions_finalized) AS finalized, 0 AS votes FROM " + TableNamePlayers +
+		" WHERE session_id IN (" + placeholders + ") GROUP BY session_id " +
+		"UNION ALL " +
+		"SELECT session_id, 0, 0, COUNT(*) FROM " + TableNameVotes +
+		" WHERE session_id IN (" + placeholders + ") GROUP BY session_id" +
+		") GROUP BY session_id"
+	rows, err := store.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sessionId int64
+		var sessionActivity live.Activity
+		err := rows.Scan(&sessionId, &sessionActivity.Players, &sessionActivity.Finalized, &sessionActivity.Votes)
+		if err != nil {
+			return nil, err
+		}
+		activity[sessionId] = sessionActivity
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return activity, nil
+}
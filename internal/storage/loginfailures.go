@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/loginlimit"
+)
+
+// ------------------------------------------------------------
+// | Login Limit Repository Methods
+// ------------------------------------------------------------
+
+const loginFailuresColumns = "kind, value, count, last_failed_at, blocked_until"
+
+func scanLoginFailures(row rowScanner) (*loginlimit.Failures, error) {
+	failures := &loginlimit.Failures{}
+	var kind string
+	var lastFailedAt, blockedUntil int64
+	err := row.Scan(&kind, &failures.Value, &failures.Count, &lastFailedAt, &blockedUntil)
+	if err != nil {
+		return nil, err
+	}
+
+	failures.Kind = loginlimit.KeyKind(kind)
+	failures.LastFailedAt = time.Unix(lastFailedAt, 0)
+	if blockedUntil > 0 {
+		failures.BlockedUntil = time.Unix(blockedUntil, 0)
+	}
+
+	return failures, nil
+}
+
+func (store *SqliteStore) GetLoginFailures(ctx context.Context, kind loginlimit.KeyKind, value string) (*loginlimit.Failures, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + loginFailuresColumns + " FROM " + TableNameLoginFailures + " WHERE kind = ? AND value = ?"
+	failures, err := scanLoginFailures(store.queryRow(ctx, query, string(kind), value))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return failures, err
+}
+
+func (store *SqliteStore) RecordLoginFailure(ctx context.Context, kind loginlimit.KeyKind, value string, now time.Time, resetBefore time.Time) (*loginlimit.Failures, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "INSERT INTO " + TableNameLoginFailures + " (kind, value, count, last_failed_at, blocked_until) VALUES (?, ?, 1, ?, 0) " +
+		"ON CONFLICT (kind, value) DO UPDATE SET " +
+		"count = CASE WHEN last_failed_at < ? THEN 1 ELSE count + 1 END, " +
+		"last_failed_at = excluded.last_failed_at " +
+		"RETURNING " + loginFailuresColumns
+	stmt, err := store.writeStmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanLoginFailures(stmt.QueryRowContext(ctx, string(kind), value, now.Unix(), resetBefore.Unix()))
+}
+
+func (store *SqliteStore) BlockLoginAttempts(ctx context.Context, kind loginlimit.KeyKind, value string, until time.Time) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE " + TableNameLoginFailures + " SET blocked_until = MAX(blocked_until, ?) WHERE kind = ? AND value = ?"
+	_, err := store.exec(ctx, query, until.Unix(), string(kind), value)
+	return err
+}
+
+func (store *SqliteStore) DeleteLoginFailures(ctx context.Context, kind loginlimit.KeyKind, value string) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "DELETE FROM " + TableNameLoginFailures + " WHERE kind = ? AND value = ?"
+	_, err := store.exec(ctx, query, string(kind), value)
+	return err
+}
+
+func (store *SqliteStore) DeleteStaleLoginFailures(ctx context.Context, before time.Time) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "DELETE FROM " + TableNameLoginFailures + " WHERE last_failed_at < ? AND blocked_until < ?"
+	_, err := store.exec(ctx, query, before.Unix(), before.Unix())
+	return err
+}
+
+func (store *SqliteStore) GetBlockedLoginFailures(ctx context.Context, now time.Time) ([]loginlimit.Failures, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + loginFailuresColumns + " FROM " + TableNameLoginFailures + " WHERE blocked_until > ? ORDER BY last_failed_at DESC"
+	rows, err := store.query(ctx, query, now.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blocked := make([]loginlimit.Failures, 0)
+	for rows.Next() {
+		failures, err := scanLoginFailures(rows)
+		if err != nil {
+			return nil, err
+		}
+		blocked = append(blocked, *failures)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return blocked, nil
+}
@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/loginsession"
+)
+
+// ------------------------------------------------------------
+// | Login Session Repository Methods
+// ------------------------------------------------------------
+
+const loginSessionColumns = "id, user_id, key, user_agent, ip_address, created_at, last_seen_at, expires_at, revoked_at"
+
+func scanLoginSession(row rowScanner) (*loginsession.LoginSession, error) {
+	session := &loginsession.LoginSession{}
+	var userAgent, ipAddress sql.NullString
+	var createdAt, lastSeenAt, expiresAt int64
+	var revokedAt sql.NullInt64
+	err := row.Scan(&session.Id, &session.UserId, &session.Key, &userAgent, &ipAddress, &createdAt, &lastSeenAt, &expiresAt, &revokedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	session.UserAgent = userAgent.String
+	session.IpAddress = ipAddress.String
+	session.CreatedAt = time.Unix(createdAt, 0)
+	session.LastSeenAt = time.Unix(lastSeenAt, 0)
+	session.ExpiresAt = time.Unix(expiresAt, 0)
+	session.RevokedAt = nullTime(revokedAt)
+
+	return session, nil
+}
+
+func (store *SqliteStore) CreateLoginSession(ctx context.Context, session *loginsession.LoginSession) (*loginsession.LoginSession, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "INSERT INTO " + TableNameLoginSessions + " (user_id, key, user_agent, ip_address, created_at, last_seen_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?) " +
+		"RETURNING " + loginSessionColumns
+	stmt, err := store.writeStmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanLoginSession(stmt.QueryRowContext(ctx, session.UserId, session.Key, session.UserAgent, session.IpAddress, session.CreatedAt.Unix(), session.LastSeenAt.Unix(), session.ExpiresAt.Unix()))
+}
+
+func (store *SqliteStore) GetLoginSession(ctx context.Context, sessionId int64) (*loginsession.LoginSession, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + loginSessionColumns + " FROM " + TableNameLoginSessions + " WHERE id = ?"
+	session, err := scanLoginSession(store.queryRow(ctx, query, sessionId))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return session, err
+}
+
+func (store *SqliteStore) GetLoginSessionByKey(ctx context.Context, key string) (*loginsession.LoginSession, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + loginSessionColumns + " FROM " + TableNameLoginSessions + " WHERE key = ?"
+	session, err := scanLoginSession(store.queryRow(ctx, query, key))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return session, err
+}
+
+func (store *SqliteStore) GetActiveUserLoginSessions(ctx context.Context, userId int64, now time.Time) ([]loginsession.LoginSession, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + loginSessionColumns + " FROM " + TableNameLoginSessions + " WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ? ORDER BY last_seen_at DESC, id DESC"
+	rows, err := store.query(ctx, query, userId, now.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make([]loginsession.LoginSession, 0)
+	for rows.Next() {
+		session, err := scanLoginSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (store *SqliteStore) SetLoginSessionLastSeen(ctx context.Context, sessionId int64, lastSeenAt time.Time, expiresAt time.Time) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE " + TableNameLoginSessions + " SET last_seen_at = ?, expires_at = ? WHERE id = ?"
+	_, err := store.exec(ctx, query, lastSeenAt.Unix(), expiresAt.Unix(), sessionId)
+	return err
+}
+
+func (store *SqliteStore) RevokeLoginSession(ctx context.Context, sessionId int64, now time.Time) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE " + TableNameLoginSessions + " SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL"
+	_, err := store.exec(ctx, query, now.Unix(), sessionId)
+	return err
+}
+
+func (store *SqliteStore) RevokeUserLoginSessions(ctx context.Context, userId int64, exceptSessionId int64, now time.Time) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE " + TableNameLoginSessions + " SET revoked_at = ? WHERE user_id = ? AND id != ? AND revoked_at IS NULL"
+	_, err := store.exec(ctx, query, now.Unix(), userId, exceptSessionId)
+	return err
+}
+
+func (store *SqliteStore) DeleteEndedUserLoginSessions(ctx context.Context, userId int64, before time.Time) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "DELETE FROM " + TableNameLoginSessions + " WHERE user_id = ? AND (revoked_at < ? OR expires_at < ?)"
+	_, err := store.exec(ctx, query, userId, before.Unix(), before.Unix())
+	return err
+}
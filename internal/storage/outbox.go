@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/mail"
+)
+
+// ------------------------------------------------------------
+// | Outbox Repository Methods
+// ------------------------------------------------------------
+
+const outboxColumns = "id, recipient, subject, text_body, html_body, headers, status, attempts, max_attempts, next_attempt_at, last_error, sent_at, created_at, updated_at"
+
+func scanOutboxMessage(row rowScanner) (*mail.OutboxMessage, error) {
+	message := &mail.OutboxMessage{}
+	var headers []byte
+	var nextAttemptAt, createdAt, updatedAt int64
+	var sentAt sql.NullInt64
+	var lastError sql.NullString
+	err := row.Scan(&message.Id, &message.Message.To, &message.Message.Subject, &message.Message.Text, &message.Message.Html, &headers, &message.Status, &message.Attempts, &message.MaxAttempts, &nextAttemptAt, &lastError, &sentAt, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(headers) > 0 {
+		if err := json.Unmarshal(headers, &message.Message.Headers); err != nil {
+			return nil, err
+		}
+	}
+
+	message.NextAttemptAt = time.Unix(nextAttemptAt, 0)
+	message.LastError = lastError.String
+	if sentAt.Valid {
+		t := time.Unix(sentAt.Int64, 0)
+		message.SentAt = &t
+	}
+	message.CreatedAt = time.Unix(createdAt, 0)
+	message.UpdatedAt = time.Unix(updatedAt, 0)
+
+	return message, nil
+}
+
+func scanOutboxMessages(rows *sql.Rows) ([]mail.OutboxMessage, error) {
+	messages := make([]mail.OutboxMessage, 0)
+	for rows.Next() {
+		message, err := scanOutboxMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, *message)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (store *SqliteStore) CreateOutboxMessage(ctx context.Context, message *mail.OutboxMessage) (*mail.OutboxMessage, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	headers, err := json.Marshal(message.Message.Headers)
+	if err != nil {
+		return nil, err
+	}
+
+	now := core.Now().Unix()
+	query := "INSERT INTO " + TableNameMailOutbox + " (recipient, subject, text_body, html_body, headers, status, attempts, max_attempts, next_attempt_at, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, 0, ?, ?, ?, ?) " +
+		"RETURNING " + outboxColumns
+	stmt, err := store.writeStmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanOutboxMessage(stmt.QueryRowContext(ctx, message.Message.To, message.Message.Subject, message.Message.Text, message.Message.Html, headers, message.Status, message.MaxAttempts, message.NextAttemptAt.Unix(), now, now))
+}
+
+func (store *SqliteStore) GetDueOutboxMessages(ctx context.Context, now time.Time, limit int) ([]mail.OutboxMessage, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + outboxColumns + " FROM " + TableNameMailOutbox + " WHERE status = ? AND next_attempt_at <= ? ORDER BY next_attempt_at LIMIT ?"
+	rows, err := store.query(ctx, query, mail.OutboxStatusPending, now.Unix(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanOutboxMessages(rows)
+}
+
+func (store *SqliteStore) MarkOutboxMessageSent(ctx context.Context, messageId int64, sentAt time.Time) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE " + TableNameMailOutbox + " SET status = ?, attempts = attempts + 1, last_error = NULL, sent_at = ?, updated_at = ? WHERE id = ?"
+	_, err := store.exec(ctx, query, mail.OutboxStatusSent, sentAt.Unix(), core.Now().Unix(), messageId)
+	return err
+}
+
+func (store *SqliteStore) FailOutboxMessage(ctx context.Context, messageId int64, lastError string, retryAt *time.Time) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	now := core.Now().Unix()
+	if retryAt != nil {
+		query := "UPDATE " + TableNameMailOutbox + " SET attempts = attempts + 1, next_attempt_at = ?, last_error = ?, updated_at = ? WHERE id = ?"
+		_, err := store.exec(ctx, query, retryAt.Unix(), lastError, now, messageId)
+		return err
+	}
+
+	query := "UPDATE " + TableNameMailOutbox + " SET status = ?, attempts = attempts + 1, last_error = ?, updated_at = ? WHERE id = ?"
+	_, err := store.exec(ctx, query, mail.OutboxStatusFailed, lastError, now, messageId)
+	return err
+}
+
+func (store *SqliteStore) RequeueOutboxMessage(ctx context.Context, messageId int64) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	now := core.Now().Unix()
+	query := "UPDATE " + TableNameMailOutbox + " SET status = ?, attempts = 0, next_attempt_at = ?, updated_at = ? WHERE id = ? AND status = ?"
+	result, err := store.exec(ctx, query, mail.OutboxStatusPending, now, now, messageId, mail.OutboxStatusFailed)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	} else if rowsAffected == 0 {
+		return mail.ErrOutboxMessageNotFound
+	}
+
+	return nil
+}
+
+func (store *SqliteStore) RequeueFailedOutboxMessages(ctx context.Context) (int64, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	now := core.Now().Unix()
+	query := "UPDATE " + TableNameMailOutbox + " SET status = ?, attempts = 0, next_attempt_at = ?, updated_at = ? WHERE status = ?"
+	result, err := store.exec(ctx, query, mail.OutboxStatusPending, now, now, mail.OutboxStatusFailed)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (store *SqliteStore) GetOutboxMessages(ctx context.Context, statuses []mail.OutboxStatus, limit int) ([]mail.OutboxMessage, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	args := make([]any, 0, len(statuses)+1)
+	placeholders := make([]string, len(statuses))
+	for i, status := range statuses {
+		placeholders[i] = "?"
+		args = append(args, status)
+	}
+	args = append(args, limit)
+
+	query := "SELECT " + outboxColumns + " FROM " + TableNameMailOutbox + " WHERE status IN (" + strings.Join(placeholders, ", ") + ") ORDER BY updated_at DESC LIMIT ?"
+	rows, err := store.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanOutboxMessages(rows)
+}
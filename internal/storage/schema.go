@@ -0,0 +1,343 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Setup creates the tables, or brings the ones made by an earlier setup up to date. It's safe to
+// run more than once.
+func (store *SqliteStore) Setup() error {
+	steps := []func() error{
+		store.createTables,
+		store.addColumns,
+		store.createViews,
+		store.assignSessionCrews,
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (store *SqliteStore) createTables() error {
+	tables := []string{
+		`CREATE TABLE IF NOT EXISTS ` + TableNameUsers + ` (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			username TEXT,
+			display_name TEXT,
+			hashed_password TEXT,
+			spotify_token TEXT,
+			spotify_email TEXT,
+			is_admin INTEGER DEFAULT (0),
+			email_opt_out INTEGER DEFAULT (0),
+			email TEXT,
+			email_verified INTEGER DEFAULT (0)
+		);`,
+		`CREATE TABLE IF NOT EXISTS ` + TableNameSessions + ` (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT,
+			crew_id INTEGER,
+			created_by INTEGER,
+			created_at INTEGER,
+			max_submissions INTEGER,
+			start_at INTEGER,
+			submission_phase_duration INTEGER,
+			submissions_closed_at INTEGER,
+			vote_phase_duration INTEGER,
+			results_frozen_at INTEGER,
+			results_freeze_failed_at INTEGER,
+			FOREIGN KEY (crew_id) REFERENCES ` + TableNameCrews + ` (id),
+			FOREIGN KEY (created_by) REFERENCES ` + TableNameUsers + ` (id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS ` + TableNamePlayers + ` (
+			session_id INTEGER,
+			player_id INTEGER,
+			playlist_id TEXT,
+			is_submissions_finalized INTEGER DEFAULT (0),
+			FOREIGN KEY (session_id) REFERENCES ` + TableNameSessions + ` (id),
+			FOREIGN KEY (player_id) REFERENCES ` + TableNameUsers + ` (id),
+			PRIMARY KEY (session_id, player_id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS ` + TableNameCandidates + ` (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			nominator_id INTEGER,
+			session_id INTEGER,
+			track_id TEXT,
+			FOREIGN KEY (nominator_id) REFERENCES ` + TableNameUsers + ` (id),
+			FOREIGN KEY (session_id) REFERENCES ` + TableNameSessions + ` (id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS ` + TableNameVotes + ` (
+			session_id INTEGER,
+			voter_id INTEGER,
+			candidate_id INTEGER,
+			FOREIGN KEY (session_id) REFERENCES ` + TableNameSessions + ` (id),
+			FOREIGN KEY (voter_id) REFERENCES ` + TableNameUsers + ` (id),
+			FOREIGN KEY (candidate_id) REFERENCES ` + TableNameCandidates + ` (id),
+			PRIMARY KEY (session_id, voter_id, candidate_id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS ` + TableNameResults + ` (
+			session_id INTEGER,
+			rank INTEGER,
+			candidate_id INTEGER,
+			place INTEGER,
+			votes INTEGER,
+			nominator_id INTEGER,
+			nominator_name TEXT,
+			track TEXT,
+			created_at INTEGER,
+			FOREIGN KEY (session_id) REFERENCES ` + TableNameSessions + ` (id),
+			PRIMARY KEY (session_id, rank)
+		);`,
+		`CREATE TABLE IF NOT EXISTS ` + TableNameSessionNotifications + ` (
+			session_id INTEGER,
+			phase TEXT,
+			notified_at INTEGER,
+			FOREIGN KEY (session_id) REFERENCES ` + TableNameSessions + ` (id),
+			PRIMARY KEY (session_id, phase)
+		);`,
+		`CREATE TABLE IF NOT EXISTS ` + TableNameRemindersSent + ` (
+			session_id INTEGER,
+			player_id INTEGER,
+			phase TEXT,
+			sent_at INTEGER,
+			FOREIGN KEY (session_id) REFERENCES ` + TableNameSessions + ` (id),
+			FOREIGN KEY (player_id) REFERENCES ` + TableNameUsers + ` (id),
+			PRIMARY KEY (session_id, player_id, phase)
+		);`,
+		`CREATE TABLE IF NOT EXISTS ` + TableNameJobs + ` (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT,
+			payload BLOB,
+			status TEXT,
+			run_at INTEGER,
+			attempts INTEGER DEFAULT (0),
+			max_attempts INTEGER,
+			interval INTEGER DEFAULT (0),
+			last_error TEXT,
+			locked_until INTEGER DEFAULT (0),
+			unique_key TEXT UNIQUE,
+			created_at INTEGER,
+			updated_at INTEGER
+		);`,
+		`CREATE INDEX IF NOT EXISTS ` + TableNameJobs + `_status_run_at ON ` + TableNameJobs + ` (status, run_at);`,
+		`CREATE TABLE IF NOT EXISTS ` + TableNameUserTokens + ` (
+			id TEXT PRIMARY KEY,
+			user_id INTEGER,
+			purpose TEXT,
+			email TEXT,
+			ip_address TEXT,
+			expires_at INTEGER,
+			used_at INTEGER,
+			created_at INTEGER,
+			FOREIGN KEY (user_id) REFERENCES ` + TableNameUsers + ` (id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS ` + TableNameMailOutbox + ` (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			recipient TEXT,
+			subject TEXT,
+			text_body TEXT,
+			html_body TEXT,
+			headers TEXT,
+			status TEXT,
+			attempts INTEGER DEFAULT (0),
+			max_attempts INTEGER,
+			next_attempt_at INTEGER,
+			last_error TEXT,
+			sent_at INTEGER,
+			created_at INTEGER,
+			updated_at INTEGER
+		);`,
+		`CREATE INDEX IF NOT EXISTS ` + TableNameMailOutbox + `_status_next_attempt_at ON ` + TableNameMailOutbox + ` (status, next_attempt_at);`,
+		`CREATE TABLE IF NOT EXISTS ` + TableNameWebhooks + ` (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			url TEXT,
+			secret TEXT,
+			description TEXT,
+			events TEXT,
+			is_active INTEGER DEFAULT (1),
+			created_at INTEGER,
+			updated_at INTEGER
+		);`,
+		`CREATE TABLE IF NOT EXISTS ` + TableNameWebhookDeliveries + ` (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			webhook_id INTEGER,
+			event_id TEXT,
+			event_type TEXT,
+			payload TEXT,
+			status TEXT,
+			attempts INTEGER DEFAULT (0),
+			max_attempts INTEGER,
+			next_attempt_at INTEGER,
+			response_status INTEGER,
+			response_body TEXT,
+			last_error TEXT,
+			delivered_at INTEGER,
+			created_at INTEGER,
+			updated_at INTEGER,
+			FOREIGN KEY (webhook_id) REFERENCES ` + TableNameWebhooks + ` (id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS ` + TableNameWebhookDeliveries + `_status_next_attempt_at ON ` + TableNameWebhookDeliveries + ` (status, next_attempt_at);`,
+		`CREATE INDEX IF NOT EXISTS ` + TableNameWebhookDeliveries + `_webhook_id ON ` + TableNameWebhookDeliveries + ` (webhook_id);`,
+		`CREATE TABLE IF NOT EXISTS ` + TableNameAccessTokens + ` (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER,
+			name TEXT,
+			prefix TEXT,
+			hash TEXT UNIQUE,
+			scopes TEXT,
+			expires_at INTEGER,
+			last_used_at INTEGER,
+			revoked_at INTEGER,
+			created_at INTEGER,
+			FOREIGN KEY (user_id) REFERENCES ` + TableNameUsers + ` (id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS ` + TableNameAccessTokens + `_user_id ON ` + TableNameAccessTokens + ` (user_id);`,
+		`CREATE TABLE IF NOT EXISTS ` + TableNameInvitations + ` (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			code TEXT UNIQUE,
+			created_by INTEGER,
+			display_name TEXT,
+			max_uses INTEGER,
+			uses INTEGER DEFAULT (0),
+			expires_at INTEGER,
+			revoked_at INTEGER,
+			created_at INTEGER,
+			FOREIGN KEY (created_by) REFERENCES ` + TableNameUsers + ` (id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS ` + TableNameInvitations + `_created_by ON ` + TableNameInvitations + ` (created_by);`,
+		`CREATE TABLE IF NOT EXISTS ` + TableNameInvitationUses + ` (
+			invitation_id INTEGER,
+			user_id INTEGER UNIQUE,
+			used_at INTEGER,
+			FOREIGN KEY (invitation_id) REFERENCES ` + TableNameInvitations + ` (id),
+			FOREIGN KEY (user_id) REFERENCES ` + TableNameUsers + ` (id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS ` + TableNameInvitationUses + `_invitation_id ON ` + TableNameInvitationUses + ` (invitation_id);`,
+		`CREATE TABLE IF NOT EXISTS ` + TableNameCrews + ` (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+			created_by INTEGER,
+			created_at INTEGER NOT NULL,
+			FOREIGN KEY (created_by) REFERENCES ` + TableNameUsers + ` (id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS ` + TableNameCrewMembers + ` (
+			crew_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			role TEXT NOT NULL,
+			joined_at INTEGER NOT NULL,
+			FOREIGN KEY (crew_id) REFERENCES ` + TableNameCrews + ` (id),
+			FOREIGN KEY (user_id) REFERENCES ` + TableNameUsers + ` (id),
+			PRIMARY KEY (crew_id, user_id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS ` + TableNameCrewMembers + `_user_id ON ` + TableNameCrewMembers + ` (user_id);`,
+		`CREATE TABLE IF NOT EXISTS ` + TableNameLoginSessions + ` (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			key TEXT NOT NULL UNIQUE,
+			user_agent TEXT,
+			ip_address TEXT,
+			created_at INTEGER NOT NULL,
+			last_seen_at INTEGER NOT NULL,
+			expires_at INTEGER NOT NULL,
+			revoked_at INTEGER,
+			FOREIGN KEY (user_id) REFERENCES ` + TableNameUsers + ` (id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS ` + TableNameLoginSessions + `_user_id ON ` + TableNameLoginSessions + ` (user_id);`,
+		`CREATE TABLE IF NOT EXISTS ` + TableNameLoginFailures + ` (
+			kind TEXT NOT NULL,
+			value TEXT NOT NULL,
+			count INTEGER NOT NULL,
+			last_failed_at INTEGER NOT NULL,
+			blocked_until INTEGER NOT NULL DEFAULT (0),
+			PRIMARY KEY (kind, value)
+		);`,
+	}
+
+	for _, query := range tables {
+		if _, err := store.Exec(query); err != nil {
+			return fmt.Errorf("failed to create table: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// addColumns brings tables created by an earlier setup up to date with columns added since.
+func (store *SqliteStore) addColumns() error {
+	columns := []string{
+		`ALTER TABLE ` + TableNameUsers + ` ADD COLUMN email_opt_out INTEGER DEFAULT (0);`,
+		`ALTER TABLE ` + TableNameUsers + ` ADD COLUMN email TEXT;`,
+		`ALTER TABLE ` + TableNameUsers + ` ADD COLUMN email_verified INTEGER DEFAULT (0);`,
+		`ALTER TABLE ` + TableNameSessions + ` ADD COLUMN crew_id INTEGER REFERENCES ` + TableNameCrews + ` (id);`,
+		`ALTER TABLE ` + TableNameUserTokens + ` ADD COLUMN ip_address TEXT;`,
+		`ALTER TABLE ` + TableNameSessions + ` ADD COLUMN results_frozen_at INTEGER;`,
+		`ALTER TABLE ` + TableNameSessions + ` ADD COLUMN results_freeze_failed_at INTEGER;`,
+		// Indexes on added columns can only be created once the columns exist.
+		`CREATE INDEX IF NOT EXISTS ` + TableNameSessions + `_crew_id ON ` + TableNameSessions + ` (crew_id);`,
+		`CREATE INDEX IF NOT EXISTS ` + TableNameUserTokens + `_ip_address ON ` + TableNameUserTokens + ` (ip_address, purpose, created_at);`,
+		// An email can only be verified by one account. Before this was enforced by the database the
+		// same address could be verified twice, in which case only the oldest account keeps it.
+		`UPDATE ` + TableNameUsers + ` SET email_verified = 0 WHERE email_verified = 1 AND EXISTS (
+			SELECT 1 FROM ` + TableNameUsers + ` other
+			WHERE other.email = ` + TableNameUsers + `.email COLLATE NOCASE AND other.email_verified = 1 AND other.id < ` + TableNameUsers + `.id
+		);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS ` + TableNameUsers + `_verified_email ON ` + TableNameUsers + ` (email COLLATE NOCASE) WHERE email_verified = 1;`,
+		// Results frozen before there was a marker are the ones that have rows.
+		`UPDATE ` + TableNameSessions + ` SET results_frozen_at = (
+			SELECT MIN(created_at) FROM ` + TableNameResults + ` WHERE session_id = ` + TableNameSessions + `.id
+		) WHERE results_frozen_at IS NULL AND id IN (SELECT session_id FROM ` + TableNameResults + `);`,
+	}
+
+	for _, query := range columns {
+		if _, err := store.Exec(query); err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+			return fmt.Errorf("failed to add column: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// defaultCrewName is the crew that sessions made before crews existed are moved to.
+const defaultCrewName = "Everyone"
+
+// assignSessionCrews moves sessions without a crew into the default crew, which every user joins
+// and global admins run, so upgraded databases keep working like they did before crews.
+func (store *SqliteStore) assignSessionCrews() error {
+	queries := []string{
+		`INSERT INTO ` + TableNameCrews + ` (name, created_by, created_at)
+			SELECT '` + defaultCrewName + `', (SELECT MIN(id) FROM ` + TableNameUsers + ` WHERE is_admin = 1), unixepoch()
+			WHERE EXISTS (SELECT 1 FROM ` + TableNameSessions + ` WHERE crew_id IS NULL)
+			ON CONFLICT (name) DO NOTHING;`,
+		`INSERT OR IGNORE INTO ` + TableNameCrewMembers + ` (crew_id, user_id, role, joined_at)
+			SELECT c.id, u.id, CASE WHEN u.is_admin = 1 THEN 'admin' ELSE 'member' END, unixepoch()
+			FROM ` + TableNameUsers + ` u, ` + TableNameCrews + ` c
+			WHERE c.name = '` + defaultCrewName + `'
+			AND EXISTS (SELECT 1 FROM ` + TableNameSessions + ` WHERE crew_id IS NULL);`,
+		`UPDATE ` + TableNameSessions + `
+			SET crew_id = (SELECT id FROM ` + TableNameCrews + ` WHERE name = '` + defaultCrewName + `')
+			WHERE crew_id IS NULL;`,
+	}
+
+	for _, query := range queries {
+		if _, err := store.Exec(query); err != nil {
+			return fmt.Errorf("failed to assign sessions to a crew: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (store *SqliteStore) createViews() error {
+	views := []string{}
+
+	for _, query := range views {
+		if _, err := store.Exec(query); err != nil {
+			return fmt.Errorf("failed to create view: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -1,23 +1,64 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"log"
+	"net/url"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/CaribouBlue/mixtape/internal/core"
-	_ "github.com/mattn/go-sqlite3"
+	mlog "github.com/CaribouBlue/mixtape/internal/log"
+	"github.com/mattn/go-sqlite3"
 )
 
 const (
 	// User Repo
-	TableNameUsers = "users"
+	TableNameUsers      = "users"
+	TableNameUserTokens = "user_tokens"
 
 	// Session Repo
 	TableNameSessions   = "sessions"
 	TableNamePlayers    = "players"
 	TableNameCandidates = "candidates"
 	TableNameVotes      = "votes"
+	TableNameResults    = "results"
+
+	// Notification Repo
+	TableNameSessionNotifications = "session_notifications"
+	TableNameRemindersSent        = "reminders_sent"
+
+	// Job Repo
+	TableNameJobs = "jobs"
+
+	// Outbox Repo
+	TableNameMailOutbox = "mail_outbox"
+
+	// Webhook Repo
+	TableNameWebhooks          = "webhooks"
+	TableNameWebhookDeliveries = "webhook_deliveries"
+
+	// Access Token Repo
+	TableNameAccessTokens = "access_tokens"
+
+	TableNameInvitations    = "invitations"
+	TableNameInvitationUses = "invitation_uses"
+
+	// Crew Repo
+	TableNameCrews       = "crews"
+	TableNameCrewMembers = "crew_members"
+
+	// Login Session Repo
+	TableNameLoginSessions = "login_sessions"
+
+	// Login Limit Repo
+	TableNameLoginFailures = "login_failures"
 )
 
 func makeSelectCandidatesQuery(conditional string) string {
@@ -36,27 +77,144 @@ func makeSelectCandidatesQuery(conditional string) string {
 	return selectCandidatesQuery
 }
 
+var (
+	querySelectCandidatesBySession      = makeSelectCandidatesQuery("WHERE c.session_id = ?")
+	querySelectCandidateById            = makeSelectCandidatesQuery("WHERE c.session_id = ? AND c.id = ?")
+	querySelectCandidatesByNominator    = makeSelectCandidatesQuery("WHERE c.session_id = ? AND c.nominator_id = ?")
+	querySelectCandidatesByNotNominator = makeSelectCandidatesQuery("WHERE c.session_id = ? AND c.nominator_id != ?")
+)
+
+type SqliteOptions struct {
+	JournalMode    string
+	BusyTimeout    time.Duration
+	ForeignKeys    bool
+	Synchronous    string
+	MaxReaderConns int
+	QueryTimeout   time.Duration
+}
+
+type SqliteOption func(*SqliteOptions)
+
+func WithJournalMode(journalMode string) SqliteOption {
+	return func(opts *SqliteOptions) {
+		opts.JournalMode = journalMode
+	}
+}
+
+func WithBusyTimeout(busyTimeout time.Duration) SqliteOption {
+	return func(opts *SqliteOptions) {
+		opts.BusyTimeout = busyTimeout
+	}
+}
+
+func WithForeignKeys(foreignKeys bool) SqliteOption {
+	return func(opts *SqliteOptions) {
+		opts.ForeignKeys = foreignKeys
+	}
+}
+
+func WithSynchronous(synchronous string) SqliteOption {
+	return func(opts *SqliteOptions) {
+		opts.Synchronous = synchronous
+	}
+}
+
+func WithMaxReaderConns(maxReaderConns int) SqliteOption {
+	return func(opts *SqliteOptions) {
+		opts.MaxReaderConns = maxReaderConns
+	}
+}
+
+func WithQueryTimeout(queryTimeout time.Duration) SqliteOption {
+	return func(opts *SqliteOptions) {
+		opts.QueryTimeout = queryTimeout
+	}
+}
+
 type SqliteStore struct {
 	dbPath string
-	db     *sql.DB
+	opts   SqliteOptions
+
+	// SQLite allows a single writer at a time, so writes go through a pool with one
+	// connection while reads are spread across a separate read-only pool.
+	writer *sql.DB
+	reader *sql.DB
+
+	stmtsMu sync.RWMutex
+	stmts   map[*sql.DB]map[string]*sql.Stmt
 }
 
-func NewSqliteDb(dbPath string) (*SqliteStore, error) {
+func NewSqliteDb(dbPath string, options ...SqliteOption) (*SqliteStore, error) {
+	opts := SqliteOptions{
+		JournalMode:    "WAL",
+		BusyTimeout:    5 * time.Second,
+		ForeignKeys:    true,
+		Synchronous:    "NORMAL",
+		MaxReaderConns: runtime.NumCPU(),
+		QueryTimeout:   10 * time.Second,
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
 	sqlite := &SqliteStore{
 		dbPath: dbPath,
+		opts:   opts,
+		stmts:  make(map[*sql.DB]map[string]*sql.Stmt),
 	}
 	err := sqlite.init()
 	return sqlite, err
 }
 
+func (store *SqliteStore) dsn(readOnly bool) string {
+	params := url.Values{}
+	params.Set("_busy_timeout", strconv.FormatInt(store.opts.BusyTimeout.Milliseconds(), 10))
+	params.Set("_foreign_keys", strconv.FormatBool(store.opts.ForeignKeys))
+	if store.opts.Synchronous != "" {
+		params.Set("_synchronous", store.opts.Synchronous)
+	}
+
+	if readOnly {
+		params.Set("_query_only", "true")
+	} else {
+		if store.opts.JournalMode != "" {
+			params.Set("_journal_mode", store.opts.JournalMode)
+		}
+		// Take the write lock when a transaction begins instead of upgrading a read lock
+		// later, which fails immediately with SQLITE_BUSY regardless of the busy timeout.
+		params.Set("_txlock", "immediate")
+	}
+
+	return store.dbPath + "?" + params.Encode()
+}
+
 func (store *SqliteStore) init() error {
-	db, err := sql.Open("sqlite3", store.dbPath)
+	writer, err := sql.Open("sqlite3", store.dsn(false))
+	if err != nil {
+		return err
+	}
+	writer.SetMaxOpenConns(1)
+	store.writer = writer
+
+	if err = store.writer.Ping(); err != nil {
+		return err
+	}
+
+	// An in-memory database only exists within its own connection pool.
+	if store.dbPath == ":memory:" {
+		store.reader = writer
+		return nil
+	}
+
+	reader, err := sql.Open("sqlite3", store.dsn(true))
 	if err != nil {
 		return err
 	}
-	store.db = db
+	reader.SetMaxOpenConns(max(store.opts.MaxReaderConns, 1))
+	reader.SetMaxIdleConns(max(store.opts.MaxReaderConns, 1))
+	store.reader = reader
 
-	if err = store.db.Ping(); err != nil {
+	if err = store.reader.Ping(); err != nil {
 		return err
 	}
 
@@ -64,23 +222,207 @@ func (store *SqliteStore) init() error {
 }
 
 func (store *SqliteStore) Close() error {
-	if store.db != nil {
-		return store.db.Close()
+	store.stmtsMu.Lock()
+	for _, stmts := range store.stmts {
+		for _, stmt := range stmts {
+			stmt.Close()
+		}
 	}
-	return nil
+	store.stmts = make(map[*sql.DB]map[string]*sql.Stmt)
+	store.stmtsMu.Unlock()
+
+	var err error
+	if store.reader != nil && store.reader != store.writer {
+		err = store.reader.Close()
+	}
+	if store.writer != nil {
+		if writerErr := store.writer.Close(); writerErr != nil {
+			err = writerErr
+		}
+	}
+	return err
 }
 
+// Exec runs a one-off statement, such as a schema change, on the writer without caching it.
 func (store *SqliteStore) Exec(query string, args ...any) (sql.Result, error) {
-	return store.db.Exec(query, args...)
+	return store.writer.Exec(query, args...)
+}
+
+// prepare returns a cached prepared statement for the query on the given pool.
+func (store *SqliteStore) prepare(db *sql.DB, query string) (*sql.Stmt, error) {
+	store.stmtsMu.RLock()
+	stmt, ok := store.stmts[db][query]
+	store.stmtsMu.RUnlock()
+	if ok {
+		return stmt, nil
+	}
+
+	store.stmtsMu.Lock()
+	defer store.stmtsMu.Unlock()
+
+	if stmt, ok := store.stmts[db][query]; ok {
+		return stmt, nil
+	}
+
+	stmt, err := db.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if store.stmts[db] == nil {
+		store.stmts[db] = make(map[string]*sql.Stmt)
+	}
+	store.stmts[db][query] = stmt
+
+	return stmt, nil
+}
+
+// withTimeout bounds a single repository operation by the configured query timeout.
+func (store *SqliteStore) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if store.opts.QueryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, store.opts.QueryTimeout)
+}
+
+// isUniqueViolation reports whether err is from a write that broke a unique index.
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
+// logCanceled records queries that were abandoned because their context ended.
+func logCanceled(ctx context.Context, query string, err error) {
+	if err == nil || ctx.Err() == nil {
+		return
+	}
+
+	event := mlog.Logger().Warn()
+	if errors.Is(ctx.Err(), context.Canceled) {
+		event = mlog.Logger().Info()
+	}
+	event.Err(ctx.Err()).Str("query", query).Msg("SQLite query canceled")
+}
+
+type txCtxKey struct{}
+
+// txState is stored in the context instead of the bare transaction so contexts that outlive it,
+// like those handed to commit hooks, stop using it once it is finished.
+type txState struct {
+	tx   *sql.Tx
+	done atomic.Bool
+}
+
+// InTransaction runs fn in a write transaction. Repository methods called with the context fn
+// receives take part in the transaction, so they must not be given any other context while fn
+// runs: the writer has a single connection, which the transaction holds until fn returns.
+// Nested calls join the outer transaction. Functions registered with core.AfterCommit run once
+// the outermost transaction commits.
+func (store *SqliteStore) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if txFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	tx, err := store.writer.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	state := &txState{tx: tx}
+	defer func() {
+		state.done.Store(true)
+		tx.Rollback()
+	}()
+
+	ctx, runCommitHooks := core.WithCommitHooks(ctx)
+	if err := fn(context.WithValue(ctx, txCtxKey{}, state)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	state.done.Store(true)
+
+	runCommitHooks()
+	return nil
+}
+
+func txFromContext(ctx context.Context) *sql.Tx {
+	state, _ := ctx.Value(txCtxKey{}).(*txState)
+	if state == nil || state.done.Load() {
+		return nil
+	}
+	return state.tx
+}
+
+// writeStmt returns the cached writer statement for the query, bound to the context's
+// transaction when there is one.
+func (store *SqliteStore) writeStmt(ctx context.Context, query string) (*sql.Stmt, error) {
+	tx := txFromContext(ctx)
+	if tx == nil {
+		return store.prepare(store.writer, query)
+	}
+
+	// Preparing on the writer pool would wait for the connection the transaction holds, so
+	// statements that aren't cached yet are prepared on the transaction itself.
+	store.stmtsMu.RLock()
+	stmt, ok := store.stmts[store.writer][query]
+	store.stmtsMu.RUnlock()
+	if ok {
+		return tx.StmtContext(ctx, stmt), nil
+	}
+	return tx.PrepareContext(ctx, query)
+}
+
+// readStmt returns the cached reader statement for the query. Inside a transaction reads go
+// through the writer instead so they see the transaction's uncommitted changes.
+func (store *SqliteStore) readStmt(ctx context.Context, query string) (*sql.Stmt, error) {
+	if txFromContext(ctx) != nil {
+		return store.writeStmt(ctx, query)
+	}
+	return store.prepare(store.reader, query)
+}
+
+func (store *SqliteStore) exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	stmt, err := store.writeStmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	result, err := stmt.ExecContext(ctx, args...)
+	logCanceled(ctx, query, err)
+	return result, err
+}
+
+func (store *SqliteStore) query(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	stmt, err := store.readStmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.QueryContext(ctx, args...)
+	logCanceled(ctx, query, err)
+	return rows, err
+}
+
+func (store *SqliteStore) queryRow(ctx context.Context, query string, args ...any) *sql.Row {
+	stmt, err := store.readStmt(ctx, query)
+	if err != nil {
+		// Let database/sql surface the prepare error through Row.Scan.
+		return store.reader.QueryRowContext(ctx, query, args...)
+	}
+	return stmt.QueryRowContext(ctx, args...)
 }
 
 // ------------------------------------------------------------
 // | User Repository Methods
 // ------------------------------------------------------------
 
-func (store *SqliteStore) CreateUser(user *core.UserEntity) (*core.UserEntity, error) {
+func (store *SqliteStore) CreateUser(ctx context.Context, user *core.UserEntity) (*core.UserEntity, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
 	query := "INSERT INTO " + TableNameUsers + " (username, display_name, hashed_password) VALUES (?, ?, ?)"
-	result, err := store.Exec(query, user.Username, user.DisplayName, user.HashedPassword)
+	result, err := store.exec(ctx, query, user.Username, user.DisplayName, user.HashedPassword)
 	if err != nil {
 		return nil, err
 	}
@@ -94,51 +436,85 @@ func (store *SqliteStore) CreateUser(user *core.UserEntity) (*core.UserEntity, e
 	return user, nil
 }
 
-func (store *SqliteStore) GetUserById(userId int64) (*core.UserEntity, error) {
+const querySelectUsers = "SELECT id, username, display_name, hashed_password, spotify_token, spotify_email, is_admin, email_opt_out, email, email_verified FROM " + TableNameUsers
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUser(row rowScanner) (*core.UserEntity, error) {
 	user := &core.UserEntity{}
-	query := "SELECT id, username, display_name, spotify_token, spotify_email, is_admin FROM " + TableNameUsers + " WHERE id = ?"
-	row := store.db.QueryRow(query, userId)
+	var hashedPassword []byte
 	var spotifyToken sql.NullString
 	var spotifyEmail sql.NullString
 	var isAdmin sql.NullBool
-	err := row.Scan(&user.Id, &user.Username, &user.DisplayName, &spotifyToken, &spotifyEmail, &isAdmin)
-	if err == sql.ErrNoRows {
-		return nil, nil // User not found
-	} else if err != nil {
+	var emailOptOut sql.NullBool
+	var email sql.NullString
+	var emailVerified sql.NullBool
+	err := row.Scan(&user.Id, &user.Username, &user.DisplayName, &hashedPassword, &spotifyToken, &spotifyEmail, &isAdmin, &emailOptOut, &email, &emailVerified)
+	if err != nil {
 		return nil, err
 	}
 
+	user.HashedPassword = hashedPassword
 	user.IsAdmin = isAdmin.Bool
 	user.SpotifyToken = spotifyToken.String
 	user.SpotifyEmail = spotifyEmail.String
+	user.EmailOptOut = emailOptOut.Bool
+	user.Email = email.String
+	user.IsEmailVerified = emailVerified.Bool
 
 	return user, nil
 }
 
-func (store *SqliteStore) GetUserByUsername(username string) (*core.UserEntity, error) {
-	user := &core.UserEntity{}
-	query := "SELECT id, username, display_name, hashed_password, spotify_token, spotify_email, is_admin FROM " + TableNameUsers + " WHERE username = ?"
-	row := store.db.QueryRow(query, username)
-	var spotifyToken sql.NullString
-	var spotifyEmail sql.NullString
-	var isAdmin sql.NullBool
-	err := row.Scan(&user.Id, &user.Username, &user.DisplayName, &user.HashedPassword, &spotifyToken, &spotifyEmail, &isAdmin)
+func (store *SqliteStore) GetUserById(ctx context.Context, userId int64) (*core.UserEntity, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	user, err := scanUser(store.queryRow(ctx, querySelectUsers+" WHERE id = ?", userId))
 	if err == sql.ErrNoRows {
 		return nil, nil // User not found
 	} else if err != nil {
 		return nil, err
 	}
 
-	user.IsAdmin = isAdmin.Bool
-	user.SpotifyToken = spotifyToken.String
-	user.SpotifyEmail = spotifyEmail.String
+	return user, nil
+}
+
+func (store *SqliteStore) GetUserByUsername(ctx context.Context, username string) (*core.UserEntity, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	user, err := scanUser(store.queryRow(ctx, querySelectUsers+" WHERE username = ?", username))
+	if err == sql.ErrNoRows {
+		return nil, nil // User not found
+	} else if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// GetUserByEmail looks up the user who has verified the given email address.
+func (store *SqliteStore) GetUserByEmail(ctx context.Context, email string) (*core.UserEntity, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	user, err := scanUser(store.queryRow(ctx, querySelectUsers+" WHERE email = ? COLLATE NOCASE AND email_verified = 1", email))
+	if err == sql.ErrNoRows {
+		return nil, nil // User not found
+	} else if err != nil {
+		return nil, err
+	}
 
 	return user, nil
 }
 
-func (store *SqliteStore) GetAllUsers() (*[]core.UserEntity, error) {
-	query := "SELECT id, username, display_name FROM " + TableNameUsers
-	rows, err := store.db.Query(query)
+func (store *SqliteStore) GetAllUsers(ctx context.Context) (*[]core.UserEntity, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := store.query(ctx, querySelectUsers+" ORDER BY id")
 	if err != nil {
 		return nil, err
 	}
@@ -146,12 +522,11 @@ func (store *SqliteStore) GetAllUsers() (*[]core.UserEntity, error) {
 
 	users := make([]core.UserEntity, 0)
 	for rows.Next() {
-		user := core.UserEntity{}
-		err := rows.Scan(&user.Id, &user.Username, &user.DisplayName)
+		user, err := scanUser(rows)
 		if err != nil {
 			return nil, err
 		}
-		users = append(users, user)
+		users = append(users, *user)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -161,14 +536,17 @@ func (store *SqliteStore) GetAllUsers() (*[]core.UserEntity, error) {
 	return &users, nil
 }
 
-func (store *SqliteStore) UpdateUserSpotifyInfo(userId int64, spotifyToken string, spotifyEmail string) (*core.UserEntity, error) {
+func (store *SqliteStore) UpdateUserSpotifyInfo(ctx context.Context, userId int64, spotifyToken string, spotifyEmail string) (*core.UserEntity, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
 	query := "UPDATE " + TableNameUsers + " SET spotify_token = ?, spotify_email = ? WHERE id = ?"
-	_, err := store.Exec(query, spotifyToken, spotifyEmail, userId)
+	_, err := store.exec(ctx, query, spotifyToken, spotifyEmail, userId)
 	if err != nil {
 		return nil, err
 	}
 
-	user, err := store.GetUserById(userId)
+	user, err := store.GetUserById(ctx, userId)
 	if err != nil {
 		return nil, err
 	}
@@ -176,16 +554,156 @@ func (store *SqliteStore) UpdateUserSpotifyInfo(userId int64, spotifyToken strin
 	return user, nil
 }
 
+func (store *SqliteStore) UpdateUserEmail(ctx context.Context, userId int64, email string, isVerified bool) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE " + TableNameUsers + " SET email = ?, email_verified = ? WHERE id = ?"
+	_, err := store.exec(ctx, query, email, isVerified, userId)
+	if isUniqueViolation(err) {
+		return core.ErrEmailAlreadyInUse
+	}
+	return err
+}
+
+func (store *SqliteStore) UpdateUserPassword(ctx context.Context, userId int64, hashedPassword []byte) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE " + TableNameUsers + " SET hashed_password = ? WHERE id = ?"
+	_, err := store.exec(ctx, query, hashedPassword, userId)
+	return err
+}
+
+func (store *SqliteStore) UpdateUserDisplayName(ctx context.Context, userId int64, displayName string) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE " + TableNameUsers + " SET display_name = ? WHERE id = ?"
+	_, err := store.exec(ctx, query, displayName, userId)
+	return err
+}
+
+func (store *SqliteStore) UpdateUserIsAdmin(ctx context.Context, userId int64, isAdmin bool) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE " + TableNameUsers + " SET is_admin = ? WHERE id = ?"
+	_, err := store.exec(ctx, query, isAdmin, userId)
+	return err
+}
+
+func (store *SqliteStore) DeleteUser(ctx context.Context, userId int64) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	return store.InTransaction(ctx, func(ctx context.Context) error {
+		var sessionCount int
+		err := store.queryRow(ctx, "SELECT COUNT(*) FROM "+TableNameSessions+" WHERE created_by = ?", userId).Scan(&sessionCount)
+		if err != nil {
+			return err
+		}
+		if sessionCount > 0 {
+			return core.ErrUserHasSessions
+		}
+
+		// The user's invitations are kept so the people who signed up with them still show who
+		// invited them, but nobody can sign up with them anymore.
+		query := "UPDATE " + TableNameInvitations + " SET revoked_at = COALESCE(revoked_at, ?), created_by = NULL WHERE created_by = ?"
+		if _, err := store.exec(ctx, query, core.Now().Unix(), userId); err != nil {
+			return err
+		}
+
+		// Rows referencing the user go first, votes for the user's candidates before the candidates.
+		// Published results are kept, they're a snapshot that doesn't reference the user's rows.
+		queries := []string{
+			"DELETE FROM " + TableNameVotes + " WHERE voter_id = ?",
+			"DELETE FROM " + TableNameVotes + " WHERE candidate_id IN (SELECT id FROM " + TableNameCandidates + " WHERE nominator_id = ?)",
+			"DELETE FROM " + TableNameCandidates + " WHERE nominator_id = ?",
+			"DELETE FROM " + TableNameRemindersSent + " WHERE player_id = ?",
+			"DELETE FROM " + TableNamePlayers + " WHERE player_id = ?",
+			"DELETE FROM " + TableNameUserTokens + " WHERE user_id = ?",
+			"DELETE FROM " + TableNameAccessTokens + " WHERE user_id = ?",
+			"DELETE FROM " + TableNameLoginSessions + " WHERE user_id = ?",
+			"DELETE FROM " + TableNameInvitationUses + " WHERE user_id = ?",
+			"DELETE FROM " + TableNameCrewMembers + " WHERE user_id = ?",
+			"UPDATE " + TableNameCrews + " SET created_by = NULL WHERE created_by = ?",
+			"DELETE FROM " + TableNameUsers + " WHERE id = ?",
+		}
+		for _, query := range queries {
+			if _, err := store.exec(ctx, query, userId); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (store *SqliteStore) UpdateUserEmailOptOut(ctx context.Context, userId int64, optOut bool) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE " + TableNameUsers + " SET email_opt_out = ? WHERE id = ?"
+	_, err := store.exec(ctx, query, optOut, userId)
+	return err
+}
+
 // ------------------------------------------------------------
 // | Session Repository Methods
 // ------------------------------------------------------------
 
-func (store *SqliteStore) CreateSession(session *core.SessionEntity) (*core.SessionEntity, error) {
+const sessionColumns = "id, name, crew_id, created_by, created_at, max_submissions, start_at, submission_phase_duration, vote_phase_duration, results_frozen_at, results_freeze_failed_at"
+
+func scanSession(row rowScanner) (*core.SessionEntity, error) {
+	session := &core.SessionEntity{}
+	var crewId, resultsFrozenAt, resultsFreezeFailedAt sql.NullInt64
+	var createdAt, startAt int64
+	err := row.Scan(&session.Id, &session.Name, &crewId, &session.CreatedBy, &createdAt, &session.MaxSubmissions, &startAt, &session.SubmissionPhaseDuration, &session.VotePhaseDuration, &resultsFrozenAt, &resultsFreezeFailedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	session.CrewId = crewId.Int64
+	session.CreatedAt = time.Unix(createdAt, 0)
+	session.StartAt = time.Unix(startAt, 0)
+	session.ResultsFrozenAt = nullTime(resultsFrozenAt)
+	session.ResultsFreezeFailedAt = nullTime(resultsFreezeFailedAt)
+
+	return session, nil
+}
+
+func (store *SqliteStore) scanSessions(ctx context.Context, query string, args ...any) (*[]core.SessionEntity, error) {
+	rows, err := store.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make([]core.SessionEntity, 0)
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &sessions, nil
+}
+
+func (store *SqliteStore) CreateSession(ctx context.Context, session *core.SessionEntity) (*core.SessionEntity, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
 	query := `INSERT INTO ` + TableNameSessions + `
-		(name, created_by, created_at, max_submissions, start_at, submission_phase_duration, vote_phase_duration) 
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		(name, crew_id, created_by, created_at, max_submissions, start_at, submission_phase_duration, vote_phase_duration) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`
-	result, err := store.Exec(query, session.Name, session.CreatedBy, session.CreatedAt.Unix(), session.MaxSubmissions, session.StartAt.Unix(), session.SubmissionPhaseDuration, session.VotePhaseDuration)
+	result, err := store.exec(ctx, query, session.Name, session.CrewId, session.CreatedBy, session.CreatedAt.Unix(), session.MaxSubmissions, session.StartAt.Unix(), session.SubmissionPhaseDuration, session.VotePhaseDuration)
 	if err != nil {
 		return nil, err
 	}
@@ -199,54 +717,83 @@ func (store *SqliteStore) CreateSession(session *core.SessionEntity) (*core.Sess
 	return session, nil
 }
 
-func (store *SqliteStore) GetSessionById(id int64) (*core.SessionEntity, error) {
-	session := &core.SessionEntity{}
-	query := "SELECT id, name, created_by, created_at, max_submissions, start_at, submission_phase_duration, vote_phase_duration FROM " + TableNameSessions + " WHERE id = ?"
-	row := store.db.QueryRow(query, id)
-	var CreatedAt, StartAt int64
-	err := row.Scan(&session.Id, &session.Name, &session.CreatedBy, &CreatedAt, &session.MaxSubmissions, &StartAt, &session.SubmissionPhaseDuration, &session.VotePhaseDuration)
+func (store *SqliteStore) GetSessionById(ctx context.Context, id int64) (*core.SessionEntity, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + sessionColumns + " FROM " + TableNameSessions + " WHERE id = ?"
+	session, err := scanSession(store.queryRow(ctx, query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil // Session not found
 	} else if err != nil {
 		return nil, err
 	}
 
-	session.CreatedAt = time.Unix(CreatedAt, 0)
-	session.StartAt = time.Unix(StartAt, 0)
-
 	return session, nil
 }
 
-func (store *SqliteStore) GetAllSessions() (*[]core.SessionEntity, error) {
-	query := "SELECT id, name, created_by, created_at, max_submissions, start_at, submission_phase_duration, vote_phase_duration FROM " + TableNameSessions
-	rows, err := store.db.Query(query)
+func (store *SqliteStore) GetAllSessions(ctx context.Context) (*[]core.SessionEntity, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + sessionColumns + " FROM " + TableNameSessions + " ORDER BY id"
+	return store.scanSessions(ctx, query)
+}
+
+func (store *SqliteStore) GetCrewMemberSessions(ctx context.Context, userId int64) (*[]core.SessionEntity, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + sessionColumns + " FROM " + TableNameSessions + `
+		WHERE crew_id IN (SELECT crew_id FROM ` + TableNameCrewMembers + ` WHERE user_id = ?)
+		ORDER BY id`
+	return store.scanSessions(ctx, query, userId)
+}
+
+func (store *SqliteStore) UpdateSessionStartAt(ctx context.Context, id int64, startAt time.Time) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE " + TableNameSessions + " SET start_at = ? WHERE id = ?"
+	_, err := store.exec(ctx, query, startAt.Unix(), id)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
-	sessions := make([]core.SessionEntity, 0)
-	for rows.Next() {
-		session := core.SessionEntity{}
-		var CreatedAt, StartAt int64
-		err := rows.Scan(&session.Id, &session.Name, &session.CreatedBy, &CreatedAt, &session.MaxSubmissions, &StartAt, &session.SubmissionPhaseDuration, &session.VotePhaseDuration)
-		if err != nil {
-			return nil, err
-		}
 
-		session.CreatedAt = time.Unix(CreatedAt, 0)
-		session.StartAt = time.Unix(StartAt, 0)
+	return nil
+}
 
-		sessions = append(sessions, session)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return &sessions, nil
+func (store *SqliteStore) DeleteSession(ctx context.Context, id int64) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	return store.InTransaction(ctx, func(ctx context.Context) error {
+		// Rows referencing the session go first, votes before the candidates they reference.
+		queries := []string{
+			"DELETE FROM " + TableNameVotes + " WHERE session_id = ?",
+			"DELETE FROM " + TableNameCandidates + " WHERE session_id = ?",
+			"DELETE FROM " + TableNameResults + " WHERE session_id = ?",
+			"DELETE FROM " + TableNameRemindersSent + " WHERE session_id = ?",
+			"DELETE FROM " + TableNameSessionNotifications + " WHERE session_id = ?",
+			"DELETE FROM " + TableNamePlayers + " WHERE session_id = ?",
+			"DELETE FROM " + TableNameSessions + " WHERE id = ?",
+		}
+		for _, query := range queries {
+			if _, err := store.exec(ctx, query, id); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 }
 
-func (store *SqliteStore) AddCandidate(sessionId int64, candidate *core.CandidateEntity) (*core.CandidateEntity, error) {
+func (store *SqliteStore) AddCandidate(ctx context.Context, sessionId int64, candidate *core.CandidateEntity) (*core.CandidateEntity, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
 	query := "INSERT INTO " + TableNameCandidates + " (session_id, nominator_id, track_id) VALUES (?, ?, ?)"
-	result, err := store.Exec(query, sessionId, candidate.NominatorId, candidate.TrackId)
+	result, err := store.exec(ctx, query, sessionId, candidate.NominatorId, candidate.TrackId)
 	if err != nil {
 		return nil, err
 	}
@@ -260,8 +807,11 @@ func (store *SqliteStore) AddCandidate(sessionId int64, candidate *core.Candidat
 	return candidate, nil
 }
 
-func (store *SqliteStore) GetAllCandidates(sessionId int64) (*[]core.CandidateEntity, error) {
-	rows, err := store.db.Query(makeSelectCandidatesQuery("WHERE c.session_id = ?"), sessionId)
+func (store *SqliteStore) GetAllCandidates(ctx context.Context, sessionId int64) (*[]core.CandidateEntity, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := store.query(ctx, querySelectCandidatesBySession, sessionId)
 	if err != nil {
 		return nil, err
 	}
@@ -284,8 +834,11 @@ func (store *SqliteStore) GetAllCandidates(sessionId int64) (*[]core.CandidateEn
 	return &candidates, nil
 }
 
-func (store *SqliteStore) GetCandidateById(sessionId int64, candidateId int64) (*core.CandidateEntity, error) {
-	row := store.db.QueryRow(makeSelectCandidatesQuery("WHERE c.session_id = ? AND c.id = ?"), sessionId, candidateId)
+func (store *SqliteStore) GetCandidateById(ctx context.Context, sessionId int64, candidateId int64) (*core.CandidateEntity, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	row := store.queryRow(ctx, querySelectCandidateById, sessionId, candidateId)
 	candidate := &core.CandidateEntity{}
 	err := row.Scan(&candidate.Id, &candidate.SessionId, &candidate.NominatorId, &candidate.TrackId, &candidate.Votes)
 	if err == sql.ErrNoRows {
@@ -296,8 +849,11 @@ func (store *SqliteStore) GetCandidateById(sessionId int64, candidateId int64) (
 	return candidate, nil
 }
 
-func (store *SqliteStore) GetCandidatesByUserId(sessionId int64, userId int64) (*[]core.CandidateEntity, error) {
-	rows, err := store.db.Query(makeSelectCandidatesQuery("WHERE c.session_id = ? AND c.nominator_id = ?"), sessionId, userId)
+func (store *SqliteStore) GetCandidatesByUserId(ctx context.Context, sessionId int64, userId int64) (*[]core.CandidateEntity, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := store.query(ctx, querySelectCandidatesByNominator, sessionId, userId)
 	if err != nil {
 		log.Default().Println("Error querying candidates: ", err)
 		return nil, err
@@ -321,8 +877,11 @@ func (store *SqliteStore) GetCandidatesByUserId(sessionId int64, userId int64) (
 	return &candidates, nil
 }
 
-func (store *SqliteStore) GetCandidateByNotUserId(sessionId int64, userId int64) (*[]core.CandidateEntity, error) {
-	rows, err := store.db.Query(makeSelectCandidatesQuery("WHERE c.session_id = ? AND c.nominator_id != ?"), sessionId, userId)
+func (store *SqliteStore) GetCandidateByNotUserId(ctx context.Context, sessionId int64, userId int64) (*[]core.CandidateEntity, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := store.query(ctx, querySelectCandidatesByNotNominator, sessionId, userId)
 	if err != nil {
 		return nil, err
 	}
@@ -345,18 +904,24 @@ func (store *SqliteStore) GetCandidateByNotUserId(sessionId int64, userId int64)
 	return &candidates, nil
 }
 
-func (store *SqliteStore) DeleteCandidate(sessionId int64, candidateId int64) error {
+func (store *SqliteStore) DeleteCandidate(ctx context.Context, sessionId int64, candidateId int64) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
 	query := "DELETE FROM " + TableNameCandidates + " WHERE session_id = ? AND id = ?"
-	_, err := store.Exec(query, sessionId, candidateId)
+	_, err := store.exec(ctx, query, sessionId, candidateId)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (store *SqliteStore) AddVote(sessionId int64, vote *core.VoteEntity) (*core.VoteEntity, error) {
+func (store *SqliteStore) AddVote(ctx context.Context, sessionId int64, vote *core.VoteEntity) (*core.VoteEntity, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
 	query := "INSERT INTO " + TableNameVotes + " (session_id, voter_id, candidate_id) VALUES (?, ?, ?)"
-	_, err := store.Exec(query, sessionId, vote.VoterId, vote.CandidateId)
+	_, err := store.exec(ctx, query, sessionId, vote.VoterId, vote.CandidateId)
 	if err != nil {
 		return nil, err
 	}
@@ -364,9 +929,12 @@ func (store *SqliteStore) AddVote(sessionId int64, vote *core.VoteEntity) (*core
 	return vote, nil
 }
 
-func (store *SqliteStore) GetVotesByUserId(sessionId int64, userId int64) (*[]core.VoteEntity, error) {
+func (store *SqliteStore) GetVotesByUserId(ctx context.Context, sessionId int64, userId int64) (*[]core.VoteEntity, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
 	query := "SELECT session_id, voter_id, candidate_id FROM " + TableNameVotes + " WHERE session_id = ? AND voter_id = ?"
-	rows, err := store.db.Query(query, sessionId, userId)
+	rows, err := store.query(ctx, query, sessionId, userId)
 	if err != nil {
 		return nil, err
 	}
@@ -389,9 +957,12 @@ func (store *SqliteStore) GetVotesByUserId(sessionId int64, userId int64) (*[]co
 	return &votes, nil
 }
 
-func (store *SqliteStore) GetVote(sessionId int64, userId int64, candidateId int64) (*core.VoteEntity, error) {
+func (store *SqliteStore) GetVote(ctx context.Context, sessionId int64, userId int64, candidateId int64) (*core.VoteEntity, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
 	query := "SELECT session_id, voter_id, candidate_id FROM " + TableNameVotes + " WHERE session_id = ? AND voter_id = ? AND candidate_id = ?"
-	row := store.db.QueryRow(query, sessionId, userId, candidateId)
+	row := store.queryRow(ctx, query, sessionId, userId, candidateId)
 	vote := &core.VoteEntity{}
 	err := row.Scan(&vote.SessionId, &vote.VoterId, &vote.CandidateId)
 	if err == sql.ErrNoRows {
@@ -402,18 +973,24 @@ func (store *SqliteStore) GetVote(sessionId int64, userId int64, candidateId int
 	return vote, nil
 }
 
-func (store *SqliteStore) DeleteVote(sessionId int64, userId int64, candidateId int64) error {
+func (store *SqliteStore) DeleteVote(ctx context.Context, sessionId int64, userId int64, candidateId int64) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
 	query := "DELETE FROM " + TableNameVotes + " WHERE session_id = ? AND voter_id = ? AND candidate_id = ?"
-	_, err := store.Exec(query, sessionId, userId, candidateId)
+	_, err := store.exec(ctx, query, sessionId, userId, candidateId)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (store *SqliteStore) AddPlayer(sessionId int64, player *core.PlayerEntity) (*core.PlayerEntity, error) {
+func (store *SqliteStore) AddPlayer(ctx context.Context, sessionId int64, player *core.PlayerEntity) (*core.PlayerEntity, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
 	query := "INSERT INTO " + TableNamePlayers + " (session_id, player_id, playlist_id) VALUES (?, ?, ?)"
-	_, err := store.Exec(query, sessionId, player.PlayerId, player.PlaylistId)
+	_, err := store.exec(ctx, query, sessionId, player.PlayerId, player.PlaylistId)
 	if err != nil {
 		return nil, err
 	}
@@ -421,9 +998,12 @@ func (store *SqliteStore) AddPlayer(sessionId int64, player *core.PlayerEntity)
 	return player, nil
 }
 
-func (store *SqliteStore) UpdatePlayerPlaylist(sessionId int64, playerId int64, playlistId string) error {
+func (store *SqliteStore) UpdatePlayerPlaylist(ctx context.Context, sessionId int64, playerId int64, playlistId string) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
 	query := "UPDATE " + TableNamePlayers + " SET playlist_id = ? WHERE session_id = ? AND player_id = ?"
-	_, err := store.Exec(query, playlistId, sessionId, playerId)
+	_, err := store.exec(ctx, query, playlistId, sessionId, playerId)
 	if err != nil {
 		return err
 	}
@@ -431,9 +1011,12 @@ func (store *SqliteStore) UpdatePlayerPlaylist(sessionId int64, playerId int64,
 	return nil
 }
 
-func (store *SqliteStore) FinalizePlayerSubmissions(sessionId, playerId int64) error {
+func (store *SqliteStore) FinalizePlayerSubmissions(ctx context.Context, sessionId, playerId int64) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
 	query := "UPDATE " + TableNamePlayers + " SET is_submissions_finalized = ? WHERE session_id = ? AND player_id = ?"
-	_, err := store.Exec(query, true, sessionId, playerId)
+	_, err := store.exec(ctx, query, true, sessionId, playerId)
 	if err != nil {
 		return err
 	}
@@ -441,9 +1024,12 @@ func (store *SqliteStore) FinalizePlayerSubmissions(sessionId, playerId int64) e
 	return nil
 }
 
-func (store *SqliteStore) GetPlayer(sessionId int64, playerId int64) (*core.PlayerEntity, error) {
+func (store *SqliteStore) GetPlayer(ctx context.Context, sessionId int64, playerId int64) (*core.PlayerEntity, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
 	query := "SELECT session_id, player_id, playlist_id, is_submissions_finalized FROM " + TableNamePlayers + " WHERE session_id = ? AND player_id = ?"
-	row := store.db.QueryRow(query, sessionId, playerId)
+	row := store.queryRow(ctx, query, sessionId, playerId)
 	player := &core.PlayerEntity{}
 	err := row.Scan(&player.SessionId, &player.PlayerId, &player.PlaylistId, &player.IsSubmissionsFinalized)
 	if err == sql.ErrNoRows {
@@ -455,9 +1041,12 @@ func (store *SqliteStore) GetPlayer(sessionId int64, playerId int64) (*core.Play
 	return player, nil
 }
 
-func (store *SqliteStore) GetPlayers(sessionId int64) (*[]core.PlayerEntity, error) {
+func (store *SqliteStore) GetPlayers(ctx context.Context, sessionId int64) (*[]core.PlayerEntity, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
 	query := "SELECT session_id, player_id, playlist_id, is_submissions_finalized FROM " + TableNamePlayers + " WHERE session_id = ?"
-	rows, err := store.db.Query(query, sessionId)
+	rows, err := store.query(ctx, query, sessionId)
 	if err != nil {
 		return nil, err
 	}
@@ -477,3 +1066,125 @@ func (store *SqliteStore) GetPlayers(sessionId int64) (*[]core.PlayerEntity, err
 	}
 	return &players, nil
 }
+
+func (store *SqliteStore) GetResults(ctx context.Context, sessionId int64) (*[]core.ResultEntity, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT session_id, candidate_id, place, votes, nominator_id, nominator_name, track, created_at FROM " + TableNameResults + " WHERE session_id = ? ORDER BY rank"
+	rows, err := store.query(ctx, query, sessionId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	results := make([]core.ResultEntity, 0)
+	for rows.Next() {
+		result := core.ResultEntity{}
+		var track []byte
+		var createdAt int64
+		err := rows.Scan(&result.SessionId, &result.CandidateId, &result.Place, &result.Votes, &result.NominatorId, &result.NominatorName, &track, &createdAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(track, &result.Track); err != nil {
+			return nil, err
+		}
+		result.CreatedAt = time.Unix(createdAt, 0)
+
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return &results, nil
+}
+
+// ReplaceResults swaps a session's results snapshot and marks it frozen in a single transaction.
+func (store *SqliteStore) ReplaceResults(ctx context.Context, sessionId int64, results []core.ResultEntity, frozenAt time.Time) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	return store.InTransaction(ctx, func(ctx context.Context) error {
+		_, err := store.exec(ctx, "DELETE FROM "+TableNameResults+" WHERE session_id = ?", sessionId)
+		if err != nil {
+			return err
+		}
+
+		query := "INSERT INTO " + TableNameResults + " (session_id, rank, candidate_id, place, votes, nominator_id, nominator_name, track, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
+		for rank, result := range results {
+			track, err := json.Marshal(result.Track)
+			if err != nil {
+				return err
+			}
+
+			_, err = store.exec(ctx, query, sessionId, rank, result.CandidateId, result.Place, result.Votes, result.NominatorId, result.NominatorName, track, result.CreatedAt.Unix())
+			if err != nil {
+				return err
+			}
+		}
+
+		query = "UPDATE " + TableNameSessions + " SET results_frozen_at = ?, results_freeze_failed_at = NULL WHERE id = ?"
+		_, err = store.exec(ctx, query, frozenAt.Unix(), sessionId)
+		return err
+	})
+}
+
+func (store *SqliteStore) MarkResultsFreezeFailed(ctx context.Context, sessionId int64, failedAt time.Time) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE " + TableNameSessions + " SET results_freeze_failed_at = ? WHERE id = ?"
+	_, err := store.exec(ctx, query, failedAt.Unix(), sessionId)
+	return err
+}
+
+// ------------------------------------------------------------
+// | Notification Repository Methods
+// ------------------------------------------------------------
+
+func (store *SqliteStore) IsSessionPhaseNotified(ctx context.Context, sessionId int64, phase core.SessionPhase) (bool, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	var isNotified bool
+	query := "SELECT EXISTS (SELECT 1 FROM " + TableNameSessionNotifications + " WHERE session_id = ? AND phase = ?)"
+	err := store.queryRow(ctx, query, sessionId, phase).Scan(&isNotified)
+	return isNotified, err
+}
+
+func (store *SqliteStore) MarkSessionPhaseNotified(ctx context.Context, sessionId int64, phase core.SessionPhase) (bool, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "INSERT OR IGNORE INTO " + TableNameSessionNotifications + " (session_id, phase, notified_at) VALUES (?, ?, ?)"
+	result, err := store.exec(ctx, query, sessionId, phase, core.Now().Unix())
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (store *SqliteStore) MarkReminderSent(ctx context.Context, sessionId int64, playerId int64, phase core.SessionPhase) (bool, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "INSERT OR IGNORE INTO " + TableNameRemindersSent + " (session_id, player_id, phase, sent_at) VALUES (?, ?, ?, ?)"
+	result, err := store.exec(ctx, query, sessionId, playerId, phase, core.Now().Unix())
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
@@ -0,0 +1,227 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+)
+
+// Sizes of the fixture the benchmarks query, roughly a busy crew's session.
+const (
+	benchUsers       = 20
+	benchSessions    = 10
+	benchSubmissions = 5
+)
+
+type benchFixture struct {
+	store      *SqliteStore
+	users      []*core.UserEntity
+	session    *core.SessionEntity
+	sessionIds []int64
+	candidates []core.CandidateEntity
+}
+
+// newBenchFixture sets up a database in a temp directory with a crew of users playing a few
+// sessions in the vote phase, every player having submitted and voted.
+func newBenchFixture(b *testing.B, options ...SqliteOption) *benchFixture {
+	b.Helper()
+	ctx := context.Background()
+
+	store, err := NewSqliteDb(filepath.Join(b.TempDir(), "bench.db"), options...)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { store.Close() })
+
+	if err := store.Setup(); err != nil {
+		b.Fatal(err)
+	}
+
+	f := &benchFixture{store: store}
+	for i := range benchUsers {
+		user, err := store.CreateUser(ctx, &core.UserEntity{Username: fmt.Sprintf("user%d", i)})
+		if err != nil {
+			b.Fatal(err)
+		}
+		f.users = append(f.users, user)
+	}
+
+	crew, err := store.CreateCrew(ctx, &core.CrewEntity{Name: "Bench", CreatedBy: f.users[0].Id, CreatedAt: time.Now()})
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, user := range f.users {
+		err := store.AddCrewMember(ctx, &core.CrewMemberEntity{CrewId: crew.Id, UserId: user.Id, Role: core.CrewRoleMember, JoinedAt: crew.CreatedAt})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	for i := range benchSessions {
+		session := core.NewSessionEntity(fmt.Sprintf("Bench %d", i), f.users[0].Id,
+			core.WithSessionCrew(crew.Id),
+			core.WithSessionStartAt(time.Now().Add(-36*time.Hour)),
+			core.WithSubmissionDuration(24*time.Hour),
+			core.WithVoteDuration(24*time.Hour),
+		)
+		session.MaxSubmissions = benchSubmissions
+		session, err := store.CreateSession(ctx, session)
+		if err != nil {
+			b.Fatal(err)
+		}
+		f.sessionIds = append(f.sessionIds, session.Id)
+
+		candidates := make([]core.CandidateEntity, 0)
+		for _, user := range f.users {
+			if _, err := store.AddPlayer(ctx, session.Id, &core.PlayerEntity{SessionId: session.Id, PlayerId: user.Id}); err != nil {
+				b.Fatal(err)
+			}
+			for j := range benchSubmissions {
+				candidate, err := store.AddCandidate(ctx, session.Id, &core.CandidateEntity{
+					SessionId:   session.Id,
+					NominatorId: user.Id,
+					TrackId:     fmt.Sprintf("track-%d-%d", user.Id, j),
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+				candidates = append(candidates, *candidate)
+			}
+		}
+
+		for k, user := range f.users {
+			for j := range session.MaxVotes() {
+				candidate := candidates[((k+1)*benchSubmissions+j)%len(candidates)]
+				if candidate.NominatorId == user.Id {
+					continue
+				}
+				_, err := store.AddVote(ctx, session.Id, &core.VoteEntity{SessionId: session.Id, VoterId: user.Id, CandidateId: candidate.Id})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+
+		f.session = session
+		f.candidates = candidates
+	}
+
+	return f
+}
+
+func BenchmarkGetSessionById(b *testing.B) {
+	f := newBenchFixture(b)
+	ctx := context.Background()
+
+	for b.Loop() {
+		if _, err := f.store.GetSessionById(ctx, f.session.Id); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetCrewMemberSessions(b *testing.B) {
+	f := newBenchFixture(b)
+	ctx := context.Background()
+
+	for b.Loop() {
+		if _, err := f.store.GetCrewMemberSessions(ctx, f.users[1].Id); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetPlayers(b *testing.B) {
+	f := newBenchFixture(b)
+	ctx := context.Background()
+
+	for b.Loop() {
+		if _, err := f.store.GetPlayers(ctx, f.session.Id); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetAllCandidates(b *testing.B) {
+	f := newBenchFixture(b)
+	ctx := context.Background()
+
+	for b.Loop() {
+		if _, err := f.store.GetAllCandidates(ctx, f.session.Id); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetVotesByUserId(b *testing.B) {
+	f := newBenchFixture(b)
+	ctx := context.Background()
+
+	for b.Loop() {
+		if _, err := f.store.GetVotesByUserId(ctx, f.session.Id, f.users[1].Id); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetSessionsActivity(b *testing.B) {
+	f := newBenchFixture(b)
+	ctx := context.Background()
+
+	for b.Loop() {
+		if _, err := f.store.GetSessionsActivity(ctx, f.sessionIds); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkToggleVote measures the write a player makes most, casting and taking back a vote.
+func BenchmarkToggleVote(b *testing.B) {
+	f := newBenchFixture(b)
+	ctx := context.Background()
+	voter := f.users[0].Id
+	candidate := f.candidates[len(f.candidates)-1]
+
+	for b.Loop() {
+		_, err := f.store.AddVote(ctx, f.session.Id, &core.VoteEntity{SessionId: f.session.Id, VoterId: voter, CandidateId: candidate.Id})
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := f.store.DeleteVote(ctx, f.session.Id, voter, candidate.Id); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkSessionPageParallel runs the reads of a session page load from many goroutines, like
+// players refreshing the page at once.
+func BenchmarkSessionPageParallel(b *testing.B) {
+	f := newBenchFixture(b, WithJournalMode("WAL"), WithMaxReaderConns(8))
+
+	b.RunParallel(func(pb *testing.PB) {
+		ctx := context.Background()
+		user := f.users[1].Id
+		for pb.Next() {
+			if _, err := f.store.GetSessionById(ctx, f.session.Id); err != nil {
+				b.Error(err)
+				return
+			}
+			if _, err := f.store.GetPlayers(ctx, f.session.Id); err != nil {
+				b.Error(err)
+				return
+			}
+			if _, err := f.store.GetCandidateByNotUserId(ctx, f.session.Id, user); err != nil {
+				b.Error(err)
+				return
+			}
+			if _, err := f.store.GetVotesByUserId(ctx, f.session.Id, user); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
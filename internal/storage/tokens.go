@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/account"
+	"github.com/CaribouBlue/mixtape/internal/core"
+)
+
+// ------------------------------------------------------------
+// | Token Repository Methods
+// ------------------------------------------------------------
+
+const userTokenColumns = "id, user_id, purpose, email, expires_at, used_at, created_at"
+
+func scanUserToken(row rowScanner) (*account.UserToken, error) {
+	token := &account.UserToken{}
+	var email sql.NullString
+	var expiresAt, createdAt int64
+	var usedAt sql.NullInt64
+	err := row.Scan(&token.Id, &token.UserId, &token.Purpose, &email, &expiresAt, &usedAt, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	token.Email = email.String
+	token.ExpiresAt = time.Unix(expiresAt, 0)
+	if usedAt.Valid {
+		t := time.Unix(usedAt.Int64, 0)
+		token.UsedAt = &t
+	}
+	token.CreatedAt = time.Unix(createdAt, 0)
+
+	return token, nil
+}
+
+func (store *SqliteStore) CreateUserToken(ctx context.Context, token *account.UserToken) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "INSERT INTO " + TableNameUserTokens + " (id, user_id, purpose, email, ip_address, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	_, err := store.exec(ctx, query, token.Id, token.UserId, token.Purpose, token.Email, token.IpAddress, token.ExpiresAt.Unix(), token.CreatedAt.Unix())
+	return err
+}
+
+func (store *SqliteStore) ConsumeUserToken(ctx context.Context, tokenId string, purpose account.TokenPurpose, now time.Time) (*account.UserToken, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE " + TableNameUserTokens + " SET used_at = ? WHERE id = ? AND purpose = ? AND used_at IS NULL AND expires_at > ? " +
+		"RETURNING " + userTokenColumns
+	stmt, err := store.writeStmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := scanUserToken(stmt.QueryRowContext(ctx, now.Unix(), tokenId, purpose, now.Unix()))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		logCanceled(ctx, query, err)
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func (store *SqliteStore) RevokeUserTokens(ctx context.Context, userId int64, purpose account.TokenPurpose) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE " + TableNameUserTokens + " SET used_at = ? WHERE user_id = ? AND purpose = ? AND used_at IS NULL"
+	_, err := store.exec(ctx, query, core.Now().Unix(), userId, purpose)
+	return err
+}
+
+func (store *SqliteStore) CountUserTokensSince(ctx context.Context, userId int64, purpose account.TokenPurpose, since time.Time) (int, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	var count int
+	query := "SELECT COUNT(*) FROM " + TableNameUserTokens + " WHERE user_id = ? AND purpose = ? AND created_at >= ?"
+	err := store.queryRow(ctx, query, userId, purpose, since.Unix()).Scan(&count)
+	return count, err
+}
+
+func (store *SqliteStore) CountIpTokensSince(ctx context.Context, ipAddress string, purpose account.TokenPurpose, since time.Time) (int, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	var count int
+	query := "SELECT COUNT(*) FROM " + TableNameUserTokens + " WHERE ip_address = ? AND purpose = ? AND created_at >= ?"
+	err := store.queryRow(ctx, query, ipAddress, purpose, since.Unix()).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,255 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/webhook"
+)
+
+// ------------------------------------------------------------
+// | Webhook Repository Methods
+// ------------------------------------------------------------
+
+const webhookColumns = "id, url, secret, description, events, is_active, created_at, updated_at"
+
+func scanWebhook(row rowScanner) (*webhook.Webhook, error) {
+	hook := &webhook.Webhook{}
+	var events string
+	var createdAt, updatedAt int64
+	err := row.Scan(&hook.Id, &hook.Url, &hook.Secret, &hook.Description, &events, &hook.IsActive, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	hook.Events = make([]webhook.EventType, 0)
+	if events != "" {
+		for _, event := range strings.Split(events, ",") {
+			hook.Events = append(hook.Events, webhook.EventType(event))
+		}
+	}
+	hook.CreatedAt = time.Unix(createdAt, 0)
+	hook.UpdatedAt = time.Unix(updatedAt, 0)
+
+	return hook, nil
+}
+
+func (store *SqliteStore) CreateWebhook(ctx context.Context, hook *webhook.Webhook) (*webhook.Webhook, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	events := make([]string, len(hook.Events))
+	for i, event := range hook.Events {
+		events[i] = string(event)
+	}
+
+	now := core.Now().Unix()
+	query := "INSERT INTO " + TableNameWebhooks + " (url, secret, description, events, is_active, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?) " +
+		"RETURNING " + webhookColumns
+	stmt, err := store.writeStmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanWebhook(stmt.QueryRowContext(ctx, hook.Url, hook.Secret, hook.Description, strings.Join(events, ","), hook.IsActive, now, now))
+}
+
+func (store *SqliteStore) GetWebhook(ctx context.Context, webhookId int64) (*webhook.Webhook, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + webhookColumns + " FROM " + TableNameWebhooks + " WHERE id = ?"
+	hook, err := scanWebhook(store.queryRow(ctx, query, webhookId))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return hook, err
+}
+
+func (store *SqliteStore) GetWebhooks(ctx context.Context) ([]webhook.Webhook, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + webhookColumns + " FROM " + TableNameWebhooks + " ORDER BY id"
+	rows, err := store.query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hooks := make([]webhook.Webhook, 0)
+	for rows.Next() {
+		hook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, *hook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return hooks, nil
+}
+
+func (store *SqliteStore) SetWebhookActive(ctx context.Context, webhookId int64, isActive bool) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE " + TableNameWebhooks + " SET is_active = ?, updated_at = ? WHERE id = ?"
+	_, err := store.exec(ctx, query, isActive, core.Now().Unix(), webhookId)
+	return err
+}
+
+func (store *SqliteStore) DeleteWebhook(ctx context.Context, webhookId int64) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	return store.InTransaction(ctx, func(ctx context.Context) error {
+		// The delivery log references the webhook, so it goes first.
+		if _, err := store.exec(ctx, "DELETE FROM "+TableNameWebhookDeliveries+" WHERE webhook_id = ?", webhookId); err != nil {
+			return err
+		}
+
+		_, err := store.exec(ctx, "DELETE FROM "+TableNameWebhooks+" WHERE id = ?", webhookId)
+		return err
+	})
+}
+
+const deliveryColumns = "id, webhook_id, event_id, event_type, payload, status, attempts, max_attempts, next_attempt_at, response_status, response_body, last_error, delivered_at, created_at, updated_at"
+
+func scanDelivery(row rowScanner) (*webhook.Delivery, error) {
+	delivery := &webhook.Delivery{}
+	var nextAttemptAt, createdAt, updatedAt int64
+	var responseStatus, deliveredAt sql.NullInt64
+	var responseBody, lastError sql.NullString
+	err := row.Scan(&delivery.Id, &delivery.WebhookId, &delivery.EventId, &delivery.EventType, &delivery.Payload, &delivery.Status, &delivery.Attempts, &delivery.MaxAttempts, &nextAttemptAt, &responseStatus, &responseBody, &lastError, &deliveredAt, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.NextAttemptAt = time.Unix(nextAttemptAt, 0)
+	delivery.ResponseStatus = int(responseStatus.Int64)
+	delivery.ResponseBody = responseBody.String
+	delivery.LastError = lastError.String
+	if deliveredAt.Valid {
+		t := time.Unix(deliveredAt.Int64, 0)
+		delivery.DeliveredAt = &t
+	}
+	delivery.CreatedAt = time.Unix(createdAt, 0)
+	delivery.UpdatedAt = time.Unix(updatedAt, 0)
+
+	return delivery, nil
+}
+
+func scanDeliveries(rows *sql.Rows) ([]webhook.Delivery, error) {
+	deliveries := make([]webhook.Delivery, 0)
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (store *SqliteStore) CreateDelivery(ctx context.Context, delivery *webhook.Delivery) (*webhook.Delivery, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	now := core.Now().Unix()
+	query := "INSERT INTO " + TableNameWebhookDeliveries + " (webhook_id, event_id, event_type, payload, status, attempts, max_attempts, next_attempt_at, created_at, updated_at) VALUES (?, ?, ?, ?, ?, 0, ?, ?, ?, ?) " +
+		"RETURNING " + deliveryColumns
+	stmt, err := store.writeStmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanDelivery(stmt.QueryRowContext(ctx, delivery.WebhookId, delivery.EventId, delivery.EventType, string(delivery.Payload), delivery.Status, delivery.MaxAttempts, delivery.NextAttemptAt.Unix(), now, now))
+}
+
+func (store *SqliteStore) GetDueDeliveries(ctx context.Context, now time.Time, limit int) ([]webhook.Delivery, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + deliveryColumns + " FROM " + TableNameWebhookDeliveries + " WHERE status = ? AND next_attempt_at <= ? ORDER BY next_attempt_at LIMIT ?"
+	rows, err := store.query(ctx, query, webhook.DeliveryStatusPending, now.Unix(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanDeliveries(rows)
+}
+
+func (store *SqliteStore) RecordDeliveryAttempt(ctx context.Context, attempt webhook.DeliveryAttempt) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	status := webhook.DeliveryStatusFailed
+	var nextAttemptAt, deliveredAt sql.NullInt64
+	if attempt.DeliveredAt != nil {
+		status = webhook.DeliveryStatusSucceeded
+		deliveredAt = sql.NullInt64{Int64: attempt.DeliveredAt.Unix(), Valid: true}
+	} else if attempt.RetryAt != nil {
+		status = webhook.DeliveryStatusPending
+		nextAttemptAt = sql.NullInt64{Int64: attempt.RetryAt.Unix(), Valid: true}
+	}
+
+	var responseStatus sql.NullInt64
+	if attempt.ResponseStatus != 0 {
+		responseStatus = sql.NullInt64{Int64: int64(attempt.ResponseStatus), Valid: true}
+	}
+
+	var lastError sql.NullString
+	if attempt.Error != "" {
+		lastError = sql.NullString{String: attempt.Error, Valid: true}
+	}
+
+	query := "UPDATE " + TableNameWebhookDeliveries + " SET status = ?, attempts = attempts + 1, next_attempt_at = COALESCE(?, next_attempt_at), response_status = ?, response_body = ?, last_error = ?, delivered_at = ?, updated_at = ? WHERE id = ?"
+	_, err := store.exec(ctx, query, status, nextAttemptAt, responseStatus, attempt.ResponseBody, lastError, deliveredAt, core.Now().Unix(), attempt.DeliveryId)
+	return err
+}
+
+func (store *SqliteStore) RedeliverDelivery(ctx context.Context, deliveryId int64) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	now := core.Now().Unix()
+	query := "UPDATE " + TableNameWebhookDeliveries + " SET status = ?, attempts = 0, next_attempt_at = ?, updated_at = ? WHERE id = ? AND status != ?"
+	result, err := store.exec(ctx, query, webhook.DeliveryStatusPending, now, now, deliveryId, webhook.DeliveryStatusPending)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	} else if rowsAffected == 0 {
+		return webhook.ErrDeliveryNotFound
+	}
+
+	return nil
+}
+
+func (store *SqliteStore) GetDeliveries(ctx context.Context, webhookId int64, limit int) ([]webhook.Delivery, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + deliveryColumns + " FROM " + TableNameWebhookDeliveries + " WHERE webhook_id = ? ORDER BY id DESC LIMIT ?"
+	rows, err := store.query(ctx, query, webhookId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanDeliveries(rows)
+}
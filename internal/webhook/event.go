@@ -0,0 +1,132 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+)
+
+// Event is the JSON body of every webhook request. The ID is shared by all deliveries of the same
+// event, so receivers can use it to ignore retries they've already handled.
+type Event struct {
+	Id        string    `json:"id"`
+	Type      EventType `json:"type"`
+	CreatedAt time.Time `json:"createdAt"`
+	Data      any       `json:"data"`
+}
+
+func NewEvent(eventType EventType, data any) (*Event, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	return &Event{
+		Id:        "evt_" + hex.EncodeToString(b),
+		Type:      eventType,
+		CreatedAt: core.Now().UTC().Truncate(time.Second),
+		Data:      data,
+	}, nil
+}
+
+type SessionData struct {
+	Id             int64     `json:"id"`
+	Name           string    `json:"name"`
+	Url            string    `json:"url"`
+	Phase          string    `json:"phase"`
+	CreatedBy      int64     `json:"createdBy"`
+	MaxSubmissions int       `json:"maxSubmissions"`
+	StartAt        time.Time `json:"startAt"`
+	VotingStartAt  time.Time `json:"votingStartAt"`
+	ResultsStartAt time.Time `json:"resultsStartAt"`
+}
+
+func newSessionData(baseUrl string, session *core.SessionEntity) SessionData {
+	return SessionData{
+		Id:             session.Id,
+		Name:           session.Name,
+		Url:            fmt.Sprintf("%s/app/session/%d", baseUrl, session.Id),
+		Phase:          string(session.Phase()),
+		CreatedBy:      session.CreatedBy,
+		MaxSubmissions: session.MaxSubmissions,
+		StartAt:        session.StartAt.UTC(),
+		VotingStartAt:  session.PhaseStartAt(core.VotePhase).UTC(),
+		ResultsStartAt: session.PhaseStartAt(core.ResultPhase).UTC(),
+	}
+}
+
+type PlayerData struct {
+	UserId      int64  `json:"userId"`
+	DisplayName string `json:"displayName"`
+}
+
+func newPlayerData(user *core.UserEntity) PlayerData {
+	return PlayerData{
+		UserId:      user.Id,
+		DisplayName: user.DisplayName,
+	}
+}
+
+type TrackData struct {
+	Id      string   `json:"id"`
+	Name    string   `json:"name"`
+	Artists []string `json:"artists"`
+	Album   string   `json:"album"`
+	Url     string   `json:"url"`
+}
+
+type ResultData struct {
+	Place     int        `json:"place"`
+	Votes     int        `json:"votes"`
+	Nominator PlayerData `json:"nominator"`
+	Track     TrackData  `json:"track"`
+}
+
+func newResultData(result *core.ResultEntity) ResultData {
+	artists := make([]string, len(result.Track.Artists))
+	for i, artist := range result.Track.Artists {
+		artists[i] = artist.Name
+	}
+
+	return ResultData{
+		Place: result.Place,
+		Votes: result.Votes,
+		Nominator: PlayerData{
+			UserId:      result.NominatorId,
+			DisplayName: result.NominatorName,
+		},
+		Track: TrackData{
+			Id:      result.Track.Id,
+			Name:    result.Track.Name,
+			Artists: artists,
+			Album:   result.Track.Album.Name,
+			Url:     result.Track.Url,
+		},
+	}
+}
+
+type SessionEventData struct {
+	Session SessionData `json:"session"`
+}
+
+type PhaseChangedEventData struct {
+	Session SessionData `json:"session"`
+	Phase   string      `json:"phase"`
+}
+
+type PlayerEventData struct {
+	Session SessionData `json:"session"`
+	Player  PlayerData  `json:"player"`
+}
+
+type ResultsEventData struct {
+	Session SessionData  `json:"session"`
+	Results []ResultData `json:"results"`
+}
+
+type PingEventData struct {
+	Message string `json:"message"`
+}
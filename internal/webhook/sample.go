@@ -0,0 +1,57 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+)
+
+// SampleEvent returns an event of the given type filled with made-up data, for testing receivers
+// without running a session.
+func SampleEvent(baseUrl string, eventType EventType) (*Event, error) {
+	if eventType == EventPing {
+		return NewEvent(eventType, PingEventData{Message: "Test event from Mixtape"})
+	} else if !IsEventType(string(eventType)) {
+		return nil, ErrInvalidEventType
+	}
+
+	// Results are sampled from a finished session so the phase in the payload matches the event.
+	startAt := time.Now().Truncate(time.Second).Add(-time.Hour)
+	if eventType == EventResultsPublished {
+		startAt = startAt.Add(-11 * 24 * time.Hour)
+	}
+
+	session := core.NewSessionEntity("Sample Session", 1, core.WithSessionStartAt(startAt))
+	session.Id = 1
+	sessionData := newSessionData(baseUrl, session)
+	player := PlayerData{UserId: 2, DisplayName: "Sample Player"}
+
+	var data any
+	switch eventType {
+	case EventSessionCreated:
+		data = SessionEventData{Session: sessionData}
+	case EventSessionPhaseChanged:
+		data = PhaseChangedEventData{Session: sessionData, Phase: sessionData.Phase}
+	case EventPlayerJoined, EventSubmissionsFinalized:
+		data = PlayerEventData{Session: sessionData, Player: player}
+	case EventResultsPublished:
+		data = ResultsEventData{
+			Session: sessionData,
+			Results: []ResultData{
+				{
+					Place:     1,
+					Votes:     3,
+					Nominator: player,
+					Track: TrackData{
+						Id:      "sample-track",
+						Name:    "Sample Track",
+						Artists: []string{"Sample Artist"},
+						Album:   "Sample Album",
+					},
+				},
+			},
+		}
+	}
+
+	return NewEvent(eventType, data)
+}
@@ -0,0 +1,455 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/CaribouBlue/mixtape/internal/core"
+	"github.com/CaribouBlue/mixtape/internal/log"
+	"github.com/CaribouBlue/mixtape/internal/scheduler"
+)
+
+type WebhookServiceOpts struct {
+	// BaseUrl is used to build the links to sessions included in payloads.
+	BaseUrl     string
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// BatchSize is how many deliveries are sent each time pending deliveries are checked.
+	BatchSize      int
+	RequestTimeout time.Duration
+}
+
+type WebhookServiceOption func(*WebhookServiceOpts)
+
+func WithBaseUrl(baseUrl string) WebhookServiceOption {
+	return func(opts *WebhookServiceOpts) {
+		opts.BaseUrl = baseUrl
+	}
+}
+
+func WithMaxAttempts(maxAttempts int) WebhookServiceOption {
+	return func(opts *WebhookServiceOpts) {
+		opts.MaxAttempts = maxAttempts
+	}
+}
+
+func WithBackoff(base, max time.Duration) WebhookServiceOption {
+	return func(opts *WebhookServiceOpts) {
+		opts.BaseBackoff = base
+		opts.MaxBackoff = max
+	}
+}
+
+func WithBatchSize(batchSize int) WebhookServiceOption {
+	return func(opts *WebhookServiceOpts) {
+		opts.BatchSize = batchSize
+	}
+}
+
+func WithRequestTimeout(timeout time.Duration) WebhookServiceOption {
+	return func(opts *WebhookServiceOpts) {
+		opts.RequestTimeout = timeout
+	}
+}
+
+type WebhookService struct {
+	opts       WebhookServiceOpts
+	repository WebhookRepository
+	client     *http.Client
+}
+
+func NewWebhookService(repository WebhookRepository, options ...WebhookServiceOption) *WebhookService {
+	opts := WebhookServiceOpts{
+		MaxAttempts:    6,
+		BaseBackoff:    30 * time.Second,
+		MaxBackoff:     3 * time.Hour,
+		BatchSize:      50,
+		RequestTimeout: 10 * time.Second,
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	return &WebhookService{
+		opts:       opts,
+		repository: repository,
+		client:     NewClient(opts.RequestTimeout),
+	}
+}
+
+// NewClient returns an HTTP client suitable for sending webhooks. Redirects aren't followed, so a
+// receiver that moved shows up as a failed delivery instead of silently posting somewhere else.
+func NewClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// NewRequest builds a signed webhook request carrying payload.
+func NewRequest(ctx context.Context, webhookUrl string, secret string, eventType EventType, deliveryId string, payload []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookUrl, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Mixtape-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, string(eventType))
+	req.Header.Set(HeaderDelivery, deliveryId)
+	req.Header.Set(HeaderSignature, Sign(secret, core.Now(), payload))
+
+	return req, nil
+}
+
+func validateUrl(webhookUrl string) error {
+	u, err := url.Parse(webhookUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidUrl
+	}
+	return nil
+}
+
+func (s *WebhookService) CreateWebhook(ctx context.Context, webhookUrl string, description string, events []EventType) (*Webhook, error) {
+	if err := validateUrl(webhookUrl); err != nil {
+		return nil, err
+	}
+
+	for _, event := range events {
+		if !IsEventType(string(event)) {
+			return nil, ErrInvalidEventType
+		}
+	}
+
+	secret, err := NewSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repository.CreateWebhook(ctx, &Webhook{
+		Url:         webhookUrl,
+		Secret:      secret,
+		Description: description,
+		Events:      events,
+		IsActive:    true,
+	})
+}
+
+func (s *WebhookService) GetWebhook(ctx context.Context, webhookId int64) (*Webhook, error) {
+	webhook, err := s.repository.GetWebhook(ctx, webhookId)
+	if err != nil {
+		return nil, err
+	} else if webhook == nil {
+		return nil, ErrWebhookNotFound
+	}
+	return webhook, nil
+}
+
+func (s *WebhookService) GetWebhooks(ctx context.Context) ([]Webhook, error) {
+	return s.repository.GetWebhooks(ctx)
+}
+
+func (s *WebhookService) SetWebhookActive(ctx context.Context, webhookId int64, isActive bool) (*Webhook, error) {
+	if _, err := s.GetWebhook(ctx, webhookId); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.SetWebhookActive(ctx, webhookId, isActive); err != nil {
+		return nil, err
+	}
+
+	return s.GetWebhook(ctx, webhookId)
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, webhookId int64) error {
+	if _, err := s.GetWebhook(ctx, webhookId); err != nil {
+		return err
+	}
+	return s.repository.DeleteWebhook(ctx, webhookId)
+}
+
+func (s *WebhookService) GetDeliveries(ctx context.Context, webhookId int64, limit int) ([]Delivery, error) {
+	return s.repository.GetDeliveries(ctx, webhookId, limit)
+}
+
+func (s *WebhookService) RedeliverDelivery(ctx context.Context, deliveryId int64) error {
+	return s.repository.RedeliverDelivery(ctx, deliveryId)
+}
+
+// Publish queues an event for every active webhook subscribed to it. Called inside a transaction,
+// the event is only delivered if the transaction commits.
+func (s *WebhookService) Publish(ctx context.Context, eventType EventType, data any) error {
+	webhooks, err := s.repository.GetWebhooks(ctx)
+	if err != nil {
+		return err
+	}
+
+	var event *Event
+	var payload []byte
+	for _, webhook := range webhooks {
+		if !webhook.IsActive || !webhook.IsSubscribed(eventType) {
+			continue
+		}
+
+		// Every subscriber gets the same event, so it is only built once there is one.
+		if event == nil {
+			event, err = NewEvent(eventType, data)
+			if err != nil {
+				return err
+			}
+
+			payload, err = json.Marshal(event)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err := s.repository.CreateDelivery(ctx, &Delivery{
+			WebhookId:     webhook.Id,
+			EventId:       event.Id,
+			EventType:     eventType,
+			Payload:       payload,
+			Status:        DeliveryStatusPending,
+			MaxAttempts:   s.opts.MaxAttempts,
+			NextAttemptAt: core.Now(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Subscribe queues webhook events for the domain events they mirror. The subscribers are sync, so
+// a delivery is queued in the same transaction as the change that caused it.
+func (s *WebhookService) Subscribe(bus *core.EventBus) {
+	core.On(bus, func(ctx context.Context, event core.SessionCreatedEvent) error {
+		return s.PublishSessionCreated(ctx, &event.Session)
+	})
+	core.On(bus, func(ctx context.Context, event core.PhaseChangedEvent) error {
+		return s.PublishSessionPhaseChanged(ctx, &event.Session, event.Phase)
+	})
+	core.On(bus, func(ctx context.Context, event core.PlayerJoinedEvent) error {
+		return s.PublishPlayerJoined(ctx, &event.Session, &event.User)
+	})
+	core.On(bus, func(ctx context.Context, event core.SubmissionsFinalizedEvent) error {
+		return s.PublishSubmissionsFinalized(ctx, &event.Session, &event.User)
+	})
+	core.On(bus, func(ctx context.Context, event core.ResultsPublishedEvent) error {
+		return s.PublishResults(ctx, &event.Session, event.Results)
+	})
+}
+
+func (s *WebhookService) PublishSessionCreated(ctx context.Context, session *core.SessionEntity) error {
+	return s.Publish(ctx, EventSessionCreated, SessionEventData{
+		Session: newSessionData(s.opts.BaseUrl, session),
+	})
+}
+
+func (s *WebhookService) PublishSessionPhaseChanged(ctx context.Context, session *core.SessionEntity, phase core.SessionPhase) error {
+	return s.Publish(ctx, EventSessionPhaseChanged, PhaseChangedEventData{
+		Session: newSessionData(s.opts.BaseUrl, session),
+		Phase:   string(phase),
+	})
+}
+
+func (s *WebhookService) PublishPlayerJoined(ctx context.Context, session *core.SessionEntity, user *core.UserEntity) error {
+	return s.Publish(ctx, EventPlayerJoined, PlayerEventData{
+		Session: newSessionData(s.opts.BaseUrl, session),
+		Player:  newPlayerData(user),
+	})
+}
+
+func (s *WebhookService) PublishSubmissionsFinalized(ctx context.Context, session *core.SessionEntity, user *core.UserEntity) error {
+	return s.Publish(ctx, EventSubmissionsFinalized, PlayerEventData{
+		Session: newSessionData(s.opts.BaseUrl, session),
+		Player:  newPlayerData(user),
+	})
+}
+
+func (s *WebhookService) PublishResults(ctx context.Context, session *core.SessionEntity, results []core.ResultEntity) error {
+	data := ResultsEventData{
+		Session: newSessionData(s.opts.BaseUrl, session),
+		Results: make([]ResultData, len(results)),
+	}
+	for i, result := range results {
+		data.Results[i] = newResultData(&result)
+	}
+
+	return s.Publish(ctx, EventResultsPublished, data)
+}
+
+// TestWebhook sends a ping event to a webhook right away, whether or not it is active, and returns
+// the logged delivery. Test pings aren't retried.
+func (s *WebhookService) TestWebhook(ctx context.Context, webhookId int64) (*Delivery, error) {
+	webhook, err := s.GetWebhook(ctx, webhookId)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := NewEvent(EventPing, PingEventData{Message: "Test event from Mixtape"})
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.repository.CreateDelivery(ctx, &Delivery{
+		WebhookId:     webhook.Id,
+		EventId:       event.Id,
+		EventType:     EventPing,
+		Payload:       payload,
+		Status:        DeliveryStatusPending,
+		MaxAttempts:   1,
+		NextAttemptAt: core.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	attempt := s.send(ctx, webhook, delivery)
+	if err := s.repository.RecordDeliveryAttempt(ctx, attempt); err != nil {
+		return nil, err
+	}
+
+	delivery.Attempts++
+	delivery.ResponseStatus = attempt.ResponseStatus
+	delivery.ResponseBody = attempt.ResponseBody
+	delivery.LastError = attempt.Error
+	delivery.DeliveredAt = attempt.DeliveredAt
+	if attempt.DeliveredAt != nil {
+		delivery.Status = DeliveryStatusSucceeded
+	} else {
+		delivery.Status = DeliveryStatusFailed
+	}
+
+	return delivery, nil
+}
+
+const JobDeliverWebhooks = "webhook.deliver"
+
+// RegisterJobs schedules webhook delivery to run every interval.
+func (s *WebhookService) RegisterJobs(ctx context.Context, jobScheduler *scheduler.Scheduler, interval time.Duration) error {
+	jobScheduler.Register(JobDeliverWebhooks, func(ctx context.Context, job *scheduler.Job) error {
+		return s.DeliverPending(ctx)
+	})
+
+	_, err := jobScheduler.Every(ctx, JobDeliverWebhooks, interval, nil)
+	return err
+}
+
+// DeliverPending sends the deliveries that are due. Failed deliveries are retried with exponential
+// backoff until they run out of attempts.
+func (s *WebhookService) DeliverPending(ctx context.Context) error {
+	deliveries, err := s.repository.GetDueDeliveries(ctx, core.Now(), s.opts.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	webhooks := make(map[int64]*Webhook)
+	for _, delivery := range deliveries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		webhook, ok := webhooks[delivery.WebhookId]
+		if !ok {
+			webhook, err = s.repository.GetWebhook(ctx, delivery.WebhookId)
+			if err != nil {
+				return err
+			}
+			webhooks[delivery.WebhookId] = webhook
+		}
+
+		if err := s.deliver(ctx, webhook, &delivery); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *WebhookService) deliver(ctx context.Context, webhook *Webhook, delivery *Delivery) error {
+	logger := log.Logger().With().Int64("deliveryId", delivery.Id).Int64("webhookId", delivery.WebhookId).Str("event", string(delivery.EventType)).Int("attempt", delivery.Attempts+1).Logger()
+
+	if webhook == nil || !webhook.IsActive {
+		logger.Info().Msg("Dropping webhook delivery for a disabled webhook")
+		return s.repository.RecordDeliveryAttempt(ctx, DeliveryAttempt{
+			DeliveryId: delivery.Id,
+			Error:      "webhook is disabled",
+		})
+	}
+
+	attempt := s.send(ctx, webhook, delivery)
+	if attempt.DeliveredAt != nil {
+		logger.Debug().Int("status", attempt.ResponseStatus).Msg("Delivered webhook")
+		return s.repository.RecordDeliveryAttempt(ctx, attempt)
+	}
+
+	if delivery.Attempts+1 < delivery.MaxAttempts {
+		next := core.Now().Add(s.backoff(delivery.Attempts + 1))
+		attempt.RetryAt = &next
+		logger.Warn().Str("error", attempt.Error).Time("retryAt", next).Msg("Failed to deliver webhook, retrying")
+	} else {
+		logger.Error().Str("error", attempt.Error).Msg("Failed to deliver webhook, giving up")
+	}
+
+	return s.repository.RecordDeliveryAttempt(ctx, attempt)
+}
+
+// maxResponseBody is how much of a receiver's response is kept in the delivery log.
+const maxResponseBody = 1024
+
+// send makes a single delivery attempt. Any 2xx response counts as delivered.
+func (s *WebhookService) send(ctx context.Context, webhook *Webhook, delivery *Delivery) DeliveryAttempt {
+	attempt := DeliveryAttempt{DeliveryId: delivery.Id}
+
+	req, err := NewRequest(ctx, webhook.Url, webhook.Secret, delivery.EventType, strconv.FormatInt(delivery.Id, 10), delivery.Payload)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(res.Body, maxResponseBody))
+	attempt.ResponseStatus = res.StatusCode
+	attempt.ResponseBody = string(body)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		attempt.Error = fmt.Sprintf("unexpected response status %s", res.Status)
+		return attempt
+	}
+
+	now := core.Now()
+	attempt.DeliveredAt = &now
+	return attempt
+}
+
+// backoff doubles the delay with every attempt, starting at BaseBackoff and capped at MaxBackoff.
+func (s *WebhookService) backoff(attempts int) time.Duration {
+	delay := s.opts.BaseBackoff
+	for i := 1; i < attempts && delay < s.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, s.opts.MaxBackoff)
+}
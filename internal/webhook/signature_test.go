@@ -0,0 +1,76 @@
+package webhook
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	const secret = "whsec_test"
+	payload := []byte(`{"type":"session.created"}`)
+	now := time.Now()
+	signature := Sign(secret, now, payload)
+
+	tests := []struct {
+		name      string
+		secret    string
+		header    string
+		payload   []byte
+		tolerance time.Duration
+		want      error
+	}{
+		{"valid", secret, signature, payload, 5 * time.Minute, nil},
+		{"no tolerance", secret, Sign(secret, now.Add(-time.Hour), payload), payload, 0, nil},
+		{"spaces between parts", secret, fmt.Sprintf("t=%d, v1=%s", now.Unix(), computeSignature(secret, fmt.Sprint(now.Unix()), payload)), payload, time.Minute, nil},
+		{"one of several signatures", secret, signature + ",v1=deadbeef", payload, time.Minute, nil},
+		{"wrong secret", "whsec_other", signature, payload, time.Minute, ErrInvalidSignature},
+		{"tampered payload", secret, signature, []byte(`{"type":"session.deleted"}`), time.Minute, ErrInvalidSignature},
+		{"too old", secret, Sign(secret, now.Add(-10*time.Minute), payload), payload, 5 * time.Minute, ErrSignatureExpired},
+		{"too far ahead", secret, Sign(secret, now.Add(10*time.Minute), payload), payload, 5 * time.Minute, ErrSignatureExpired},
+		{"replayed with a new timestamp", secret, fmt.Sprintf("t=%d,v1=%s", now.Unix()+1, computeSignature(secret, fmt.Sprint(now.Unix()), payload)), payload, time.Minute, ErrInvalidSignature},
+		{"missing timestamp", secret, "v1=" + computeSignature(secret, fmt.Sprint(now.Unix()), payload), payload, time.Minute, ErrInvalidSignature},
+		{"missing signature", secret, fmt.Sprintf("t=%d", now.Unix()), payload, time.Minute, ErrInvalidSignature},
+		{"malformed timestamp", secret, "t=soon,v1=deadbeef", payload, time.Minute, ErrInvalidSignature},
+		{"empty header", secret, "", payload, time.Minute, ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Verify(tt.secret, tt.header, tt.payload, tt.tolerance); err != tt.want {
+				t.Errorf("Verify() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSign(t *testing.T) {
+	timestamp := time.Unix(1700000000, 0)
+	payload := []byte("{}")
+
+	tests := []struct {
+		name    string
+		secret  string
+		payload []byte
+		time    time.Time
+		same    bool
+	}{
+		{"same input", "whsec_test", payload, timestamp, true},
+		{"other secret", "whsec_other", payload, timestamp, false},
+		{"other payload", "whsec_test", []byte("[]"), timestamp, false},
+		{"other timestamp", "whsec_test", payload, timestamp.Add(time.Second), false},
+	}
+
+	want := Sign("whsec_test", timestamp, payload)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Sign(tt.secret, tt.time, tt.payload)
+			if (got == want) != tt.same {
+				t.Errorf("Sign() = %q, compared to %q", got, want)
+			}
+			if err := Verify(tt.secret, got, tt.payload, 0); err != nil {
+				t.Errorf("Verify() of own signature = %v", err)
+			}
+		})
+	}
+}
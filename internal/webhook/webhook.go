@@ -0,0 +1,114 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventSessionCreated       EventType = "session.created"
+	EventSessionPhaseChanged  EventType = "session.phase_changed"
+	EventPlayerJoined         EventType = "player.joined"
+	EventSubmissionsFinalized EventType = "submissions.finalized"
+	EventResultsPublished     EventType = "results.published"
+	// EventPing is only sent by the admin test button and the CLI, never by the app itself.
+	EventPing EventType = "ping"
+)
+
+// EventTypes lists the events a webhook can subscribe to.
+var EventTypes = []EventType{
+	EventSessionCreated,
+	EventSessionPhaseChanged,
+	EventPlayerJoined,
+	EventSubmissionsFinalized,
+	EventResultsPublished,
+}
+
+func IsEventType(value string) bool {
+	return slices.Contains(EventTypes, EventType(value))
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryStatusPending   DeliveryStatus = "pending"
+	DeliveryStatusSucceeded DeliveryStatus = "succeeded"
+	DeliveryStatusFailed    DeliveryStatus = "failed"
+)
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrInvalidUrl       = errors.New("webhook url must be an absolute http or https url")
+	ErrInvalidEventType = errors.New("invalid webhook event type")
+)
+
+type Webhook struct {
+	Id          int64
+	Url         string
+	Secret      string
+	Description string
+	// Events the webhook is subscribed to. An empty list subscribes to every event.
+	Events    []EventType
+	IsActive  bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (w *Webhook) IsSubscribed(eventType EventType) bool {
+	return len(w.Events) == 0 || slices.Contains(w.Events, eventType)
+}
+
+// Delivery is an event queued for a webhook, along with the outcome of its latest attempt.
+type Delivery struct {
+	Id             int64
+	WebhookId      int64
+	EventId        string
+	EventType      EventType
+	Payload        []byte
+	Status         DeliveryStatus
+	Attempts       int
+	MaxAttempts    int
+	NextAttemptAt  time.Time
+	ResponseStatus int
+	ResponseBody   string
+	LastError      string
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// DeliveryAttempt is the outcome of sending a delivery once.
+type DeliveryAttempt struct {
+	DeliveryId     int64
+	ResponseStatus int
+	ResponseBody   string
+	Error          string
+	// DeliveredAt is set when the attempt succeeded.
+	DeliveredAt *time.Time
+	// RetryAt is set when a failed attempt should be retried.
+	RetryAt *time.Time
+}
+
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, webhook *Webhook) (*Webhook, error)
+	// GetWebhook returns nil when no webhook has the given ID.
+	GetWebhook(ctx context.Context, webhookId int64) (*Webhook, error)
+	GetWebhooks(ctx context.Context) ([]Webhook, error)
+	SetWebhookActive(ctx context.Context, webhookId int64, isActive bool) error
+	// DeleteWebhook removes a webhook along with its delivery log.
+	DeleteWebhook(ctx context.Context, webhookId int64) error
+
+	CreateDelivery(ctx context.Context, delivery *Delivery) (*Delivery, error)
+	// GetDueDeliveries returns up to limit pending deliveries whose next attempt is due.
+	GetDueDeliveries(ctx context.Context, now time.Time, limit int) ([]Delivery, error)
+	RecordDeliveryAttempt(ctx context.Context, attempt DeliveryAttempt) error
+	// RedeliverDelivery resets a finished delivery so it is sent again as soon as possible.
+	RedeliverDelivery(ctx context.Context, deliveryId int64) error
+	// GetDeliveries returns a webhook's most recent deliveries, newest first.
+	GetDeliveries(ctx context.Context, webhookId int64, limit int) ([]Delivery, error)
+}